@@ -22,6 +22,8 @@ type ApiCommand struct {
 	ServerFlagUrl     string            `short:"s" long:"server" description:"URI of API server to target" env:"CREDHUB_SERVER"`
 	CaCerts           []string          `long:"ca-cert" description:"Trusted CA for API and UAA TLS connections. Multiple flags may be provided." env:"CREDHUB_CA_CERT"`
 	SkipTlsValidation bool              `long:"skip-tls-validation" description:"Skip certificate validation of the API endpoint. Not recommended!"`
+	Proxy             string            `long:"proxy" description:"HTTP(S) or SOCKS5 proxy for API and UAA connections of this target, overriding CREDHUB_PROXY"`
+	NoProxy           string            `long:"no-proxy" description:"Comma-separated hosts to connect to without the proxy of this target"`
 	ConfigCommand
 }
 
@@ -50,8 +52,12 @@ func (c *ApiCommand) Execute([]string) error {
 	newConfig.CaCerts = caCerts
 	newConfig.InsecureSkipVerify = c.SkipTlsValidation
 	newConfig.HttpTimeout = c.config.HttpTimeout
+	newConfig.Proxy, newConfig.NoProxy = c.Proxy, c.NoProxy
+	if newConfig.Proxy == "" && newConfig.NoProxy == "" && newConfig.ApiURL == c.config.ApiURL {
+		newConfig.Proxy, newConfig.NoProxy = c.config.Proxy, c.config.NoProxy
+	}
 
-	credhubInfo, err := GetApiInfo(newConfig.ApiURL, newConfig.CaCerts, newConfig.InsecureSkipVerify, newConfig.HttpTimeout)
+	credhubInfo, err := GetApiInfo(newConfig.ApiURL, newConfig.CaCerts, newConfig.InsecureSkipVerify, newConfig.HttpTimeout, ProxyOption(newConfig))
 	if err != nil {
 		return errors.NewNetworkError(err)
 	}
@@ -63,6 +69,12 @@ func (c *ApiCommand) Execute([]string) error {
 	}
 	newConfig.AccessToken = c.config.AccessToken
 	newConfig.RefreshToken = c.config.RefreshToken
+	newConfig.CredentialStore = c.config.CredentialStore
+	if newConfig.ApiURL == c.config.ApiURL {
+		newConfig.ClientCertPath = c.config.ClientCertPath
+		newConfig.ClientKeyPath = c.config.ClientKeyPath
+		newConfig.CredentialHelper = c.config.CredentialHelper
+	}
 
 	err = verifyAuthServerConnection(newConfig, newConfig.InsecureSkipVerify)
 	if err != nil {
@@ -79,8 +91,9 @@ func (c *ApiCommand) Execute([]string) error {
 	return config.WriteConfig(newConfig)
 }
 
-func GetApiInfo(serverUrl string, caCerts []string, skipTlsValidation bool, httpTimeout *time.Duration) (*server.Info, error) {
-	credhubClient, err := credhub.New(serverUrl, credhub.CaCerts(caCerts...), credhub.SkipTLSValidation(skipTlsValidation), credhub.SetHttpTimeout(httpTimeout))
+func GetApiInfo(serverUrl string, caCerts []string, skipTlsValidation bool, httpTimeout *time.Duration, options ...credhub.Option) (*server.Info, error) {
+	options = append([]credhub.Option{credhub.CaCerts(caCerts...), credhub.SkipTLSValidation(skipTlsValidation), credhub.SetHttpTimeout(httpTimeout)}, options...)
+	credhubClient, err := credhub.New(serverUrl, options...)
 	if err != nil {
 		return nil, err
 	}
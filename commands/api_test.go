@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/credhub-cli/config"
@@ -473,6 +474,46 @@ var _ = Describe("API", func() {
 			Expect(*cfg.HttpTimeout).To(Equal(expected))
 		})
 	})
+
+	Describe("when providing a proxy", func() {
+		apiWithProxy := func(args ...string) *Session {
+			return runCommand(append([]string{"api", "--server", server.URL(), "--ca-cert", "../test/server-tls-ca.pem", "--ca-cert", "../test/auth-tls-ca.pem"}, args...)...)
+		}
+
+		It("saves the proxy of the target in the config", func() {
+			session := apiWithProxy("--proxy", "http://proxy.example.com:3128", "--no-proxy", "uaa.example.com")
+
+			Eventually(session).Should(Exit(0))
+			cfg := config.ReadConfig()
+			Expect(cfg.Proxy).To(Equal("http://proxy.example.com:3128"))
+			Expect(cfg.NoProxy).To(Equal("uaa.example.com"))
+		})
+
+		It("keeps the proxy when targeting the same server again", func() {
+			Eventually(apiWithProxy("--proxy", "http://proxy.example.com:3128")).Should(Exit(0))
+
+			Eventually(apiWithProxy()).Should(Exit(0))
+
+			Expect(config.ReadConfig().Proxy).To(Equal("http://proxy.example.com:3128"))
+		})
+
+		It("clears the proxy when targeting another server", func() {
+			Eventually(apiWithProxy("--proxy", "http://proxy.example.com:3128")).Should(Exit(0))
+
+			otherServerURL := strings.Replace(server.URL(), "127.0.0.1", "localhost", 1)
+			session := runCommand("api", otherServerURL, "--skip-tls-validation")
+
+			Eventually(session).Should(Exit(0))
+			Expect(config.ReadConfig().Proxy).To(BeEmpty())
+		})
+
+		It("returns an error when the proxy URL is invalid", func() {
+			session := apiWithProxy("--proxy", "proxy.example.com")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("proxy URL must include a host"))
+		})
+	})
 })
 
 func setUpServer(aServer *Server) string {
@@ -1,18 +1,66 @@
 package commands
 
+import (
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/errors"
+)
+
 type BulkRegenerateCommand struct {
-	SignedBy   string `required:"yes" long:"signed-by" description:"Selects the credential whose children should recursively be regenerated"`
-	OutputJSON bool   `short:"j" long:"output-json" description:"Return response in JSON format"`
+	SignedBy    string `long:"signed-by" description:"Selects the credential whose children should recursively be regenerated"`
+	Path        string `short:"p" long:"path" description:"Regenerate the credentials under the provided path which match the given filters"`
+	Concurrency int    `long:"concurrency" default:"4" description:"Number of credentials to regenerate at the same time when regenerating by path"`
+	DryRun      bool   `long:"dry-run" description:"List the credentials that would be regenerated by path without regenerating them"`
+	OutputJSON  bool   `short:"j" long:"output-json" description:"Return response in JSON format"`
+	FilterFlags
 	ClientCommand
 }
 
 func (c *BulkRegenerateCommand) Execute([]string) error {
-	credentials, err := c.client.BulkRegenerate(c.SignedBy)
+	if (c.SignedBy == "") == (c.Path == "") {
+		return errors.NewBulkRegenerateParametersError()
+	}
+
+	if c.SignedBy != "" {
+		if c.FilterFlags.IsSet() || c.DryRun {
+			return errors.NewBulkRegenerateQueryRequiresPathError()
+		}
+
+		credentials, err := c.client.BulkRegenerate(c.SignedBy)
+		if err != nil {
+			return err
+		}
+
+		formatOutput(c.OutputJSON, credentials)
+
+		return nil
+	}
+
+	return c.handleRegenerateByPath()
+}
+
+func (c *BulkRegenerateCommand) handleRegenerateByPath() error {
+	filter, err := c.FilterFlags.FindFilter(time.Now())
 	if err != nil {
 		return err
 	}
 
-	formatOutput(c.OutputJSON, credentials)
+	options := []credhub.BulkRegenerateOption{credhub.BulkRegenerateConcurrency(c.Concurrency)}
+	if c.DryRun {
+		options = append(options, credhub.BulkRegenerateDryRun())
+	}
+
+	results, err := c.client.BulkRegenerateMatching(c.Path, filter, options...)
+	if err != nil {
+		return err
+	}
+
+	formatOutput(c.OutputJSON, results)
+
+	if len(results.Failed) > 0 {
+		return errors.NewBulkRegenerateFailureError()
+	}
 
 	return nil
 }
@@ -79,6 +79,73 @@ var _ = Describe("Bulk-regenerate", func() {
 		})
 	})
 
+	Describe("Regenerating credentials matching a query", func() {
+		BeforeEach(func() {
+			server.RouteToHandler("GET", "/api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Query().Get("name") {
+				case "":
+					w.Write([]byte(`{"credentials": [
+						{"name": "/team/old", "version_created_at": "2000-01-01T00:00:00Z"},
+						{"name": "/team/new", "version_created_at": "2999-01-01T00:00:00Z"}
+					]}`))
+				case "/team/old":
+					w.Write([]byte(`{"data": [{"type": "password", "name": "/team/old", "value": "old", "version_created_at": "2000-01-01T00:00:00Z"}]}`))
+				}
+			})
+		})
+
+		It("regenerates matching credentials and prints a summary", func() {
+			server.RouteToHandler("POST", "/api/v1/data",
+				CombineHandlers(
+					VerifyJSON(`{"name": "/team/old", "regenerate": true}`),
+					RespondWith(http.StatusOK, `{"type": "password", "name": "/team/old", "value": "new"}`),
+				),
+			)
+
+			session := runCommand("bulk-regenerate", "-p", "/team", "--type", "password", "--older-than", "90d", "--concurrency", "2")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`regenerated_credentials:
+- /team/old
+
+`))
+		})
+
+		It("prints failed credentials and exits with an error", func() {
+			server.RouteToHandler("POST", "/api/v1/data", RespondWith(http.StatusBadRequest, `{"error": "regeneration is not supported"}`))
+
+			session := runCommand("bulk-regenerate", "-p", "/team", "--older-than", "90d", "-j")
+
+			Eventually(session).Should(Exit(1))
+			Expect(string(session.Out.Contents())).To(MatchJSON(`{"regenerated_credentials": [], "failed_credentials": [{"name": "/team/old", "error": "regeneration is not supported"}]}`))
+			Expect(session.Err).To(Say("Some or all of the credentials under the provided path could not be regenerated."))
+		})
+
+		It("lists the matching credentials without regenerating them in dry-run mode", func() {
+			session := runCommand("bulk-regenerate", "-p", "/team", "--older-than", "90d", "--dry-run", "-j")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(MatchJSON(`{"regenerated_credentials": ["/team/old"], "dry_run": true}`))
+			for _, request := range server.ReceivedRequests() {
+				Expect(request.Method).NotTo(Equal("POST"))
+			}
+		})
+
+		It("requires exactly one of --signed-by or --path", func() {
+			session := runCommand("bulk-regenerate", "-p", "/team", "--signed-by", "example-ca")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("Exactly one of --signed-by or --path must be provided."))
+		})
+
+		It("does not allow filters with --signed-by", func() {
+			session := runCommand("bulk-regenerate", "--signed-by", "example-ca", "--dry-run")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("Filter flags and --dry-run can only be used when regenerating by path."))
+		})
+	})
+
 	Describe("help", func() {
 		It("Behaves like help", func() {
 			session := runCommand("bulk-regenerate", "-h")
@@ -90,6 +157,7 @@ var _ = Describe("Bulk-regenerate", func() {
 		It("has short flags", func() {
 			Expect(commands.BulkRegenerateCommand{}).To(SatisfyAll(
 				commands.HaveFlag("signed-by", ""),
+				commands.HaveFlag("path", "p"),
 			))
 		})
 	})
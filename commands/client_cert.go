@@ -0,0 +1,113 @@
+package commands
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"syscall"
+
+	"code.cloudfoundry.org/credhub-cli/config"
+	"code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/auth"
+	"code.cloudfoundry.org/credhub-cli/errors"
+	"github.com/howeyc/gopass"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// clientCertificateCheckName is looked up to verify a client certificate after login. Any
+// authenticated request would do, a lookup by name is the cheapest one the server offers.
+const clientCertificateCheckName = "/credhub-cli/client-certificate-check"
+
+// ClientCertificateOptions returns the options authenticating a client with the TLS client
+// certificate of the config. Encrypted private keys are decrypted with the passphrase in
+// CREDHUB_CLIENT_KEY_PASSPHRASE, or one entered on the terminal.
+func ClientCertificateOptions(cfg config.Config) ([]credhub.Option, error) {
+	keyPEM, err := ioutil.ReadFile(cfg.ClientKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var passphrase string
+	if credhub.IsEncryptedKey(keyPEM) {
+		if passphrase, err = clientKeyPassphrase(cfg.ClientKeyPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return []credhub.Option{
+		credhub.ClientCertWithPassphrase(cfg.ClientCertPath, cfg.ClientKeyPath, passphrase),
+		credhub.Auth(auth.Noop),
+	}, nil
+}
+
+func clientKeyPassphrase(keyPath string) (string, error) {
+	if passphrase, ok := os.LookupEnv("CREDHUB_CLIENT_KEY_PASSPHRASE"); ok {
+		return passphrase, nil
+	}
+
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.NewClientKeyPassphraseRequiredError()
+	}
+
+	fmt.Fprintf(os.Stderr, "passphrase for %s: ", keyPath)
+	passphrase, err := gopass.GetPasswdMasked()
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
+
+// verifyClientCertificate makes an authenticated request to tell apart certificates the server
+// rejects during the TLS handshake from certificates it does not accept for authentication
+func verifyClientCertificate(client *credhub.CredHub) error {
+	u, err := url.Parse(client.ApiURL)
+	if err != nil {
+		return err
+	}
+	u.Path = "/api/v1/data"
+	u.RawQuery = url.Values{"name": []string{clientCertificateCheckName}, "current": []string{"true"}}.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	// with TLS 1.3 the server verifies the certificate after the client finished its part of the
+	// handshake, so a rejection can only be told apart from other failures by arriving before
+	// the response
+	var handshakeStarted, responded bool
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeStart:    func() { handshakeStarted = true },
+		GotFirstResponseByte: func() { responded = true },
+	}
+
+	resp, err := client.Client().Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		if isTLSHandshakeRejection(err, handshakeStarted && !responded) {
+			return errors.NewClientCertificateRejectedError(err)
+		}
+		return errors.NewNetworkError(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return errors.NewClientCertificateUnauthorizedError(resp.StatusCode)
+	}
+
+	return nil
+}
+
+// isTLSHandshakeRejection reports whether the server sent a TLS alert, or reset the connection
+// while handshaking, which is how servers reject client certificates
+func isTLSHandshakeRejection(err error, handshaking bool) bool {
+	var opErr *net.OpError
+	if stderrors.As(err, &opErr) && opErr.Op == "remote error" {
+		return true
+	}
+	return handshaking && stderrors.Is(err, syscall.ECONNRESET)
+}
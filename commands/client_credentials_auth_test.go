@@ -1,9 +1,11 @@
 package commands_test
 
 import (
+	"encoding/base64"
+	"fmt"
 	"net/http"
-
 	"strings"
+	"time"
 
 	"code.cloudfoundry.org/credhub-cli/config"
 	. "github.com/onsi/ginkgo"
@@ -112,5 +114,55 @@ var _ = Describe("Find", func() {
 			Eventually(session).Should(Exit(0))
 			Eventually(string(session.Out.Contents())).Should(Equal("credentials: []\n\n"))
 		})
+
+		It("saves the refreshed token for later commands", func() {
+			expiredAccessToken := jwtExpiringIn(-time.Minute)
+			newAccessToken := jwtExpiringIn(time.Hour)
+
+			config.WriteConfig(
+				config.Config{
+					ConfigWithoutSecrets: config.ConfigWithoutSecrets{
+						ApiURL:       server.URL(),
+						AuthURL:      authServer.URL(),
+						AccessToken:  expiredAccessToken,
+						RefreshToken: "erousflkajqwer",
+					},
+				},
+			)
+
+			server.RouteToHandler("GET", "/api/v1/data",
+				CombineHandlers(
+					VerifyHeader(http.Header{"Authorization": []string{"Bearer " + newAccessToken}}),
+					RespondWith(http.StatusOK, `{"credentials": []}`),
+				),
+			)
+
+			authServer.RouteToHandler("POST", "/oauth/token",
+				CombineHandlers(
+					VerifyBody([]byte(`client_id=credhub_cli&client_secret=&grant_type=refresh_token&refresh_token=erousflkajqwer&response_type=token`)),
+					RespondWith(http.StatusOK, `{
+						"access_token":"`+newAccessToken+`",
+						"refresh_token":"new-refresh-token",
+						"token_type":"bearer"}`),
+				),
+			)
+
+			session := runCommandWithEnv([]string{"CREDHUB_CA_CERT=../test/server-and-auth-stacked-cert.pem"}, "find")
+			Eventually(session).Should(Exit(0))
+
+			cfg := config.ReadConfig()
+			Expect(cfg.AccessToken).To(Equal(newAccessToken))
+			Expect(cfg.RefreshToken).To(Equal("new-refresh-token"))
+
+			session = runCommandWithEnv([]string{"CREDHUB_CA_CERT=../test/server-and-auth-stacked-cert.pem"}, "find")
+			Eventually(session).Should(Exit(0))
+			Expect(authServer.ReceivedRequests()).To(HaveLen(1))
+		})
 	})
 })
+
+// jwtExpiringIn returns an unsigned JWT whose exp claim is the given duration from now
+func jwtExpiringIn(d time.Duration) string {
+	payload := fmt.Sprintf(`{"jti":"some-jti","exp":%d}`, time.Now().Add(d).Unix())
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+}
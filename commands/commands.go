@@ -14,20 +14,27 @@ type CredhubCommand struct {
 	Find             FindCommand             `command:"find"       alias:"f" description:"Find stored credential names or paths based on query parameters" long-description:"Find stored credential names or paths based on query parameters"`
 	Generate         GenerateCommand         `command:"generate"   alias:"n" description:"Generate and set a credential value" long-description:"Set a credential with generated value(s). A type must be specified when generating a credential. The provided flags are used to set parameters for the credential that is generated, e.g. a certificate credential may use --common-name, --duration and --self-sign to generate an appropriate value. Supported credential types are prefixed in the flag description."`
 	Get              GetCommand              `command:"get"        alias:"g" description:"Get a credential value" long-description:"Get a credential value by name or ID"`
-	Import           ImportCommand           `command:"import"     alias:"i" description:"Set multiple credential values" long-description:"Set multiple credential values from import file. File must be in yaml format containing a list of credentials under the key 'credentials'. Name, type and value are required for each credential in the list."`
+	Import           ImportCommand           `command:"import"     alias:"i" description:"Set multiple credential values" long-description:"Set multiple credential values from import file. File must be in yaml format containing a list of credentials under the key 'credentials'. Name, type and either value or generate parameters are required for each credential in the list. Credentials with a mode of 'no-overwrite' keep existing values."`
 	Interpolate      InterpolateCommand      `command:"interpolate" description:"Fill a template with values returned from CredHub" long-description:"Fill a template with values returned from CredHub.\n\nUses double-paren placeholders in the style of the bosh cli. Example:\n\n---\nsomething-stored-in-credhub: ((path/to/var))\nsomething-else: static value\n\nIn the above example, the whole value of the cred will be inserted.\nFor instance, if path/to/var is of type ssh, the output will have all the credential's fields, like this:\n\n---\nsomething-stored-in-credhub:\n  private_key: fake-private-key\n  public_key: fake-public-key\n  public_key_fingerprint: fake-fingerprint\nsome-other-key: static value\n\nIf you want just the password value, you'd need to use ((path/to/var.public_key)),\nwhich would only have the specified field, like this:\n\n---\nsomething-stored-in-credhub: fake-public-key\nsomething-else: static value\n\nIf the prefix flag is provided, the given prefix will be prepended\nto any credentials that do not start with the '/' character.\nExample:\n\n---\nsomething: ((/env-specific-path/path/to/var))\nsame-thing: ((path/to/var))\n\nWhen this example is used with the prefix flag 'env-specific-path', they will be evaluated to the same thing."`
 	Login            LoginCommand            `command:"login"      alias:"l" description:"Authenticate with CredHub" long-description:"Authenticate with CredHub. UAA password and client credential grants are supported. If client credentials exist in the environment, authentication will be performed automatically without the need to explicitly call this command."`
 	Logout           LogoutCommand           `command:"logout"     alias:"o" description:"Discard authenticated user session" long-description:"Discard authenticated session. Refresh token revocation will be attempted for password grants."`
 	Regenerate       RegenerateCommand       `command:"regenerate" alias:"r" description:"Generate and set a credential value using the same attributes as the stored value" long-description:"Set a credential with a generated value using the same attributes as the stored value"`
-	BulkRegenerate   BulkRegenerateCommand   `command:"bulk-regenerate" description:"Recursively regenerate all certificates signed by the provided certificate" long-description:"Recursively regenerate all certificates signed by the provided certificate"`
+	BulkRegenerate   BulkRegenerateCommand   `command:"bulk-regenerate" description:"Recursively regenerate all certificates signed by the provided certificate, or all credentials under a path matching the given filters" long-description:"Recursively regenerate all certificates signed by the provided certificate, or regenerate all credentials under a path matching the given filters"`
 	Set              SetCommand              `command:"set"        alias:"s" description:"Set a credential with a provided value" long-description:"Set a credential with provided value(s). A type must be specified when setting a credential. The provided flags are used to set specific values of a credential, e.g. a certificate credential may use --root, --certificate and --private to set each value. Supported credential types are prefixed in the flag description."`
 	Curl             CurlCommand             `command:"curl"       description:"Make an arbitrary request to the targeted CredHub server." long-description:"Make an arbitrary request to the targeted CredHub server"`
 	SetPermission    SetPermissionCommand    `command:"set-permission" description:"Set permissions for an actor on a given path." long-description:"Set permissions for an actor on a given path"`
 	GetPermission    GetPermissionCommand    `command:"get-permission" description:"Get permissions for an actor on a given path." long-description:"Get permissions for an actor on a given path"`
 	DeletePermission DeletePermissionCommand `command:"delete-permission" description:"Delete permissions for an actor on a given path." long-description:"Delete permissions for an actor on a given path"`
+	Whoami           WhoamiCommand           `command:"whoami"     description:"Show the identity of the current authentication" long-description:"Show the user or client, grant type, scopes, issuer and expiry of the current access token together with the targeted API and auth server. The token is decoded without verification unless --verify is provided, which checks its signature with the keys published by the auth server."`
+	Watch            WatchCommand            `command:"watch"      alias:"w" description:"Watch credentials under a path for changes" long-description:"Poll the credentials under a path and report each credential that is created, updated or deleted. A command may be run for each change with --exec. Watching continues until interrupted."`
+	SSHTunnel        SSHTunnelCommand        `command:"ssh-tunnel" hidden:"true" description:"Serve the SSH tunnel of an ssh+socks5 proxy to later commands" long-description:"Serve the SSH tunnel of an ssh+socks5 proxy on its control socket until it is unused for the control-persist duration of the proxy URL. The tunnel is started in the background by other commands and this command is not meant to be run directly."`
 
 	HttpTimeout *time.Duration `long:"http-timeout" env:"CREDHUB_HTTP_TIMEOUT" description:"Http timeout for http-client. Needs to have unit passed in (i.e. 30s, 1m)"`
 
+	LogLevel      string `long:"log-level" env:"CREDHUB_LOG_LEVEL" description:"Log requests to stderr as JSON lines at the level debug, info, warn or error. Credentials are redacted"`
+	LogFile       string `long:"log-file" env:"CREDHUB_LOG_FILE" description:"Write logs to a file instead of stderr"`
+	LogUnredacted bool   `long:"log-unredacted" env:"CREDHUB_LOG_UNREDACTED" description:"Log requests and responses without redacting credentials, at the debug level unless --log-level is provided. Not recommended!"`
+
 	Version func() `long:"version" description:"Version of CLI and targeted CredHub API"`
 	Token   func() `long:"token" description:"Return your current CredHub authentication token"`
 }
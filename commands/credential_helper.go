@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"os"
+
+	"code.cloudfoundry.org/credhub-cli/config"
+	"code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/auth"
+)
+
+// CredentialHelperOption returns the option authenticating a client with tokens of the credential
+// helper of the config. A helper the user logged in with starts with the token saved in the
+// config, if any.
+func CredentialHelperOption(cfg config.Config) credhub.Option {
+	return credhub.Auth(credentialHelperBuilder(cfg))
+}
+
+func credentialHelperBuilder(cfg config.Config) auth.Builder {
+	build := auth.CredentialHelper(cfg.CredentialHelper, cfg.CredentialHelperArgs...)
+	return func(authConfig auth.Config) (auth.Strategy, error) {
+		strategy, err := build(authConfig)
+		if err != nil {
+			return nil, err
+		}
+		if !credentialHelperInEnvironment() && cfg.AccessToken != "" && cfg.AccessToken != "revoked" {
+			strategy.(*auth.CredentialHelperStrategy).SetAccessToken(cfg.AccessToken)
+		}
+		return strategy, nil
+	}
+}
+
+// credentialHelperInEnvironment reports whether the helper is set by CREDHUB_CREDENTIAL_HELPER
+// instead of a login. The tokens in the config are not the ones of such a helper and its tokens
+// are not saved.
+func credentialHelperInEnvironment() bool {
+	_, ok := os.LookupEnv("CREDHUB_CREDENTIAL_HELPER")
+	return ok
+}
+
+// credentialHelperToken returns a token of the credential helper of the config, saving the token
+// when the helper runs
+func credentialHelperToken(cfg config.Config) (string, error) {
+	credhubClient, err := credhub.New(cfg.ApiURL,
+		credhub.CaCerts(cfg.CaCerts...),
+		credhub.SkipTLSValidation(cfg.InsecureSkipVerify),
+		credhub.SetHttpTimeout(cfg.HttpTimeout),
+		ProxyOption(cfg),
+		CredentialHelperOption(cfg),
+	)
+	if err != nil {
+		return "", err
+	}
+	PersistRefreshedTokens(credhubClient)
+
+	helper := credhubClient.Auth.(*auth.CredentialHelperStrategy)
+	return helper.AccessToken(cfg.ApiURL)
+}
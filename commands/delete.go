@@ -11,11 +11,15 @@ type DeleteCommand struct {
 	CredentialIdentifier string `short:"n" long:"name" description:"Name of the credential to delete"`
 	CredentialPath       string `short:"p" long:"path" description:"Path of the credentials to delete"`
 	Quiet                bool   `short:"q" long:"quiet" description:"Disable real-time status of delete by path"`
+	FilterFlags
 	ClientCommand
 }
 
 func (c *DeleteCommand) Execute([]string) error {
 	if c.CredentialIdentifier != "" {
+		if c.FilterFlags.IsSet() {
+			return errors.NewFilterRequiresPathError()
+		}
 		return c.handleDeleteByName()
 	} else if c.CredentialPath != "" {
 		return c.handleDeleteByPath()
@@ -67,7 +71,17 @@ type DeleteFailedCredential struct {
 }
 
 func (c *DeleteCommand) deleteByPath(path string, quiet bool) ([]DeleteFailedCredential, int, error) {
-	results, err := c.client.FindByPath(path)
+	options, err := c.FilterFlags.FindOptions()
+	if err != nil {
+		return []DeleteFailedCredential{}, 0, err
+	}
+
+	iterator, err := c.client.FindByPathIterator(path, options...)
+	if err != nil {
+		return []DeleteFailedCredential{}, 0, err
+	}
+
+	results, err := iterator.Collect()
 	if err != nil {
 		return []DeleteFailedCredential{}, 0, err
 	}
@@ -186,6 +186,39 @@ var _ = Describe("Delete", func() {
 		})
 	})
 
+	Describe("delete by path with filters", func() {
+		It("only deletes matching credentials", func() {
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "path=deploy123"),
+					RespondWith(http.StatusOK, `{
+						"credentials": [
+							{"name": "deploy123/old", "version_created_at": "2000-01-01T00:00:00Z"},
+							{"name": "deploy123/new", "version_created_at": "2999-01-01T00:00:00Z"}
+						]
+					}`),
+				),
+				CombineHandlers(
+					VerifyRequest("DELETE", "/api/v1/data", "name=deploy123/old"),
+					RespondWith(http.StatusOK, ""),
+				),
+			)
+
+			session := runCommand("delete", "-p", "deploy123", "--older-than", "1w", "-q")
+
+			Eventually(session).Should(Exit(0))
+			Expect(server.ReceivedRequests()).To(HaveLen(4))
+			Expect(session.Out).To(Say("All 1 out of 1 credentials under the provided path are successfully deleted."))
+		})
+
+		It("requires a path", func() {
+			session := runCommand("delete", "-n", "some-name", "--type", "password")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Err).Should(Say("Filter flags can only be used when deleting by path."))
+		})
+	})
+
 	Describe("General errors", func() {
 		It("requires a name or a path", func() {
 			session := runCommand("delete")
@@ -2,64 +2,157 @@ package commands
 
 import (
 	"fmt"
-	"io/ioutil"
+	"os"
+	"strings"
 
 	"code.cloudfoundry.org/credhub-cli/config"
+	"code.cloudfoundry.org/credhub-cli/credhub"
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+	"code.cloudfoundry.org/credhub-cli/errors"
 	"code.cloudfoundry.org/credhub-cli/models"
+	"code.cloudfoundry.org/credhub-cli/util"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 type ExportCommand struct {
-	Path       string `short:"p" long:"path" description:"Path of credentials to export" required:"false"`
-	File       string `short:"f" long:"file" description:"File in which to write credentials" required:"false"`
-	OutputJSON bool   `short:"j" long:"output-json" description:"Return response in JSON format"`
+	Path            string `short:"p" long:"path" description:"Path of credentials to export" required:"false"`
+	File            string `short:"f" long:"file" description:"File in which to write credentials" required:"false"`
+	OutputJSON      bool   `short:"j" long:"output-json" description:"Return response in JSON format"`
+	WithPermissions bool   `long:"with-permissions" description:"Include the permissions on the names of the exported credentials. Permissions on path prefixes such as /team/* are not exported"`
+	AllVersions     bool   `long:"all-versions" description:"Include every version of the exported credentials with its ID and creation time"`
+	Force           bool   `long:"force" description:"Overwrite the file if it already exists"`
+	StdoutOnly      bool   `long:"stdout-only" description:"Only print the credentials to stdout, refusing to print them to a terminal unless confirmed"`
+	Format          string `long:"format" description:"Format of the exported credentials: yaml, json, dotenv, k8s-secret, k8s-secret-by-path or flat-json (Default: yaml)"`
+	FilterFlags
+	RewriteFlags
 }
 
 func (cmd ExportCommand) Execute([]string) error {
-	allCredentials, err := getAllCredentialsForPath(cmd.Path)
-
+	findOptions, err := cmd.FindOptions()
 	if err != nil {
 		return err
 	}
 
-	exportCreds, err := models.ExportCredentials(allCredentials, cmd.OutputJSON)
+	if cmd.StdoutOnly && cmd.File != "" {
+		return errors.NewStdoutOnlyAndFileError()
+	}
 
+	rewrites, err := cmd.NameRewrites()
 	if err != nil {
 		return err
 	}
 
-	if cmd.File == "" {
-		fmt.Printf("%s", exportCreds)
-
+	format, bulkFormat, err := bulkFormat(cmd.Format, "output-json", cmd.OutputJSON)
+	if err != nil {
 		return err
-	} else {
-		return ioutil.WriteFile(cmd.File, exportCreds.Bytes, 0644)
 	}
-}
 
-func getAllCredentialsForPath(path string) ([]credentials.Credential, error) {
+	if !isCredHubFormat(format) {
+		if cmd.WithPermissions {
+			return errors.NewFlagRequiresCredHubFormatError("with-permissions")
+		}
+		if cmd.AllVersions {
+			return errors.NewFlagRequiresCredHubFormatError("all-versions")
+		}
+	}
+
 	cfg := config.ReadConfig()
 	credhubClient, err := initializeCredhubClient(cfg)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	allPaths, err := credhubClient.FindByPath(path)
+	allCredentials, err := getAllCredentialsForPath(credhubClient, cmd.Path, isCredHubFormat(format), findOptions...)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	credentials := make([]credentials.Credential, len(allPaths.Credentials))
-	for i, baseCred := range allPaths.Credentials {
-		credential, err := credhubClient.GetLatestVersion(baseCred.Name)
+	var versions [][]models.CredentialVersion
+	if cmd.AllVersions {
+		versions, err = getVersionsForCredentials(credhubClient, allCredentials)
 
 		if err != nil {
-			return nil, err
+			return err
 		}
+	}
 
-		if credential.Type == "certificate" {
+	var permissions []models.PermissionEntry
+	if cmd.WithPermissions {
+		permissions, err = getPermissionsForCredentials(credhubClient, allCredentials)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	rewrites.RewriteCredentials(allCredentials)
+	rewrites.RewriteVersions(allCredentials, versions)
+	rewrites.RewritePermissions(permissions)
+
+	var exported []byte
+	if isCredHubFormat(format) {
+		var exportCreds *models.CredentialBulkExport
+		exportCreds, err = models.ExportCredentialsWithVersions(allCredentials, versions, permissions, format == models.FormatJSON)
+		if exportCreds != nil {
+			exported = exportCreds.Bytes
+		}
+	} else {
+		exported, err = bulkFormat.Export(allCredentials)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if cmd.File == "" {
+		if cmd.StdoutOnly && terminal.IsTerminal(int(os.Stdout.Fd())) && !confirmPrintToTerminal(len(allCredentials)) {
+			return errors.NewExportToTerminalNotConfirmedError()
+		}
+
+		fmt.Printf("%s", exported)
+
+		return err
+	}
+
+	err = util.WriteFileAtomically(cmd.File, exported, 0600, cmd.Force)
+	if os.IsExist(err) {
+		return errors.NewExportFileExistsError(cmd.File)
+	}
+	return err
+}
+
+// confirmPrintToTerminal asks whether the exported credentials may be printed to the terminal
+func confirmPrintToTerminal(count int) bool {
+	var answer string
+	promptForInput(fmt.Sprintf("About to print %d credentials to the terminal. Continue? [y/N]: ", count), &answer)
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
+// getAllCredentialsForPath returns the latest version of the credentials under path. When
+// resolveCANames is set, the ca of certificates signed by a CA credential is replaced by its name.
+func getAllCredentialsForPath(credhubClient *credhub.CredHub, path string, resolveCANames bool, options ...credhub.FindOption) ([]credentials.Credential, error) {
+	allPaths, err := credhubClient.FindByPathIterator(path, options...)
+
+	if err != nil {
+		return nil, err
+	}
+	defer allPaths.Close()
+
+	credentials := []credentials.Credential{}
+	for allPaths.Next() {
+		credential, fetched := allPaths.Credential()
+		if !fetched {
+			credential, err = credhubClient.GetLatestVersion(allPaths.Result().Name)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if resolveCANames && credential.Type == "certificate" {
 			certMetadata, err := credhubClient.GetCertificateMetadataByName(credential.Name)
 
 			if err != nil {
@@ -74,8 +167,71 @@ func getAllCredentialsForPath(path string) ([]credentials.Credential, error) {
 				}
 			}
 		}
-		credentials[i] = credential
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, allPaths.Err()
+}
+
+// getVersionsForCredentials returns the versions of each credential ordered from oldest to newest.
+// Certificate versions record whether they are the transitional version.
+func getVersionsForCredentials(credhubClient *credhub.CredHub, creds []credentials.Credential) ([][]models.CredentialVersion, error) {
+	versions := make([][]models.CredentialVersion, len(creds))
+
+	for i, credential := range creds {
+		allVersions, err := credhubClient.GetAllVersions(credential.Name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		transitional := make(map[string]bool)
+		if credential.Type == "certificate" {
+			certMetadata, err := credhubClient.GetCertificateMetadataByName(credential.Name)
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, version := range certMetadata.Versions {
+				transitional[version.Id] = version.Transitional
+			}
+		}
+
+		// the server returns the newest version first
+		for j := len(allVersions) - 1; j >= 0; j-- {
+			versions[i] = append(versions[i], models.CredentialVersion{
+				Id:               allVersions[j].Id,
+				VersionCreatedAt: allVersions[j].VersionCreatedAt,
+				Value:            allVersions[j].Value,
+				Transitional:     transitional[allVersions[j].Id],
+			})
+		}
+	}
+
+	return versions, nil
+}
+
+// getPermissionsForCredentials returns the permissions on the names of the credentials. The API
+// cannot list permissions by path, so permissions on path prefixes are not included.
+func getPermissionsForCredentials(credhubClient *credhub.CredHub, credentials []credentials.Credential) ([]models.PermissionEntry, error) {
+	var entries []models.PermissionEntry
+
+	for _, credential := range credentials {
+		permissions, err := credhubClient.GetPermissions(credential.Name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, permission := range permissions {
+			entries = append(entries, models.PermissionEntry{
+				Actor:      permission.Actor,
+				Path:       credential.Name,
+				Operations: permission.Operations,
+			})
+		}
 	}
 
-	return credentials, nil
+	return entries, nil
 }
@@ -4,10 +4,12 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 
 	"runtime"
 
 	"code.cloudfoundry.org/credhub-cli/config"
+	"code.cloudfoundry.org/credhub-cli/test"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -26,6 +28,8 @@ func withTemporaryFile(wantingFile func(string)) error {
 	name := f.Name()
 
 	f.Close()
+	// export refuses to overwrite existing files
+	os.Remove(name)
 	wantingFile(name)
 
 	return os.Remove(name)
@@ -119,6 +123,154 @@ var _ = Describe("Export", func() {
 			Eventually(session.Out).Should(Say(responseTable))
 		})
 
+		It("includes the permissions of the credentials when requested", func() {
+			findJSON := `{"credentials": [{"version_created_at": "idc", "name": "/path/to/cred"}]}`
+			getJSON := `{"data": [{"type":"value","id":"some_uuid","name":"/path/to/cred","version_created_at":"idc","value":"foo"}]}`
+			permissionsJSON := `{"credential_name":"/path/to/cred","permissions":[{"actor":"uaa-client:app","operations":["read","write"]}]}`
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "path="),
+					RespondWith(http.StatusOK, findJSON),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "name=/path/to/cred&current=true"),
+					RespondWith(http.StatusOK, getJSON),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/permissions", "credential_name=/path/to/cred"),
+					RespondWith(http.StatusOK, permissionsJSON),
+				),
+			)
+
+			session := runCommand("export", "--with-permissions")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say(`permissions:
+- actor: uaa-client:app
+  path: /path/to/cred
+  operations:
+  - read
+  - write`))
+		})
+
+		It("rewrites the names of the credentials and the paths of their permissions", func() {
+			findJSON := `{"credentials": [{"version_created_at": "idc", "name": "/dev/app/cred"}]}`
+			getJSON := `{"data": [{"type":"value","id":"some_uuid","name":"/dev/app/cred","version_created_at":"idc","value":"foo"}]}`
+			permissionsJSON := `{"credential_name":"/dev/app/cred","permissions":[{"actor":"uaa-client:app","operations":["read"]}]}`
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "path="),
+					RespondWith(http.StatusOK, findJSON),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "name=/dev/app/cred&current=true"),
+					RespondWith(http.StatusOK, getJSON),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/permissions", "credential_name=/dev/app/cred"),
+					RespondWith(http.StatusOK, permissionsJSON),
+				),
+			)
+
+			session := runCommand("export", "--with-permissions", "--rewrite-prefix", "/dev/app/=/qa/app/")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say(`credentials:
+- name: /qa/app/cred
+  type: value
+  value: foo
+  metadata: {}
+permissions:
+- actor: uaa-client:app
+  path: /qa/app/cred
+  operations:
+  - read`))
+		})
+
+		It("includes every version of the credentials when requested", func() {
+			findJSON := `{"credentials": [{"version_created_at": "idc", "name": "/path/to/cred"}]}`
+			getJSON := `{"data": [{"type":"value","id":"new-id","name":"/path/to/cred","version_created_at":"2020-02-01T00:00:00Z","value":"new"}]}`
+			versionsJSON := `{"data": [
+				{"type":"value","id":"new-id","name":"/path/to/cred","version_created_at":"2020-02-01T00:00:00Z","value":"new"},
+				{"type":"value","id":"old-id","name":"/path/to/cred","version_created_at":"2020-01-01T00:00:00Z","value":"old"}
+			]}`
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "path="),
+					RespondWith(http.StatusOK, findJSON),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "name=/path/to/cred&current=true"),
+					RespondWith(http.StatusOK, getJSON),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "name=/path/to/cred"),
+					RespondWith(http.StatusOK, versionsJSON),
+				),
+			)
+
+			session := runCommand("export", "--all-versions")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say(`credentials:
+- name: /path/to/cred
+  type: value
+  value: new
+  metadata: {}
+  versions:
+  - id: old-id
+    version_created_at: "2020-01-01T00:00:00Z"
+    value: old
+  - id: new-id
+    version_created_at: "2020-02-01T00:00:00Z"
+    value: new`))
+		})
+
+		It("exports in the requested format", func() {
+			findJSON := `{"credentials": [{"version_created_at": "idc", "name": "/path/to/cred"}]}`
+			getJSON := `{"data": [{"type":"password","id":"some_uuid","name":"/path/to/cred","version_created_at":"idc","value":"foo"}]}`
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "path="),
+					RespondWith(http.StatusOK, findJSON),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "name=/path/to/cred&current=true"),
+					RespondWith(http.StatusOK, getJSON),
+				),
+			)
+
+			session := runCommand("export", "--format", "dotenv")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal("# /path/to/cred (password)\nPATH_TO_CRED=\"foo\"\n"))
+		})
+
+		It("returns an error for unknown formats", func() {
+			session := runCommand("export", "--format", "xml")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The format 'xml' is not supported. Valid formats are: dotenv, flat-json, json, k8s-secret, k8s-secret-by-path, yaml."))
+		})
+
+		It("returns an error when --output-json is combined with another format", func() {
+			session := runCommand("export", "--format", "dotenv", "--output-json")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The --output-json flag can only be combined with the json format."))
+		})
+
+		It("returns an error when permissions are requested in another format", func() {
+			session := runCommand("export", "--format", "k8s-secret", "--with-permissions")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The --with-permissions flag can only be used with the yaml and json formats."))
+		})
+
 		It("resolves ca names for certificats", func() {
 			findJson := `{
 				"credentials": [
@@ -266,6 +418,32 @@ var _ = Describe("Export", func() {
 			})
 		})
 
+		Context("when given filters", func() {
+			It("exports only matching credentials without fetching them twice", func() {
+				server.AppendHandlers(
+					CombineHandlers(
+						VerifyRequest("GET", "/api/v1/data", "path=some/path"),
+						RespondWith(http.StatusOK, `{"credentials": [{"name": "/some/path/a", "version_created_at": "2017-01-01T00:00:00Z"}, {"name": "/some/path/b", "version_created_at": "2017-01-01T00:00:00Z"}]}`),
+					),
+					CombineHandlers(
+						VerifyRequest("GET", "/api/v1/data", "name=/some/path/a&current=true"),
+						RespondWith(http.StatusOK, `{"data": [{"type": "value", "name": "/some/path/a", "value": "a"}]}`),
+					),
+					CombineHandlers(
+						VerifyRequest("GET", "/api/v1/data", "name=/some/path/b&current=true"),
+						RespondWith(http.StatusOK, `{"data": [{"type": "password", "name": "/some/path/b", "value": "b"}]}`),
+					),
+				)
+
+				session := runCommand("export", "-p", "some/path", "--type", "password")
+
+				Eventually(session).Should(Exit(0))
+				Expect(session.Out).To(Say("name: /some/path/b"))
+				Expect(string(session.Out.Contents())).NotTo(ContainSubstring("/some/path/a"))
+				Expect(server.ReceivedRequests()).To(HaveLen(5))
+			})
+		})
+
 		Context("when given a file", func() {
 			Context("when given output-json flag", func() {
 				It("writes the JSON to that file", func() {
@@ -322,6 +500,77 @@ var _ = Describe("Export", func() {
 		})
 	})
 
+	Describe("writing to a file", func() {
+		var tempDir, filename string
+
+		BeforeEach(func() {
+			tempDir = test.CreateTempDir("export")
+			filename = filepath.Join(tempDir, "export.yml")
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "path="),
+					RespondWith(http.StatusOK, `{ "credentials" : [] }`),
+				),
+			)
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tempDir)).To(Succeed())
+		})
+
+		It("is only readable by the owner", func() {
+			session := runCommand("export", "-f", filename)
+
+			Eventually(session).Should(Exit(0))
+			if runtime.GOOS != "windows" {
+				info, err := os.Stat(filename)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+			}
+		})
+
+		It("refuses to overwrite an existing file without --force", func() {
+			Expect(ioutil.WriteFile(filename, []byte("existing"), 0600)).To(Succeed())
+
+			session := runCommand("export", "-f", filename)
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The file '.*export.yml' already exists. Use --force to overwrite it."))
+			Expect(ioutil.ReadFile(filename)).To(Equal([]byte("existing")))
+		})
+
+		It("overwrites an existing file with --force", func() {
+			Expect(ioutil.WriteFile(filename, []byte("existing"), 0644)).To(Succeed())
+
+			session := runCommand("export", "-f", filename, "--force")
+
+			Eventually(session).Should(Exit(0))
+			Expect(ioutil.ReadFile(filename)).To(Equal([]byte("credentials: []\n")))
+		})
+	})
+
+	It("prints the credentials with --stdout-only when the output is not a terminal", func() {
+		server.AppendHandlers(
+			CombineHandlers(
+				VerifyRequest("GET", "/api/v1/data", "path="),
+				RespondWith(http.StatusOK, `{ "credentials" : [] }`),
+			),
+		)
+
+		session := runCommand("export", "--stdout-only")
+
+		Eventually(session).Should(Exit(0))
+		Expect(string(session.Out.Contents())).To(Equal("credentials: []\n"))
+	})
+
+	It("returns an error when --stdout-only is combined with --file", func() {
+		session := runCommand("export", "-f", "export.yml", "--stdout-only")
+
+		Eventually(session).Should(Exit(1))
+		Expect(session.Err).To(Say("The --stdout-only flag cannot be combined with the --file flag."))
+	})
+
 	Describe("Errors", func() {
 		It("prints an error when the network request fails", func() {
 			cfg := config.ReadConfig()
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/errors"
+	"code.cloudfoundry.org/credhub-cli/util"
+)
+
+// FilterFlags select credentials by type, metadata, age and certificate expiry.
+// Every provided flag must match for a credential to be selected.
+type FilterFlags struct {
+	FilterTypes    []string `long:"type" description:"Only include credentials of the given type (may be specified multiple times)"`
+	FilterMetadata []string `long:"with-metadata" description:"Only include credentials whose metadata contains KEY=VALUE. Nested keys may be separated with '.' (may be specified multiple times)"`
+	OlderThan      string   `long:"older-than" description:"Only include credentials whose latest version is older than the given duration (e.g. 90d, 1d12h)"`
+	NewerThan      string   `long:"newer-than" description:"Only include credentials whose latest version is newer than the given duration (e.g. 7d, 30m)"`
+	ExpiresWithin  string   `long:"expires-within" description:"Only include certificates that expire within the given duration, including expired certificates (e.g. 30d)"`
+}
+
+func (f FilterFlags) IsSet() bool {
+	return len(f.FilterTypes) > 0 || len(f.FilterMetadata) > 0 || f.OlderThan != "" || f.NewerThan != "" || f.ExpiresWithin != ""
+}
+
+func (f FilterFlags) FindFilter(now time.Time) (credhub.FindFilter, error) {
+	filter := credhub.FindFilter{Types: f.FilterTypes}
+
+	if len(f.FilterMetadata) > 0 {
+		filter.Metadata = make(map[string]string, len(f.FilterMetadata))
+		for _, pair := range f.FilterMetadata {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return filter, errors.NewInvalidMetadataFilterError(pair)
+			}
+			filter.Metadata[parts[0]] = parts[1]
+		}
+	}
+
+	if f.OlderThan != "" {
+		d, err := util.ParseDuration(f.OlderThan)
+		if err != nil {
+			return filter, errors.NewInvalidDurationFlagError("older-than", err)
+		}
+		filter.CreatedBefore = now.Add(-d)
+	}
+
+	if f.NewerThan != "" {
+		d, err := util.ParseDuration(f.NewerThan)
+		if err != nil {
+			return filter, errors.NewInvalidDurationFlagError("newer-than", err)
+		}
+		filter.CreatedAfter = now.Add(-d)
+	}
+
+	if f.ExpiresWithin != "" {
+		d, err := util.ParseDuration(f.ExpiresWithin)
+		if err != nil {
+			return filter, errors.NewInvalidDurationFlagError("expires-within", err)
+		}
+		filter.ExpiresBefore = now.Add(d)
+	}
+
+	return filter, nil
+}
+
+// FindOptions returns the find option applying the filter, or no options when no filter flags were given
+func (f FilterFlags) FindOptions() ([]credhub.FindOption, error) {
+	if !f.IsSet() {
+		return nil, nil
+	}
+
+	filter, err := f.FindFilter(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return []credhub.FindOption{credhub.FindMatching(filter)}, nil
+}
@@ -1,6 +1,10 @@
 package commands
 
 import (
+	"encoding/json"
+	"fmt"
+
+	"code.cloudfoundry.org/credhub-cli/credhub"
 	"code.cloudfoundry.org/credhub-cli/errors"
 )
 
@@ -8,30 +12,94 @@ type FindCommand struct {
 	PartialCredentialIdentifier string `short:"n" long:"name-like" description:"Find credentials whose name contains the query string"`
 	PathIdentifier              string `short:"p" long:"path" description:"Find credentials that exist under the provided path"`
 	OutputJSON                  bool   `short:"j" long:"output-json" description:"Return response in JSON format"`
+	Output                      string `long:"output" choice:"jsonl" description:"Stream results as they are received, one JSON object per line"`
+	SortBy                      string `long:"sort-by" choice:"name" choice:"version_created_at" description:"Sort results by the given field"`
+	Reverse                     bool   `long:"reverse" description:"Sort results in descending order"`
+	Offset                      int    `long:"offset" description:"Skip the given number of results"`
+	Limit                       int    `long:"limit" description:"Return at most the given number of results"`
+	FilterFlags
 	ClientCommand
 }
 
 func (c *FindCommand) Execute([]string) error {
+	if c.OutputJSON && c.Output != "" {
+		return errors.NewOutputJSONAndOutputFormatError()
+	}
+
+	options, err := c.findOptions()
+	if err != nil {
+		return err
+	}
 
+	var iterator *credhub.FindIterator
 	if c.PartialCredentialIdentifier != "" {
-		results, err := c.client.FindByPartialName(c.PartialCredentialIdentifier)
-		if err != nil {
-			return err
-		}
+		iterator, err = c.client.FindByPartialNameIterator(c.PartialCredentialIdentifier, options...)
+	} else {
+		iterator, err = c.client.FindByPathIterator(c.PathIdentifier, options...)
+	}
+	if err != nil {
+		return err
+	}
+	defer iterator.Close()
+
+	if c.Output == "jsonl" {
+		return c.streamResults(iterator)
+	}
 
-		if len(results.Credentials) == 0 {
-			return errors.NewNoMatchingCredentialsFoundError()
-		}
+	results, err := iterator.Collect()
+	if err != nil {
+		return err
+	}
 
-		formatOutput(c.OutputJSON, results)
-	} else {
-		output, err := c.client.FindByPath(c.PathIdentifier)
-		if err != nil {
-			return err
-		}
+	if c.PartialCredentialIdentifier != "" && len(results.Credentials) == 0 {
+		return errors.NewNoMatchingCredentialsFoundError()
+	}
+
+	formatOutput(c.OutputJSON, results)
+
+	return nil
+}
 
-		formatOutput(c.OutputJSON, output)
+func (c *FindCommand) streamResults(iterator *credhub.FindIterator) error {
+	found := false
+	for iterator.Next() {
+		found = true
+		s, _ := json.Marshal(iterator.Result())
+		fmt.Println(string(s))
+	}
+
+	if err := iterator.Err(); err != nil {
+		return err
+	}
+
+	if c.PartialCredentialIdentifier != "" && !found {
+		return errors.NewNoMatchingCredentialsFoundError()
 	}
 
 	return nil
 }
+
+func (c *FindCommand) findOptions() ([]credhub.FindOption, error) {
+	if c.Reverse && c.SortBy == "" {
+		return nil, errors.NewReverseWithoutSortError()
+	}
+
+	options, err := c.FilterFlags.FindOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.SortBy != "" {
+		options = append(options, credhub.FindSortBy(c.SortBy, c.Reverse))
+	}
+
+	if c.Offset != 0 {
+		options = append(options, credhub.FindOffset(c.Offset))
+	}
+
+	if c.Limit != 0 {
+		options = append(options, credhub.FindLimit(c.Limit))
+	}
+
+	return options, nil
+}
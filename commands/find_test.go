@@ -148,6 +148,141 @@ var _ = Describe("Find", func() {
 		})
 	})
 
+	Describe("streaming and ordering results", func() {
+		const responseJSON = `{
+			"credentials": [
+				{"name": "/deploy123/b", "version_created_at": "2016-09-06T23:26:58Z"},
+				{"name": "/deploy123/c", "version_created_at": "2016-09-05T23:26:58Z"},
+				{"name": "/deploy123/a", "version_created_at": "2016-09-07T23:26:58Z"}
+			]
+		}`
+
+		BeforeEach(func() {
+			server.RouteToHandler("GET", "/api/v1/data",
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "path=deploy123"),
+					RespondWith(http.StatusOK, responseJSON),
+				),
+			)
+		})
+
+		It("prints one JSON object per line with --output jsonl", func() {
+			session := runCommand("find", "-p", "deploy123", "--output", "jsonl")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(
+				`{"name":"/deploy123/b","version_created_at":"2016-09-06T23:26:58Z"}
+{"name":"/deploy123/c","version_created_at":"2016-09-05T23:26:58Z"}
+{"name":"/deploy123/a","version_created_at":"2016-09-07T23:26:58Z"}
+`))
+		})
+
+		It("sorts, offsets and limits results", func() {
+			session := runCommand("find", "-p", "deploy123", "--output", "jsonl", "--sort-by", "version_created_at", "--reverse", "--offset", "1", "--limit", "1")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`{"name":"/deploy123/b","version_created_at":"2016-09-06T23:26:58Z"}
+`))
+		})
+
+		It("applies ordering to the default output", func() {
+			session := runCommand("find", "-p", "deploy123", "--sort-by", "name", "--limit", "2")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say(`credentials:\n- name: /deploy123/a\n  version_created_at: "2016-09-07T23:26:58Z"\n- name: /deploy123/b\n`))
+			Expect(session.Out).NotTo(Say("/deploy123/c"))
+		})
+
+		It("rejects unknown sort fields", func() {
+			session := runCommand("find", "-p", "deploy123", "--sort-by", "type")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("Invalid value `type' for option `--sort-by'"))
+		})
+
+		It("requires --sort-by when --reverse is given", func() {
+			session := runCommand("find", "-p", "deploy123", "--reverse")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The --reverse flag requires the --sort-by flag"))
+		})
+
+		It("rejects combining --output-json and --output", func() {
+			session := runCommand("find", "-p", "deploy123", "--output", "jsonl", "-j")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The --output-json flag and --output flag are incompatible"))
+		})
+
+		It("reports no matches for name-like searches in jsonl mode", func() {
+			server.RouteToHandler("GET", "/api/v1/data", RespondWith(http.StatusOK, `{"credentials": []}`))
+
+			session := runCommand("find", "-n", "dan", "--output", "jsonl")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("No credentials exist which match the provided parameters."))
+		})
+	})
+
+	Describe("filtering results", func() {
+		BeforeEach(func() {
+			server.RouteToHandler("GET", "/api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Query().Get("name") {
+				case "":
+					w.Write([]byte(`{
+						"credentials": [
+							{"name": "/deploy123/old-password", "version_created_at": "2000-01-01T00:00:00Z"},
+							{"name": "/deploy123/new-value", "version_created_at": "2999-01-01T00:00:00Z"}
+						]
+					}`))
+				case "/deploy123/old-password":
+					w.Write([]byte(`{"data": [{"type": "password", "name": "/deploy123/old-password", "value": "secret", "metadata": {"team": {"owner": "ops"}}, "version_created_at": "2000-01-01T00:00:00Z"}]}`))
+				case "/deploy123/new-value":
+					w.Write([]byte(`{"data": [{"type": "value", "name": "/deploy123/new-value", "value": "v", "metadata": null, "version_created_at": "2999-01-01T00:00:00Z"}]}`))
+				}
+			})
+		})
+
+		It("filters by age without fetching credentials", func() {
+			session := runCommand("find", "-p", "deploy123", "--output", "jsonl", "--older-than", "30d")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`{"name":"/deploy123/old-password","version_created_at":"2000-01-01T00:00:00Z"}
+`))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+
+		It("filters by type", func() {
+			session := runCommand("find", "-p", "deploy123", "--type", "value")
+
+			Eventually(session).Should(Exit(0))
+			Expect(session.Out).To(Say("/deploy123/new-value"))
+			Expect(string(session.Out.Contents())).NotTo(ContainSubstring("old-password"))
+		})
+
+		It("filters by nested metadata", func() {
+			session := runCommand("find", "-p", "deploy123", "--with-metadata", "team.owner=ops")
+
+			Eventually(session).Should(Exit(0))
+			Expect(session.Out).To(Say("/deploy123/old-password"))
+			Expect(string(session.Out.Contents())).NotTo(ContainSubstring("new-value"))
+		})
+
+		It("rejects invalid metadata filters", func() {
+			session := runCommand("find", "-p", "deploy123", "--with-metadata", "team")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The metadata filter 'team' is not valid."))
+		})
+
+		It("rejects invalid durations", func() {
+			session := runCommand("find", "-p", "deploy123", "--older-than", "soon")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The argument for --older-than is not a valid duration"))
+		})
+	})
+
 	Describe("when an error is received from the server", func() {
 		It("shows the error name and description", func() {
 			server.AppendHandlers(
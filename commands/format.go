@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"code.cloudfoundry.org/credhub-cli/errors"
+	"code.cloudfoundry.org/credhub-cli/models"
+)
+
+// bulkFormat returns the name and implementation of the export and import file format. The
+// --output-json and --import-json flags select the json format and cannot be combined with other formats.
+func bulkFormat(format string, jsonFlag string, json bool) (string, models.BulkFormat, error) {
+	if json {
+		if format != "" && format != models.FormatJSON {
+			return "", nil, errors.NewFormatAndJSONFlagError(jsonFlag)
+		}
+		format = models.FormatJSON
+	}
+	if format == "" {
+		format = models.FormatYAML
+	}
+
+	bulkFormat, ok := models.BulkFormatNamed(format)
+	if !ok {
+		return "", nil, errors.NewUnknownBulkFormatError(format, models.BulkFormatNames())
+	}
+
+	return format, bulkFormat, nil
+}
+
+func isCredHubFormat(format string) bool {
+	return format == models.FormatYAML || format == models.FormatJSON
+}
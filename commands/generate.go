@@ -3,12 +3,17 @@ package commands
 import (
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
 	"encoding/json"
+	"io/ioutil"
+	"os"
 	"strings"
 
+	"code.cloudfoundry.org/credhub-cli/config"
 	"code.cloudfoundry.org/credhub-cli/credhub"
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
 	"code.cloudfoundry.org/credhub-cli/errors"
 	"code.cloudfoundry.org/credhub-cli/models"
+	"gopkg.in/yaml.v2"
 )
 
 type GenerateCommand struct {
@@ -24,6 +29,7 @@ type GenerateCommand struct {
 	ExcludeLower         bool     `short:"L" long:"exclude-lower" description:"[Password, User] Exclude lower alpha characters from the generated value"`
 	SSHComment           string   `short:"m" long:"ssh-comment" description:"[SSH] Comment appended to public key to help identify in environment"`
 	KeyLength            int      `short:"k" long:"key-length" description:"[Certificate, SSH, RSA] Bit length of the generated key (Default: 2048)"`
+	KeyAlgorithm         string   `long:"key-algorithm" choice:"rsa" choice:"ecdsa-p256" choice:"ecdsa-p384" choice:"ed25519" description:"[Certificate, SSH, RSA] Algorithm of the generated key (Default: rsa). The server only generates RSA keys, other keys are generated locally as with --local"`
 	Duration             int      `short:"d" long:"duration" description:"[Certificate] Valid duration (in days) of the generated certificate (Default: 365)"`
 	CommonName           string   `short:"c" long:"common-name" description:"[Certificate] Common name of the generated certificate"`
 	Organization         string   `short:"o" long:"organization" description:"[Certificate] Organization of the generated certificate"`
@@ -38,6 +44,10 @@ type GenerateCommand struct {
 	IsCA                 bool     `long:"is-ca" description:"[Certificate] The generated certificate is a certificate authority"`
 	SelfSign             bool     `long:"self-sign" description:"[Certificate] The generated certificate will be self-signed"`
 	Metadata             string   `long:"metadata" description:"[JSON] Sets additional metadata on the credential"`
+	Policy               string   `long:"policy" description:"[Password] Name of the password policy profile the generated value must satisfy"`
+	PolicyFile           string   `long:"policy-file" description:"[Password] File containing password policy profiles. Profiles are read from the CLI config when not provided"`
+	Local                bool     `long:"local" description:"Generate the value on this machine instead of the server. Supported for password, user, certificate, ssh and rsa credentials"`
+	OutputFile           string   `long:"output-file" description:"With --local, add the credential to this import file instead of setting it. CAs used with --ca are read from the same file. The file is written as JSON if its name ends in .json"`
 	ClientCommand
 }
 
@@ -50,6 +60,22 @@ func (c GenerateCommand) Execute([]string) error {
 
 	c.CredentialType = strings.ToLower(c.CredentialType)
 
+	if c.OutputFile != "" && !c.Local {
+		return errors.NewOutputFileRequiresLocalError()
+	}
+
+	if c.KeyAlgorithm != "" && !isKeyType(c.CredentialType) {
+		return errors.NewKeyAlgorithmOnlyValidForKeyTypesError()
+	}
+
+	if c.Local || c.requiresLocalGeneration() {
+		return c.generateLocally()
+	}
+
+	if c.Policy != "" || c.PolicyFile != "" {
+		return c.generateWithPolicy()
+	}
+
 	if c.CredentialType != "user" && len(c.Username) > 0 {
 		return errors.NewUserNameOnlyValidForUserType()
 	}
@@ -89,27 +115,208 @@ func (c GenerateCommand) Execute([]string) error {
 		}
 	}
 
-	mode := credhub.Overwrite
-	if c.NoOverwrite {
-		mode = credhub.NoOverwrite
+	options, err := c.generateOptions()
+	if err != nil {
+		return err
 	}
 
-	var options []credhub.GenerateOption
+	credential, err := c.client.GenerateCredential(c.CredentialIdentifier, c.CredentialType, parameters, c.mode(), options...)
+
+	if err == credhub.ServerDoesNotSupportMetadataError {
+		return errors.NewServerDoesNotSupportMetadataError()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	credential.Value = "<redacted>"
+	formatOutput(c.OutputJSON, credential)
+
+	return nil
+}
+
+// requiresLocalGeneration reports whether the requested key algorithm can only be generated locally,
+// since the server ignores the key algorithm and always generates RSA keys
+func (c GenerateCommand) requiresLocalGeneration() bool {
+	return c.KeyAlgorithm != "" && c.KeyAlgorithm != generate.KeyAlgorithmRSA
+}
+
+// RunsOffline reports whether the command writes to an import file without contacting the server
+func (c *GenerateCommand) RunsOffline() bool {
+	return c.Local && c.OutputFile != ""
+}
+
+func (c GenerateCommand) generateLocally() error {
+	if c.Policy != "" || c.PolicyFile != "" {
+		return errors.NewPolicyAndLocalError()
+	}
+
+	if c.CredentialType != "user" && len(c.Username) > 0 {
+		return errors.NewUserNameOnlyValidForUserType()
+	}
+
+	parameters := c.localParameters()
+
+	if c.OutputFile != "" {
+		return c.generateToFile(parameters)
+	}
+
+	options, err := c.generateOptions()
+	if err != nil {
+		return err
+	}
+
+	credential, err := c.client.GenerateCredentialLocally(c.CredentialIdentifier, c.CredentialType, parameters, c.mode(), options...)
+
+	if err == credhub.ServerDoesNotSupportMetadataError {
+		return errors.NewServerDoesNotSupportMetadataError()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	credential.Value = "<redacted>"
+	formatOutput(c.OutputJSON, credential)
+
+	return nil
+}
+
+func (c GenerateCommand) generateToFile(parameters interface{}) error {
+	outputJSON := strings.HasSuffix(strings.ToLower(c.OutputFile), ".json")
+
+	var bulkImport models.CredentialBulkImport
+	if _, err := os.Stat(c.OutputFile); err == nil {
+		if err := bulkImport.ReadFile(c.OutputFile, outputJSON); err != nil {
+			return err
+		}
+	}
+
+	if existing, ok := bulkImport.Credential(c.CredentialIdentifier); ok && c.NoOverwrite {
+		formatOutput(c.OutputJSON, redactedImportCredential(existing))
+		return nil
+	}
+
+	var ca *values.Certificate
+	if cert, ok := parameters.(generate.Certificate); ok && cert.Ca != "" && !cert.SelfSign {
+		caCredential, ok := bulkImport.Credential(cert.Ca)
+		if !ok {
+			return errors.NewLocalCaNotFoundError(cert.Ca, c.OutputFile)
+		}
+
+		ca = &values.Certificate{}
+		data, _ := json.Marshal(caCredential["value"])
+		if err := json.Unmarshal(data, ca); err != nil {
+			return err
+		}
+	}
+
+	value, err := generate.Value(c.CredentialType, parameters, ca)
+	if err != nil {
+		return err
+	}
+
+	var metadata credentials.Metadata
 	if c.Metadata != "" {
-		var metadata credentials.Metadata
 		if err := json.Unmarshal([]byte(c.Metadata), &metadata); err != nil {
 			return errors.NewInvalidJSONMetadataError()
 		}
+	}
 
-		withMetadata := func(g *credhub.GenerateOptions) error {
-			g.Metadata = metadata
-			return nil
+	bulkImport.AddCredential(c.CredentialIdentifier, c.CredentialType, value, metadata, true)
+	if err := bulkImport.WriteFile(c.OutputFile, outputJSON); err != nil {
+		return err
+	}
+
+	formatOutput(c.OutputJSON, credentials.Credential{
+		Base:  credentials.Base{Name: c.CredentialIdentifier, Type: c.CredentialType, Metadata: metadata},
+		Value: "<redacted>",
+	})
+
+	return nil
+}
+
+func redactedImportCredential(credential map[string]interface{}) credentials.Credential {
+	name, _ := credential["name"].(string)
+	credType, _ := credential["type"].(string)
+	metadata, _ := credential["metadata"].(map[string]interface{})
+
+	return credentials.Credential{
+		Base:  credentials.Base{Name: name, Type: credType, Metadata: metadata},
+		Value: "<redacted>",
+	}
+}
+
+func (c GenerateCommand) localParameters() interface{} {
+	switch c.CredentialType {
+	case "password":
+		return generate.Password{
+			Length:         c.Length,
+			IncludeSpecial: c.IncludeSpecial,
+			ExcludeNumber:  c.ExcludeNumber,
+			ExcludeUpper:   c.ExcludeUpper,
+			ExcludeLower:   c.ExcludeLower,
+		}
+	case "user":
+		return generate.User{
+			Username:       c.Username,
+			Length:         c.Length,
+			IncludeSpecial: c.IncludeSpecial,
+			ExcludeNumber:  c.ExcludeNumber,
+			ExcludeUpper:   c.ExcludeUpper,
+			ExcludeLower:   c.ExcludeLower,
 		}
+	case "rsa":
+		return generate.RSA{KeyLength: c.KeyLength, KeyAlgorithm: c.KeyAlgorithm}
+	case "ssh":
+		return generate.SSH{KeyLength: c.KeyLength, KeyAlgorithm: c.KeyAlgorithm, Comment: c.SSHComment}
+	case "certificate":
+		return generate.Certificate{
+			KeyLength:        c.KeyLength,
+			KeyAlgorithm:     c.KeyAlgorithm,
+			Duration:         c.Duration,
+			CommonName:       c.CommonName,
+			Organization:     c.Organization,
+			OrganizationUnit: c.OrganizationUnit,
+			Locality:         c.Locality,
+			State:            c.State,
+			Country:          c.Country,
+			AlternativeNames: c.AlternativeName,
+			KeyUsage:         c.KeyUsage,
+			ExtendedKeyUsage: c.ExtendedKeyUsage,
+			Ca:               c.Ca,
+			IsCA:             c.IsCA,
+			SelfSign:         c.SelfSign,
+		}
+	}
+	return nil
+}
 
-		options = append(options, withMetadata)
+func (c GenerateCommand) generateWithPolicy() error {
+	if c.Policy == "" {
+		return errors.NewMissingPolicyError()
+	}
+
+	if c.CredentialType != "password" {
+		return errors.NewPolicyOnlyValidForPasswordTypeError()
+	}
+
+	if c.Length != 0 || c.IncludeSpecial || c.ExcludeNumber || c.ExcludeUpper || c.ExcludeLower {
+		return errors.NewPolicyAndPasswordParametersError()
 	}
 
-	credential, err := c.client.GenerateCredential(c.CredentialIdentifier, c.CredentialType, parameters, mode, options...)
+	policy, err := c.readPolicy()
+	if err != nil {
+		return err
+	}
+
+	options, err := c.generateOptions()
+	if err != nil {
+		return err
+	}
+
+	password, err := c.client.GeneratePasswordWithPolicy(c.CredentialIdentifier, policy, c.mode(), options...)
 
 	if err == credhub.ServerDoesNotSupportMetadataError {
 		return errors.NewServerDoesNotSupportMetadataError()
@@ -119,8 +326,56 @@ func (c GenerateCommand) Execute([]string) error {
 		return err
 	}
 
-	credential.Value = "<redacted>"
-	formatOutput(c.OutputJSON, credential)
+	formatOutput(c.OutputJSON, credentials.Credential{Base: password.Base, Value: "<redacted>"})
 
 	return nil
 }
+
+func (c GenerateCommand) readPolicy() (generate.PasswordPolicy, error) {
+	policies := config.ReadConfig().PasswordPolicies
+
+	if c.PolicyFile != "" {
+		data, err := ioutil.ReadFile(c.PolicyFile)
+		if err != nil {
+			return generate.PasswordPolicy{}, errors.NewPolicyFileError(c.PolicyFile, err)
+		}
+
+		policies = nil
+		if err := yaml.Unmarshal(data, &policies); err != nil {
+			return generate.PasswordPolicy{}, errors.NewPolicyFileError(c.PolicyFile, err)
+		}
+	}
+
+	policy, ok := policies[c.Policy]
+	if !ok {
+		return generate.PasswordPolicy{}, errors.NewUnknownPolicyError(c.Policy)
+	}
+
+	return policy, nil
+}
+
+func (c GenerateCommand) mode() credhub.Mode {
+	if c.NoOverwrite {
+		return credhub.NoOverwrite
+	}
+	return credhub.Overwrite
+}
+
+func (c GenerateCommand) generateOptions() ([]credhub.GenerateOption, error) {
+	var options []credhub.GenerateOption
+	if c.Metadata != "" {
+		var metadata credentials.Metadata
+		if err := json.Unmarshal([]byte(c.Metadata), &metadata); err != nil {
+			return nil, errors.NewInvalidJSONMetadataError()
+		}
+
+		withMetadata := func(g *credhub.GenerateOptions) error {
+			g.Metadata = metadata
+			return nil
+		}
+
+		options = append(options, withMetadata)
+	}
+
+	return options, nil
+}
@@ -1,12 +1,19 @@
 package commands_test
 
 import (
+	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"runtime"
 
 	"fmt"
 
 	"code.cloudfoundry.org/credhub-cli/commands"
+	"code.cloudfoundry.org/credhub-cli/config"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	"code.cloudfoundry.org/credhub-cli/models"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gbytes"
@@ -158,6 +165,36 @@ var _ = Describe("Generate", func() {
 			session := runCommand("generate", "-n", "foo-ssh-key", "-t", "ssh", "-m", "i am an ssh comment")
 			Eventually(session).Should(Exit(0))
 		})
+
+		It("generates non-RSA keys locally", func() {
+			var request map[string]interface{}
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("PUT", "/api/v1/data"),
+					func(w http.ResponseWriter, r *http.Request) {
+						json.NewDecoder(r.Body).Decode(&request)
+					},
+					RespondWith(http.StatusOK, fmt.Sprintf(generateResponseJSON, "ssh", "foo-ssh-key", `{"public_key":"pub","private_key":"priv"}`)),
+				),
+			)
+
+			session := runCommand("generate", "-n", "foo-ssh-key", "-t", "ssh", "--key-algorithm", "ed25519")
+
+			Eventually(session).Should(Exit(0))
+			Expect(request["value"].(map[string]interface{})["public_key"]).To(HavePrefix("ssh-ed25519 "))
+		})
+
+		It("generates RSA keys on the server", func() {
+			setupGenerateServer("ssh", "foo-ssh-key", `{"public_key":"some-public-key","private_key":"some-private-key"}`, `{}`, true)
+			session := runCommand("generate", "-n", "foo-ssh-key", "-t", "ssh", "--key-algorithm", "rsa")
+			Eventually(session).Should(Exit(0))
+		})
+
+		It("rejects unsupported key algorithms", func() {
+			session := runCommand("generate", "-n", "foo-ssh-key", "-t", "ssh", "--key-algorithm", "dsa")
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("key-algorithm"))
+		})
 	})
 
 	Describe("with a variety of RSA parameters", func() {
@@ -479,6 +516,229 @@ metadata:
 		})
 	})
 
+	Describe("with a password policy", func() {
+		var policyFile string
+
+		BeforeEach(func() {
+			file, err := ioutil.TempFile("", "policies")
+			Expect(err).NotTo(HaveOccurred())
+			file.WriteString(`
+simple:
+  length: 20
+  min_special: 1
+strict:
+  length: 16
+  min_number: 4
+  forbidden_characters: "0Ol1"
+`)
+			file.Close()
+			policyFile = file.Name()
+		})
+
+		AfterEach(func() {
+			os.Remove(policyFile)
+		})
+
+		It("generates on the server when the server can express the policy", func() {
+			setupGenerateServer("password", "my-password", `"abcdefghijklmnopqr!1"`, `{"length":20,"include_special":true}`, true)
+
+			session := runCommand("generate", "-n", "my-password", "-t", "password", "--policy", "simple", "--policy-file", policyFile)
+
+			Eventually(session).Should(Exit(0))
+		})
+
+		It("generates locally and sets the password when the server generated value does not satisfy the policy", func() {
+			setupGenerateServer("password", "my-password", `"potatoes"`, `{"length":20,"include_special":true}`, true)
+			var request map[string]interface{}
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("PUT", "/api/v1/data"),
+					func(w http.ResponseWriter, r *http.Request) {
+						json.NewDecoder(r.Body).Decode(&request)
+					},
+					RespondWith(http.StatusOK, fmt.Sprintf(generateResponseJSON, "password", "my-password", `"generated"`)),
+				),
+			)
+
+			session := runCommand("generate", "-n", "my-password", "-t", "password", "--policy", "simple", "--policy-file", policyFile)
+
+			Eventually(session).Should(Exit(0))
+			Expect(request["value"]).To(HaveLen(20))
+			Expect(request["value"]).To(MatchRegexp(`[^a-zA-Z0-9]`))
+		})
+
+		It("generates locally and sets the password when the server cannot express the policy", func() {
+			var request map[string]interface{}
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("PUT", "/api/v1/data"),
+					func(w http.ResponseWriter, r *http.Request) {
+						json.NewDecoder(r.Body).Decode(&request)
+					},
+					RespondWith(http.StatusOK, fmt.Sprintf(generateResponseJSON, "password", "my-password", `"generated"`)),
+				),
+			)
+
+			session := runCommand("generate", "-n", "my-password", "-t", "password", "--policy", "strict", "--policy-file", policyFile)
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("value: <redacted>"))
+
+			Expect(request["value"]).To(HaveLen(16))
+			Expect(request["value"]).To(MatchRegexp(`^([^0Ol1]*[2-9]){4}[^0Ol1]*$`))
+		})
+
+		It("reads policies from the config", func() {
+			cfg := config.ReadConfig()
+			cfg.PasswordPolicies = map[string]generate.PasswordPolicy{"configured": {Length: 8}}
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			setupGenerateServer("password", "my-password", `"abcdefgh"`, `{"length":8}`, true)
+
+			session := runCommand("generate", "-n", "my-password", "-t", "password", "--policy", "configured")
+
+			Eventually(session).Should(Exit(0))
+		})
+
+		It("returns an error for unknown policies", func() {
+			session := runCommand("generate", "-n", "my-password", "-t", "password", "--policy", "missing", "--policy-file", policyFile)
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The password policy 'missing' could not be found."))
+		})
+
+		It("cannot be combined with password parameters", func() {
+			session := runCommand("generate", "-n", "my-password", "-t", "password", "--policy", "simple", "-l", "10")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The --policy flag cannot be combined with the length, include or exclude flags."))
+		})
+
+		It("is only valid for passwords", func() {
+			session := runCommand("generate", "-n", "my-user", "-t", "user", "--policy", "simple")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("Password policies are only valid for password credentials."))
+		})
+	})
+
+	Describe("generating locally", func() {
+		It("generates the value locally and sets it", func() {
+			var request map[string]interface{}
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("PUT", "/api/v1/data"),
+					func(w http.ResponseWriter, r *http.Request) {
+						json.NewDecoder(r.Body).Decode(&request)
+					},
+					RespondWith(http.StatusOK, fmt.Sprintf(generateResponseJSON, "ssh", "my-ssh", `{"public_key":"pub","private_key":"priv"}`)),
+				),
+			)
+
+			session := runCommand("generate", "-n", "my-ssh", "-t", "ssh", "--local", "-k", "1024", "-m", "me@example.com")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("value: <redacted>"))
+			Expect(request["type"]).To(Equal("ssh"))
+			Expect(request["value"].(map[string]interface{})["public_key"]).To(MatchRegexp(`^ssh-rsa \S+ me@example.com$`))
+		})
+
+		It("generates keys with the requested key algorithm", func() {
+			var request map[string]interface{}
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("PUT", "/api/v1/data"),
+					func(w http.ResponseWriter, r *http.Request) {
+						json.NewDecoder(r.Body).Decode(&request)
+					},
+					RespondWith(http.StatusOK, fmt.Sprintf(generateResponseJSON, "ssh", "my-ssh", `{"public_key":"pub","private_key":"priv"}`)),
+				),
+			)
+
+			session := runCommand("generate", "-n", "my-ssh", "-t", "ssh", "--local", "--key-algorithm", "ecdsa-p256")
+
+			Eventually(session).Should(Exit(0))
+			Expect(request["value"].(map[string]interface{})["public_key"]).To(HavePrefix("ecdsa-sha2-nistp256 "))
+		})
+
+		Context("with an output file", func() {
+			var outputFile string
+
+			BeforeEach(func() {
+				dir, err := ioutil.TempDir("", "generate")
+				Expect(err).NotTo(HaveOccurred())
+				outputFile = filepath.Join(dir, "import.yml")
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(filepath.Dir(outputFile))
+			})
+
+			It("writes an import file signing certificates with CAs from the same file without contacting the server", func() {
+				Expect(config.RemoveConfig()).To(Succeed())
+				requestsBefore := len(server.ReceivedRequests())
+
+				session := runCommand("generate", "-n", "/ca", "-t", "certificate", "--is-ca", "-c", "ca", "-k", "1024", "--local", "--output-file", outputFile)
+				Eventually(session).Should(Exit(0))
+
+				session = runCommand("generate", "-n", "/cert", "-t", "certificate", "--ca", "/ca", "-c", "example.com", "-k", "1024", "--local", "--output-file", outputFile, "--metadata", `{"owner":"ops"}`)
+				Eventually(session).Should(Exit(0))
+				Eventually(session.Out).Should(Say("name: /cert"))
+
+				Expect(server.ReceivedRequests()).To(HaveLen(requestsBefore))
+
+				var bulkImport models.CredentialBulkImport
+				Expect(bulkImport.ReadFile(outputFile, false)).To(Succeed())
+				Expect(bulkImport.Credentials).To(HaveLen(2))
+
+				cert, _ := bulkImport.Credential("/cert")
+				ca, _ := bulkImport.Credential("/ca")
+				Expect(cert["metadata"]).To(Equal(map[string]interface{}{"owner": "ops"}))
+				Expect(cert["value"].(map[string]interface{})["ca_name"]).To(Equal("/ca"))
+				Expect(cert["value"].(map[string]interface{})["ca"]).To(Equal(ca["value"].(map[string]interface{})["certificate"]))
+			})
+
+			It("keeps existing credentials in the file with --no-overwrite", func() {
+				Expect(ioutil.WriteFile(outputFile, []byte("credentials:\n- name: /pw\n  type: password\n  value: existing\n"), 0600)).To(Succeed())
+
+				session := runCommand("generate", "-n", "/pw", "-t", "password", "--local", "--output-file", outputFile, "--no-overwrite")
+				Eventually(session).Should(Exit(0))
+
+				contents, _ := ioutil.ReadFile(outputFile)
+				Expect(string(contents)).To(ContainSubstring("existing"))
+			})
+
+			It("returns an error when the CA is not in the file", func() {
+				session := runCommand("generate", "-n", "/cert", "-t", "certificate", "--ca", "/missing", "-c", "example.com", "--local", "--output-file", outputFile)
+
+				Eventually(session).Should(Exit(1))
+				Expect(session.Err).To(Say("The CA '/missing' could not be found in"))
+			})
+		})
+
+		It("requires --local for --output-file", func() {
+			session := runCommand("generate", "-n", "/pw", "-t", "password", "--output-file", "import.yml")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The --output-file flag can only be used with the --local flag."))
+		})
+
+		It("returns an error for types which cannot be generated locally", func() {
+			session := runCommand("generate", "-n", "/v", "-t", "value", "--local")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("credentials of type 'value' cannot be generated locally"))
+		})
+	})
+
+	Describe("When key algorithm is included for types without keys", func() {
+		It("returns an error", func() {
+			session := runCommand("generate", "-n", "my-password", "-t", "password", "--key-algorithm", "ed25519")
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The --key-algorithm flag can only be used with certificate, ssh and rsa credentials."))
+		})
+	})
+
 	Describe("When username parameter is included for non-user types", func() {
 		It("returns a sensible error", func() {
 			session := runCommand("generate", "-n", "test-ssh-value", "-t", "ssh", "-z", "my-username")
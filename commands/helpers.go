@@ -65,7 +65,7 @@ func readConfigFromEnvironmentVariables(cfg *config.Config) error {
 	}
 
 	if cfg.AuthURL == "" && cfg.ApiURL != "" {
-		credhubInfo, err := GetApiInfo(cfg.ApiURL, cfg.CaCerts, cfg.InsecureSkipVerify, cfg.HttpTimeout)
+		credhubInfo, err := GetApiInfo(cfg.ApiURL, cfg.CaCerts, cfg.InsecureSkipVerify, cfg.HttpTimeout, ProxyOption(*cfg))
 		if err != nil {
 			return errors.NewNetworkError(err)
 		}
@@ -77,6 +77,23 @@ func readConfigFromEnvironmentVariables(cfg *config.Config) error {
 }
 
 func newCredhubClient(cfg *config.Config, clientId string, clientSecret string, usingClientCredentials bool) (*credhub.CredHub, error) {
+	if cfg.UsesCredentialHelper() && !usingClientCredentials {
+		credhubClient, err := credhub.New(cfg.ApiURL, credhub.CaCerts(cfg.CaCerts...), credhub.SkipTLSValidation(cfg.InsecureSkipVerify), credhub.SetHttpTimeout(cfg.HttpTimeout), ProxyOption(*cfg), CredentialHelperOption(*cfg))
+		if err == nil {
+			PersistRefreshedTokens(credhubClient)
+		}
+		return credhubClient, err
+	}
+
+	if cfg.UsesClientCertificate() && !usingClientCredentials {
+		options, err := ClientCertificateOptions(*cfg)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, credhub.CaCerts(cfg.CaCerts...), credhub.SkipTLSValidation(cfg.InsecureSkipVerify), credhub.SetHttpTimeout(cfg.HttpTimeout), ProxyOption(*cfg))
+		return credhub.New(cfg.ApiURL, options...)
+	}
+
 	credhubClient, err := credhub.New(cfg.ApiURL, credhub.CaCerts(cfg.CaCerts...), credhub.SkipTLSValidation(cfg.InsecureSkipVerify), credhub.Auth(auth.Uaa(
 		clientId,
 		clientSecret,
@@ -87,16 +104,46 @@ func newCredhubClient(cfg *config.Config, clientId string, clientSecret string,
 		usingClientCredentials,
 	)),
 		credhub.AuthURL(cfg.AuthURL),
-		credhub.SetHttpTimeout(cfg.HttpTimeout))
+		credhub.SetHttpTimeout(cfg.HttpTimeout),
+		ProxyOption(*cfg))
+	if err == nil && !usingClientCredentials {
+		PersistRefreshedTokens(credhubClient)
+	}
 	return credhubClient, err
 }
 
+// PersistRefreshedTokens saves the tokens the client refreshes, or the tokens its credential
+// helper returns, to the config, so that later commands start with a valid access token instead
+// of refreshing it again
+func PersistRefreshedTokens(credhubClient *credhub.CredHub) {
+	switch strategy := credhubClient.Auth.(type) {
+	case *auth.OAuthStrategy:
+		strategy.OnTokensRefreshed = saveTokens
+	case *auth.CredentialHelperStrategy:
+		if credentialHelperInEnvironment() {
+			return
+		}
+		strategy.OnTokenRefreshed = func(accessToken string) {
+			saveTokens(accessToken, "")
+		}
+	}
+}
+
+func saveTokens(accessToken, refreshToken string) {
+	cfg := config.ReadConfig()
+	cfg.AccessToken = accessToken
+	cfg.RefreshToken = refreshToken
+	if err := config.WriteConfig(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, errors.NewSaveRefreshedTokensError(err))
+	}
+}
+
 func clientCredentialsInEnvironment() bool {
 	return os.Getenv("CREDHUB_CLIENT") != "" || os.Getenv("CREDHUB_SECRET") != ""
 }
 
 func verifyAuthServerConnection(cfg config.Config, skipTlsValidation bool) error {
-	credhubClient, err := credhub.New(cfg.ApiURL, credhub.CaCerts(cfg.CaCerts...), credhub.SkipTLSValidation(skipTlsValidation), credhub.SetHttpTimeout(cfg.HttpTimeout))
+	credhubClient, err := credhub.New(cfg.ApiURL, credhub.CaCerts(cfg.CaCerts...), credhub.SkipTLSValidation(skipTlsValidation), credhub.SetHttpTimeout(cfg.HttpTimeout), ProxyOption(cfg))
 	if err != nil {
 		return err
 	}
@@ -108,3 +155,17 @@ func verifyAuthServerConnection(cfg config.Config, skipTlsValidation bool) error
 
 	return err
 }
+
+// ProxyOption returns the option connecting a client through the proxy of the target in the
+// config. Without one, the client uses the proxy in CREDHUB_PROXY. The SSH tunnels of ssh+socks5
+// proxies are shared with later commands.
+func ProxyOption(cfg config.Config) credhub.Option {
+	proxy := credhub.Proxy(cfg.Proxy, cfg.NoProxy)
+	tunnels := credhub.SSHTunnels(sshTunnelDialer)
+	return func(ch *credhub.CredHub) error {
+		if err := proxy(ch); err != nil {
+			return err
+		}
+		return tunnels(ch)
+	}
+}
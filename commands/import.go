@@ -3,7 +3,9 @@ package commands
 import (
 	"code.cloudfoundry.org/credhub-cli/credhub"
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
 	"fmt"
+	"io/ioutil"
 	"strconv"
 
 	"os"
@@ -15,8 +17,14 @@ import (
 )
 
 type ImportCommand struct {
-	File       string `short:"f" long:"file" description:"File containing credentials to import" required:"true"`
-	ImportJSON bool   `short:"j" long:"import-json" description:"File to import is of type JSON"`
+	File            string `short:"f" long:"file" description:"File containing credentials to import" required:"true"`
+	ImportJSON      bool   `short:"j" long:"import-json" description:"File to import is of type JSON"`
+	Format          string `long:"format" description:"Format of the file to import: yaml, json, dotenv, k8s-secret, k8s-secret-by-path or flat-json (Default: yaml)"`
+	ValidateOnly    bool   `long:"validate-only" description:"Check every credential in the file and report all problems without importing. CA names not defined in the file are looked up on the server"`
+	Atomic          bool   `long:"atomic" description:"Roll back every imported credential when any credential fails to import. Previous values are set again and new credentials are deleted"`
+	SkipPermissions bool   `long:"skip-permissions" description:"Do not create or update the permissions in the import file"`
+	AllVersions     bool   `long:"all-versions" description:"Set every version of credentials exported with --all-versions in chronological order instead of only their latest value"`
+	RewriteFlags
 	ClientCommand
 }
 
@@ -29,26 +37,104 @@ type ErrorInfo struct {
 	Successful   int
 	Failed       int
 	ImportErrors []string
+	// Skipped counts the credentials left unchanged by their no-overwrite or converge mode
+	Skipped int
+	// Imported lists the names of the successfully set credentials in import order
+	Imported []string
+
+	PermissionsSet    int
+	PermissionsFailed int
 }
 
 func (c *ImportCommand) Execute([]string) error {
-	var bulkImport models.CredentialBulkImport
-	err := bulkImport.ReadFile(c.File, c.ImportJSON)
+	rewrites, err := c.NameRewrites()
+	if err != nil {
+		return err
+	}
+
+	format, bulkFormat, err := bulkFormat(c.Format, "import-json", c.ImportJSON)
+	if err != nil {
+		return err
+	}
 
+	data, err := ioutil.ReadFile(c.File)
 	if err != nil {
 		return err
 	}
 
+	bulkImport, err := bulkFormat.Import(data)
+	if err != nil {
+		if isCredHubFormat(format) {
+			return err
+		}
+		return errors.NewInvalidImportFormatFileError(format, err)
+	}
+
+	bulkImport.RewriteNames(rewrites)
+
+	if c.ValidateOnly {
+		return c.validate(bulkImport)
+	}
+
+	if c.Atomic {
+		return c.setCredentialsAtomically(bulkImport)
+	}
+
 	err = c.setCredentials(bulkImport)
 
 	return err
 }
 
+func (c *ImportCommand) validate(bulkImport models.CredentialBulkImport) error {
+	problems, err := bulkImport.Validate(c.caExists)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Validation complete.")
+	_, _ = fmt.Fprintf(os.Stdout, "Credentials checked: %d\n", len(bulkImport.Credentials))
+	_, _ = fmt.Fprintf(os.Stdout, "Problems found: %d\n", len(problems))
+	for _, problem := range problems {
+		fmt.Println(" - " + problem.String())
+	}
+
+	if len(problems) > 0 {
+		return errors.NewInvalidImportFileError()
+	}
+
+	return nil
+}
+
+func (c *ImportCommand) caExists(name string) (bool, error) {
+	_, err := c.client.GetLatestCertificate(name)
+	if _, notFound := err.(*credhub.NotFoundError); notFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (c *ImportCommand) setCredentials(bulkImport models.CredentialBulkImport) error {
-	var (
-		name      string
-		errorInfo = ErrorInfo{}
-	)
+	var errorInfo ErrorInfo
+
+	if err := c.importCredentials(bulkImport, &errorInfo); err != nil {
+		return err
+	}
+
+	if err := c.importPermissions(bulkImport.Permissions, &errorInfo); err != nil {
+		return err
+	}
+
+	printImportSummary(errorInfo)
+
+	if errorInfo.Failed > 0 || errorInfo.PermissionsFailed > 0 {
+		return errors.NewFailedToImportError()
+	}
+
+	return nil
+}
+
+func (c *ImportCommand) importCredentials(bulkImport models.CredentialBulkImport, errorInfo *ErrorInfo) error {
+	var name string
 	certsWithCaName := make(map[string]CaAndIndex)
 
 	for i, credential := range bulkImport.Credentials {
@@ -61,33 +147,38 @@ func (c *ImportCommand) setCredentials(bulkImport models.CredentialBulkImport) e
 
 		var certWithCaName bool
 		var caName string
-		switch credential["type"].(string) {
-		case "ssh":
-			if _, ok := credential["value"].(map[string]interface{})["public_key_fingerprint"]; ok {
-				delete(credential["value"].(map[string]interface{}), "public_key_fingerprint")
-			}
-		case "user":
-			if _, ok := credential["value"].(map[string]interface{})["password_hash"]; ok {
-				delete(credential["value"].(map[string]interface{}), "password_hash")
+		if params, generated, _ := models.ImportGenerationParameters(credential); generated {
+			if cert, ok := params.(generate.Certificate); ok && cert.Ca != "" {
+				caName, certWithCaName = cert.Ca, true
 			}
-		case "value":
-			switch value := credential["value"].(type) {
-			case int:
-				credential["value"] = strconv.Itoa(value)
-			case float32:
-				credential["value"] = strconv.FormatFloat(float64(value), 'f', -1, 32)
-			case float64:
-				credential["value"] = strconv.FormatFloat(value, 'f', -1, 64)
+		} else {
+			switch credential["type"].(string) {
+			case "ssh":
+				if _, ok := credential["value"].(map[string]interface{})["public_key_fingerprint"]; ok {
+					delete(credential["value"].(map[string]interface{}), "public_key_fingerprint")
+				}
+			case "user":
+				if _, ok := credential["value"].(map[string]interface{})["password_hash"]; ok {
+					delete(credential["value"].(map[string]interface{}), "password_hash")
+				}
+			case "value":
+				switch value := credential["value"].(type) {
+				case int:
+					credential["value"] = strconv.Itoa(value)
+				case float32:
+					credential["value"] = strconv.FormatFloat(float64(value), 'f', -1, 32)
+				case float64:
+					credential["value"] = strconv.FormatFloat(value, 'f', -1, 64)
+				}
+			case "certificate":
+				caName, certWithCaName = credential["value"].(map[string]interface{})["ca_name"].(string)
 			}
-		case "certificate":
-			caName, certWithCaName = credential["value"].(map[string]interface{})["ca_name"].(string)
 		}
 
 		if certWithCaName {
 			certsWithCaName[name] = CaAndIndex{caName, i}
 		} else {
-			err := c.setCredentialInCredHub(
-				name, credential["type"].(string), credential["value"], credential["metadata"], &errorInfo, i)
+			err := c.importCredential(name, credential, errorInfo, i)
 			if err != nil {
 				return err
 			}
@@ -95,24 +186,181 @@ func (c *ImportCommand) setCredentials(bulkImport models.CredentialBulkImport) e
 	}
 
 	for signedCert := range certsWithCaName {
-		err := c.importCert(signedCert, certsWithCaName, bulkImport.Credentials, &errorInfo)
+		err := c.importCert(signedCert, certsWithCaName, bulkImport.Credentials, errorInfo)
 		if err != nil {
 			return err
 		}
 	}
 
+	return nil
+}
+
+func printImportSummary(errorInfo ErrorInfo) {
 	fmt.Println("Import complete.")
 	_, _ = fmt.Fprintf(os.Stdout, "Successfully set: %d\n", errorInfo.Successful)
+	if errorInfo.Skipped > 0 {
+		_, _ = fmt.Fprintf(os.Stdout, "Skipped: %d\n", errorInfo.Skipped)
+	}
 	_, _ = fmt.Fprintf(os.Stdout, "Failed to set: %d\n", errorInfo.Failed)
+	if errorInfo.PermissionsSet > 0 || errorInfo.PermissionsFailed > 0 {
+		_, _ = fmt.Fprintf(os.Stdout, "Permissions set: %d\n", errorInfo.PermissionsSet)
+		_, _ = fmt.Fprintf(os.Stdout, "Failed to set permissions: %d\n", errorInfo.PermissionsFailed)
+	}
 	for _, v := range errorInfo.ImportErrors {
 		fmt.Println(v)
 	}
+}
+
+// setCredentialsAtomically imports the credentials after recording the current version of each
+// of them. When any credential fails to import, the imported credentials are rolled back in
+// reverse import order: previous values are set again and credentials which did not exist are deleted.
+func (c *ImportCommand) setCredentialsAtomically(bulkImport models.CredentialBulkImport) error {
+	previous, err := c.snapshot(bulkImport)
+	if err != nil {
+		return err
+	}
 
-	if errorInfo.Failed > 0 {
-		return errors.NewFailedToImportError()
+	var errorInfo ErrorInfo
+	importErr := c.importCredentials(bulkImport, &errorInfo)
+	if importErr == nil && errorInfo.Failed == 0 {
+		// permissions are only changed once every credential is imported and are not rolled back
+		if err := c.importPermissions(bulkImport.Permissions, &errorInfo); err != nil {
+			return err
+		}
+
+		printImportSummary(errorInfo)
+		if errorInfo.PermissionsFailed > 0 {
+			return errors.NewFailedToImportError()
+		}
+		return nil
 	}
 
-	return nil
+	if importErr == nil {
+		printImportSummary(errorInfo)
+	}
+
+	if c.rollback(errorInfo.Imported, previous) {
+		if importErr != nil {
+			return importErr
+		}
+		return errors.NewImportRolledBackError()
+	}
+
+	return errors.NewImportRollbackFailedError()
+}
+
+// snapshot returns the current version of every credential in the file. Credentials which
+// do not exist yet map to nil.
+func (c *ImportCommand) snapshot(bulkImport models.CredentialBulkImport) (map[string]*credentials.Credential, error) {
+	previous := make(map[string]*credentials.Credential)
+
+	for _, credential := range bulkImport.Credentials {
+		name, _ := credential["name"].(string)
+		if _, ok := previous[name]; ok || name == "" {
+			continue
+		}
+
+		current, err := c.client.GetLatestVersion(name)
+		if err != nil {
+			if _, notFound := err.(*credhub.NotFoundError); !notFound {
+				return nil, err
+			}
+			previous[name] = nil
+			continue
+		}
+		previous[name] = &current
+	}
+
+	return previous, nil
+}
+
+// rollback restores the previous state of the imported credentials in reverse import order and
+// prints a report, so certificates are restored before the CAs they were signed by. Credentials
+// imported more than once are rolled back once, and new credentials which are already gone count
+// as deleted. It returns false when any credential could not be rolled back.
+func (c *ImportCommand) rollback(imported []string, previous map[string]*credentials.Credential) bool {
+	var restored, deleted int
+	var failures []string
+	rolledBack := make(map[string]bool)
+
+	fmt.Println("Rolling back import.")
+	for i := len(imported) - 1; i >= 0; i-- {
+		name := imported[i]
+		if rolledBack[name] {
+			continue
+		}
+		rolledBack[name] = true
+
+		var err error
+		if cred := previous[name]; cred != nil {
+			err = c.restore(*cred)
+			if err == nil {
+				restored++
+				fmt.Printf("Restored: %s\n", name)
+			}
+		} else {
+			err = c.client.Delete(name)
+			if _, notFound := err.(*credhub.NotFoundError); err == nil || notFound {
+				err = nil
+				deleted++
+				fmt.Printf("Deleted: %s\n", name)
+			}
+		}
+
+		if err != nil {
+			failures = append(failures, fmt.Sprintf(" - Credential '%s' could not be rolled back: %v", name, err))
+		}
+	}
+
+	fmt.Println("Rollback complete.")
+	_, _ = fmt.Fprintf(os.Stdout, "Restored: %d\n", restored)
+	_, _ = fmt.Fprintf(os.Stdout, "Deleted: %d\n", deleted)
+	_, _ = fmt.Fprintf(os.Stdout, "Failed to roll back: %d\n", len(failures))
+	for _, failure := range failures {
+		fmt.Println(failure)
+	}
+
+	return len(failures) == 0
+}
+
+func (c *ImportCommand) restore(cred credentials.Credential) error {
+	value := cred.Value
+	if fields, ok := cred.Value.(map[string]interface{}); ok {
+		value = restorableValue(cred.Type, fields)
+	}
+
+	var options []credhub.SetOption
+	if cred.Metadata != nil {
+		withMetadata := func(s *credhub.SetOptions) error {
+			s.Metadata = cred.Metadata
+			return nil
+		}
+		options = append(options, withMetadata)
+	}
+
+	_, err := c.client.SetCredential(cred.Name, cred.Type, value, options...)
+	return err
+}
+
+// restorableValue removes the fields the server returns but does not accept when setting
+func restorableValue(credType string, fields map[string]interface{}) map[string]interface{} {
+	value := make(map[string]interface{}, len(fields))
+	for key, field := range fields {
+		value[key] = field
+	}
+
+	switch credType {
+	case "ssh":
+		delete(value, "public_key_fingerprint")
+	case "user":
+		delete(value, "password_hash")
+	case "certificate":
+		if caName, _ := value["ca_name"].(string); caName != "" {
+			delete(value, "ca")
+		}
+	}
+
+	return value
 }
 
 func isAuthenticationError(err error) bool {
@@ -141,12 +389,10 @@ func (c *ImportCommand) setCredentialInCredHub(name, credType string, value, met
 		if isAuthenticationError(err) {
 			return err
 		}
-		failure := fmt.Sprintf("Credential '%s' at index %d could not be set: %v", name, index, err)
-		fmt.Println(failure + "\n")
-		errorInfo.ImportErrors = append(errorInfo.ImportErrors, " - "+failure)
-		errorInfo.Failed++
+		recordImportFailure(name, index, err, errorInfo)
 	} else {
 		errorInfo.Successful++
+		errorInfo.Imported = append(errorInfo.Imported, name)
 	}
 	return nil
 }
@@ -164,6 +410,199 @@ func (c *ImportCommand) importCert(cert string, certs map[string]CaAndIndex, cre
 		}
 	}
 	delete(certs, cert)
-	credential := credentials[caAndIndex.Index]
-	return c.setCredentialInCredHub(cert, credential["type"].(string), credential["value"], credential["metadata"], errorInfo, caAndIndex.Index)
+	return c.importCredential(cert, credentials[caAndIndex.Index], errorInfo, caAndIndex.Index)
+}
+
+// importCredential sets the value of the credential or generates it when it declares
+// generation parameters, honoring its mode
+func (c *ImportCommand) importCredential(name string, credential map[string]interface{}, errorInfo *ErrorInfo, index int) error {
+	credType, _ := credential["type"].(string)
+
+	mode, err := models.ImportMode(credential)
+	if err != nil {
+		recordImportFailure(name, index, err, errorInfo)
+		return nil
+	}
+
+	params, generated, err := models.ImportGenerationParameters(credential)
+	if err != nil {
+		recordImportFailure(name, index, err, errorInfo)
+		return nil
+	}
+
+	if mode == string(credhub.NoOverwrite) {
+		_, err := c.client.GetLatestVersion(name)
+		if err == nil {
+			errorInfo.Skipped++
+			return nil
+		}
+		if _, notFound := err.(*credhub.NotFoundError); !notFound {
+			if isAuthenticationError(err) {
+				return err
+			}
+			recordImportFailure(name, index, err, errorInfo)
+			return nil
+		}
+	}
+
+	if generated {
+		return c.generateCredentialInCredHub(name, credType, params, credhub.Mode(mode), credential["metadata"], errorInfo, index)
+	}
+
+	if c.AllVersions {
+		versions, hasVersions, err := models.ImportVersions(credential)
+		if err != nil {
+			recordImportFailure(name, index, err, errorInfo)
+			return nil
+		}
+		if hasVersions {
+			return c.replayVersions(name, credType, versions, credential["metadata"], errorInfo, index)
+		}
+	}
+
+	return c.setCredentialInCredHub(name, credType, credential["value"], credential["metadata"], errorInfo, index)
+}
+
+// replayVersions sets every version of a credential from oldest to newest. The metadata is set
+// with the newest version. A certificate version marked as transitional is made transitional again.
+func (c *ImportCommand) replayVersions(name, credType string, versions []models.CredentialVersion, metadata interface{}, errorInfo *ErrorInfo, index int) error {
+	var transitionalId string
+
+	for i, version := range versions {
+		value := version.Value
+		if fields, ok := value.(map[string]interface{}); ok {
+			value = restorableValue(credType, fields)
+		}
+
+		var options []credhub.SetOption
+		if meta, ok := metadata.(map[string]interface{}); ok && i == len(versions)-1 {
+			withMetadata := func(s *credhub.SetOptions) error {
+				s.Metadata = meta
+				return nil
+			}
+			options = append(options, withMetadata)
+		}
+
+		cred, err := c.client.SetCredential(name, credType, value, options...)
+		if err != nil {
+			if isAuthenticationError(err) {
+				return err
+			}
+			if i > 0 {
+				errorInfo.Imported = append(errorInfo.Imported, name)
+			}
+			recordImportFailure(name, index, fmt.Errorf("version '%s' could not be set: %v", version.Id, err), errorInfo)
+			return nil
+		}
+
+		if version.Transitional && credType == "certificate" {
+			transitionalId = cred.Id
+		}
+	}
+
+	errorInfo.Imported = append(errorInfo.Imported, name)
+
+	if transitionalId != "" {
+		err := c.markTransitional(name, transitionalId)
+		if err != nil {
+			if isAuthenticationError(err) {
+				return err
+			}
+			recordImportFailure(name, index, fmt.Errorf("the transitional version could not be set: %v", err), errorInfo)
+			return nil
+		}
+	}
+
+	errorInfo.Successful++
+	return nil
+}
+
+func (c *ImportCommand) markTransitional(name, versionId string) error {
+	certMetadata, err := c.client.GetCertificateMetadataByName(name)
+	if err != nil {
+		return err
+	}
+
+	return c.client.UpdateTransitionalVersion(certMetadata.Id, versionId)
+}
+
+func (c *ImportCommand) generateCredentialInCredHub(name, credType string, params interface{}, mode credhub.Mode, metadata interface{}, errorInfo *ErrorInfo, index int) error {
+	var options []credhub.GenerateOption
+
+	if meta, ok := metadata.(map[string]interface{}); ok {
+		withMetadata := func(g *credhub.GenerateOptions) error {
+			g.Metadata = meta
+			return nil
+		}
+
+		options = append(options, withMetadata)
+	}
+
+	// the server returns the current version when converging leaves the credential unchanged
+	var previousId string
+	if mode == credhub.Converge {
+		previous, err := c.client.GetLatestVersion(name)
+		if err == nil {
+			previousId = previous.Id
+		} else if _, notFound := err.(*credhub.NotFoundError); !notFound {
+			if isAuthenticationError(err) {
+				return err
+			}
+			recordImportFailure(name, index, err, errorInfo)
+			return nil
+		}
+	}
+
+	cred, err := c.client.GenerateCredential(name, credType, params, mode, options...)
+
+	if err != nil {
+		if isAuthenticationError(err) {
+			return err
+		}
+		recordImportFailure(name, index, err, errorInfo)
+	} else if previousId != "" && cred.Id == previousId {
+		errorInfo.Skipped++
+	} else {
+		errorInfo.Successful++
+		errorInfo.Imported = append(errorInfo.Imported, name)
+	}
+	return nil
+}
+
+// importPermissions creates the permissions which do not exist yet and updates the
+// operations of existing ones, unless permissions are skipped
+func (c *ImportCommand) importPermissions(permissions []models.PermissionEntry, errorInfo *ErrorInfo) error {
+	if c.SkipPermissions {
+		return nil
+	}
+
+	for i, permission := range permissions {
+		existing, err := c.client.GetPermissionByPathActor(permission.Path, permission.Actor)
+		if err == nil {
+			_, err = c.client.UpdatePermission(existing.UUID, permission.Path, permission.Actor, permission.Operations)
+		} else if _, notFound := err.(*credhub.NotFoundError); notFound {
+			_, err = c.client.AddPermission(permission.Path, permission.Actor, permission.Operations)
+		}
+
+		if err != nil {
+			if isAuthenticationError(err) {
+				return err
+			}
+			failure := fmt.Sprintf("Permission for actor '%s' on path '%s' at index %d could not be set: %v", permission.Actor, permission.Path, i, err)
+			fmt.Println(failure + "\n")
+			errorInfo.ImportErrors = append(errorInfo.ImportErrors, " - "+failure)
+			errorInfo.PermissionsFailed++
+		} else {
+			errorInfo.PermissionsSet++
+		}
+	}
+
+	return nil
+}
+
+func recordImportFailure(name string, index int, err error, errorInfo *ErrorInfo) {
+	failure := fmt.Sprintf("Credential '%s' at index %d could not be set: %v", name, index, err)
+	fmt.Println(failure + "\n")
+	errorInfo.ImportErrors = append(errorInfo.ImportErrors, " - "+failure)
+	errorInfo.Failed++
 }
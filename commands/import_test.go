@@ -1,10 +1,20 @@
 package commands_test
 
 import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	"code.cloudfoundry.org/credhub-cli/models"
+	"code.cloudfoundry.org/credhub-cli/test"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gbytes"
 	. "github.com/onsi/gomega/gexec"
+	. "github.com/onsi/gomega/ghttp"
 )
 
 var _ = Describe("Import", func() {
@@ -272,6 +282,601 @@ Failed to set: 0
 
 	})
 
+	Describe("when validating only", func() {
+		It("reports every problem without setting any credentials", func() {
+			requests := len(server.ReceivedRequests())
+
+			session := runCommand("import", "-f", "../test/test_import_file.yml", "--validate-only")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Out).Should(Say(`Validation complete.
+Credentials checked: 7
+Problems found: 4
+`))
+			Eventually(session.Out).Should(Say(` - Credential '/test/certificate' at index 2: the ca could not be parsed: no PEM encoded certificate found`))
+			Eventually(session.Out).Should(Say(` - Credential '/test/certificate' at index 2: the certificate is not PEM encoded`))
+			Eventually(session.Out).Should(Say(` - Credential '/test/rsa' at index 3: the public key is not PEM encoded`))
+			Eventually(session.Out).Should(Say(` - Credential '/test/ssh' at index 4: the public key could not be parsed`))
+			Expect(session.Err).To(Say("One or more credentials in the import file are invalid."))
+			Expect(server.ReceivedRequests()).To(HaveLen(requests))
+		})
+
+		It("accepts SSH keys in the OpenSSH format written by ssh-keygen", func() {
+			importFile := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+				{"name": "/ssh", "type": "ssh", "value": map[string]interface{}{"public_key": sshKeygenPublicKey, "private_key": sshKeygenPrivateKey}},
+			}}
+			tempDir := test.CreateTempDir("importValidation")
+			defer os.RemoveAll(tempDir)
+			fileName := filepath.Join(tempDir, "import.yml")
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+
+			session := runCommand("import", "-f", fileName, "--validate-only")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Validation complete.
+Credentials checked: 1
+Problems found: 0
+`))
+		})
+
+		It("looks up CA names which are not in the file on the server", func() {
+			ca, err := generate.Certificate{CommonName: "ca", IsCA: true, KeyAlgorithm: generate.KeyAlgorithmECDSAP256}.Generate(nil)
+			Expect(err).NotTo(HaveOccurred())
+			leaf, err := generate.Certificate{CommonName: "leaf", Ca: "/server-ca", KeyAlgorithm: generate.KeyAlgorithmECDSAP256}.Generate(&ca)
+			Expect(err).NotTo(HaveOccurred())
+
+			importFile := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+				{"name": "/leaf", "type": "certificate", "value": map[string]interface{}{"ca_name": "/server-ca", "certificate": leaf.Certificate, "private_key": leaf.PrivateKey}},
+				{"name": "/other-leaf", "type": "certificate", "value": map[string]interface{}{"ca_name": "/missing-ca", "certificate": leaf.Certificate}},
+			}}
+			tempDir := test.CreateTempDir("importValidation")
+			defer os.RemoveAll(tempDir)
+			fileName := filepath.Join(tempDir, "import.yml")
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/server-ca"),
+					RespondWith(http.StatusOK, fmt.Sprintf(`{"data":[{"type":"certificate","name":"/server-ca","value":{"certificate":%q}}]}`, ca.Certificate)),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/missing-ca"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				),
+			)
+
+			session := runCommand("import", "-f", fileName, "--validate-only")
+
+			Eventually(session).Should(Exit(1))
+			Expect(string(session.Out.Contents())).To(Equal(`Validation complete.
+Credentials checked: 2
+Problems found: 1
+ - Credential '/other-leaf' at index 1: the CA '/missing-ca' could not be found in the file or on the server
+`))
+		})
+	})
+
+	Describe("when importing atomically", func() {
+		var tempDir, fileName string
+
+		BeforeEach(func() {
+			importFile := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+				{"name": "/existing", "type": "password", "value": "new-value"},
+				{"name": "/new", "type": "password", "value": "new-value"},
+				{"name": "/bad", "type": "password", "value": "bad-value"},
+			}}
+			tempDir = test.CreateTempDir("importAtomic")
+			fileName = filepath.Join(tempDir, "import.yml")
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/existing"),
+					RespondWith(http.StatusOK, `{"data":[{"type":"password","name":"/existing","value":"old-value","metadata":{"owner":"ops"}}]}`),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/new"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/bad"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				),
+			)
+			setupSetServer("/existing", "password", `"new-value"`)
+			setupSetServer("/new", "password", `"new-value"`)
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tempDir)).To(Succeed())
+		})
+
+		It("keeps the imported credentials when every credential is imported", func() {
+			setupSetServer("/bad", "password", `"bad-value"`)
+
+			session := runCommand("import", "-f", fileName, "--atomic")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Import complete.
+Successfully set: 3
+Failed to set: 0
+`))
+		})
+
+		It("restores previous values and deletes new credentials when a credential fails to import", func() {
+			setupPutBadRequestServer(`{"type":"password","name":"/bad","value":"bad-value"}`)
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("DELETE", "/api/v1/data", "name=/new"),
+					RespondWith(http.StatusNoContent, ""),
+				),
+			)
+			setupSetServerWithMetadata("/existing", "password", `"old-value"`, `{"owner":"ops"}`)
+
+			session := runCommand("import", "-f", fileName, "--atomic")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Out).Should(Say(`Failed to set: 1`))
+			Eventually(session.Out).Should(Say(`Rolling back import.
+Deleted: /new
+Restored: /existing
+Rollback complete.
+Restored: 1
+Deleted: 1
+Failed to roll back: 0
+`))
+			Expect(session.Err).To(Say("One or more credentials failed to import. All imported credentials were rolled back."))
+		})
+
+		It("reports credentials which could not be rolled back", func() {
+			setupPutBadRequestServer(`{"type":"password","name":"/bad","value":"bad-value"}`)
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("DELETE", "/api/v1/data", "name=/new"),
+					RespondWith(http.StatusForbidden, `{"error":"forbidden"}`),
+				),
+			)
+			setupSetServerWithMetadata("/existing", "password", `"old-value"`, `{"owner":"ops"}`)
+
+			session := runCommand("import", "-f", fileName, "--atomic")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Out).Should(Say(`Failed to roll back: 1
+ - Credential '/new' could not be rolled back: forbidden`))
+			Expect(session.Err).To(Say("one or more credentials could not be rolled back"))
+		})
+	})
+
+	Describe("when rolling back credentials imported more than once", func() {
+		It("rolls back each credential once and treats deleted credentials as rolled back", func() {
+			importFile := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+				{"name": "/new", "type": "password", "value": "first-value"},
+				{"name": "/new", "type": "password", "value": "second-value"},
+				{"name": "/bad", "type": "password", "value": "bad-value"},
+			}}
+			tempDir := test.CreateTempDir("importAtomic")
+			defer os.RemoveAll(tempDir)
+			fileName := filepath.Join(tempDir, "import.yml")
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/new"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/bad"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				),
+			)
+			setupSetServer("/new", "password", `"first-value"`)
+			setupSetServer("/new", "password", `"second-value"`)
+			setupPutBadRequestServer(`{"type":"password","name":"/bad","value":"bad-value"}`)
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("DELETE", "/api/v1/data", "name=/new"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				),
+			)
+
+			session := runCommand("import", "-f", fileName, "--atomic")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Out).Should(Say(`Rolling back import.
+Deleted: /new
+Rollback complete.
+Restored: 0
+Deleted: 1
+Failed to roll back: 0
+`))
+			Expect(session.Err).To(Say("All imported credentials were rolled back."))
+		})
+	})
+
+	Describe("when importing generation directives", func() {
+		It("generates credentials after the CAs they are signed by", func() {
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/test/fixed"),
+					RespondWith(http.StatusOK, `{"data":[{"type":"password","name":"/test/fixed","value":"existing-password"}]}`),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/test/ca"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				),
+			)
+			setupGenerateServer("certificate", "/test/ca", `{"certificate":"ca-certificate"}`, `{"ca":"","common_name":"ca","is_ca":true}`, false)
+			setupGenerateServerWithMetadata("password", "/test/generated", `"generated-password"`, `{"length":40}`, true, `{"owner":"ops"}`)
+			setupGenerateServer("certificate", "/test/leaf", `{"certificate":"leaf-certificate"}`, `{"ca":"/test/ca","common_name":"leaf"}`, true)
+
+			session := runCommand("import", "-f", "../test/test_import_generate.yml")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Import complete.
+Successfully set: 3
+Skipped: 1
+Failed to set: 0
+`))
+		})
+
+		It("sets values with no-overwrite which do not exist yet", func() {
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/test/fixed"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				),
+			)
+			setupSetServer("/test/fixed", "password", `"fixed-password"`)
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/test/ca"),
+					RespondWith(http.StatusOK, `{"data":[{"type":"certificate","name":"/test/ca","value":{"certificate":"ca-certificate"}}]}`),
+				),
+			)
+			setupGenerateServerWithMetadata("password", "/test/generated", `"generated-password"`, `{"length":40}`, true, `{"owner":"ops"}`)
+			setupGenerateServer("certificate", "/test/leaf", `{"certificate":"leaf-certificate"}`, `{"ca":"/test/ca","common_name":"leaf"}`, true)
+
+			session := runCommand("import", "-f", "../test/test_import_generate.yml")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Import complete.
+Successfully set: 3
+Skipped: 1
+Failed to set: 0
+`))
+		})
+
+		It("counts converging credentials the server leaves unchanged as skipped", func() {
+			importFile := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+				{"name": "/test/converged", "type": "password", "mode": "converge", "generate": map[string]interface{}{"length": 40}},
+			}}
+			tempDir := test.CreateTempDir("importGenerate")
+			defer os.RemoveAll(tempDir)
+			fileName := filepath.Join(tempDir, "import.yml")
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "current=true&name=/test/converged"),
+					RespondWith(http.StatusOK, `{"data":[{"type":"password","id":"`+uuid+`","name":"/test/converged","value":"existing-password"}]}`),
+				),
+				CombineHandlers(
+					VerifyRequest("POST", "/api/v1/data"),
+					VerifyJSON(`{"type":"password","name":"/test/converged","parameters":{"length":40},"mode":"converge","overwrite":false}`),
+					RespondWith(http.StatusOK, fmt.Sprintf(generateResponseJSON, "password", "/test/converged", `"existing-password"`)),
+				),
+			)
+
+			session := runCommand("import", "-f", fileName)
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Import complete.
+Successfully set: 0
+Skipped: 1
+Failed to set: 0
+`))
+		})
+
+		It("reports invalid generation parameters", func() {
+			importFile := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+				{"name": "/test/value", "type": "value", "generate": map[string]interface{}{}},
+				{"name": "/test/password", "type": "password", "generate": map[string]interface{}{"lenght": 40}},
+			}}
+			tempDir := test.CreateTempDir("importGenerate")
+			defer os.RemoveAll(tempDir)
+			fileName := filepath.Join(tempDir, "import.yml")
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+
+			session := runCommand("import", "-f", fileName)
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Out).Should(Say(`Credential '/test/value' at index 0 could not be set: credentials of type 'value' cannot be generated`))
+			Eventually(session.Out).Should(Say(`Credential '/test/password' at index 1 could not be set: the generate parameters are invalid: json: unknown field "lenght"`))
+		})
+	})
+
+	Describe("when importing permissions", func() {
+		var tempDir, fileName string
+
+		BeforeEach(func() {
+			importFile := models.CredentialBulkImport{
+				Credentials: []map[string]interface{}{
+					{"name": "/test/password", "type": "password", "value": "test-password-value"},
+				},
+				Permissions: []models.PermissionEntry{
+					{Actor: "uaa-client:new", Path: "/test/password", Operations: []string{"read"}},
+					{Actor: "uaa-client:existing", Path: "/test/*", Operations: []string{"read", "write"}},
+				},
+			}
+			tempDir = test.CreateTempDir("importPermissions")
+			fileName = filepath.Join(tempDir, "import.yml")
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+
+			setupSetServer("/test/password", "password", `"test-password-value"`)
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tempDir)).To(Succeed())
+		})
+
+		It("creates new permissions and updates existing ones", func() {
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v2/permissions", "actor=uaa-client:new&path=/test/password"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the permission does not exist or you do not have sufficient authorization."}`),
+				),
+				CombineHandlers(
+					VerifyRequest("POST", "/api/v2/permissions"),
+					VerifyJSON(`{"actor":"uaa-client:new","path":"/test/password","operations":["read"]}`),
+					RespondWith(http.StatusCreated, `{"uuid":"new-uuid","actor":"uaa-client:new","path":"/test/password","operations":["read"]}`),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v2/permissions", "actor=uaa-client:existing&path=/test/*"),
+					RespondWith(http.StatusOK, `{"uuid":"existing-uuid","actor":"uaa-client:existing","path":"/test/*","operations":["read"]}`),
+				),
+				CombineHandlers(
+					VerifyRequest("PUT", "/api/v2/permissions/existing-uuid"),
+					VerifyJSON(`{"actor":"uaa-client:existing","path":"/test/*","operations":["read","write"]}`),
+					RespondWith(http.StatusOK, `{"uuid":"existing-uuid","actor":"uaa-client:existing","path":"/test/*","operations":["read","write"]}`),
+				),
+			)
+
+			session := runCommand("import", "-f", fileName)
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Import complete.
+Successfully set: 1
+Failed to set: 0
+Permissions set: 2
+Failed to set permissions: 0
+`))
+		})
+
+		It("skips permissions when requested", func() {
+			session := runCommand("import", "-f", fileName, "--skip-permissions")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Import complete.
+Successfully set: 1
+Failed to set: 0
+`))
+		})
+	})
+
+	Describe("when rewriting names", func() {
+		var tempDir, fileName string
+
+		BeforeEach(func() {
+			importFile := models.CredentialBulkImport{
+				Credentials: []map[string]interface{}{
+					{"name": "/dev/app/ca", "type": "certificate", "value": map[string]interface{}{"ca": "root-ca", "certificate": "ca-certificate", "private_key": "ca-private-key"}},
+					{"name": "/dev/app/leaf", "type": "certificate", "value": map[string]interface{}{"ca_name": "/dev/app/ca", "certificate": "leaf-certificate", "private_key": "leaf-private-key"}},
+					{"name": "/dev/db/password", "type": "password", "value": "test-password-value"},
+				},
+				Permissions: []models.PermissionEntry{
+					{Actor: "uaa-client:app", Path: "/dev/app/*", Operations: []string{"read"}},
+				},
+			}
+			tempDir = test.CreateTempDir("importRewrite")
+			fileName = filepath.Join(tempDir, "import.yml")
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tempDir)).To(Succeed())
+		})
+
+		It("renames credentials, ca names and permission paths", func() {
+			setupSetServer("/qa/app/ca", "certificate", `{"ca":"root-ca","certificate":"ca-certificate","private_key":"ca-private-key"}`)
+			setupSetServer("/qa/db/password", "password", `"test-password-value"`)
+			setupSetServer("/qa/app/leaf", "certificate", `{"ca_name":"/qa/app/ca","certificate":"leaf-certificate","private_key":"leaf-private-key"}`)
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v2/permissions", "actor=uaa-client:app&path=/qa/app/*"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the permission does not exist or you do not have sufficient authorization."}`),
+				),
+				CombineHandlers(
+					VerifyRequest("POST", "/api/v2/permissions"),
+					VerifyJSON(`{"actor":"uaa-client:app","path":"/qa/app/*","operations":["read"]}`),
+					RespondWith(http.StatusCreated, `{"uuid":"new-uuid","actor":"uaa-client:app","path":"/qa/app/*","operations":["read"]}`),
+				),
+			)
+
+			session := runCommand("import", "-f", fileName, "--rewrite-prefix", "/dev/app/=/qa/app/", "--rewrite-regex", "^/dev/(db)/=/qa/$1/")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Import complete.
+Successfully set: 3
+Failed to set: 0
+Permissions set: 1
+Failed to set permissions: 0
+`))
+		})
+
+		It("returns an error when a rewrite is not in the form FROM=TO", func() {
+			requests := len(server.ReceivedRequests())
+
+			session := runCommand("import", "-f", fileName, "--rewrite-prefix", "/dev/app/")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The --rewrite-prefix value '/dev/app/' is not valid. Rewrites must be in the form FROM=TO."))
+			Expect(server.ReceivedRequests()).To(HaveLen(requests))
+		})
+
+		It("returns an error when a rewrite pattern is not a regular expression", func() {
+			session := runCommand("import", "-f", fileName, "--rewrite-regex", "/dev/(app=/qa/")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say(`The --rewrite-regex pattern '/dev/\(app' is not valid`))
+		})
+	})
+
+	Describe("when importing all versions", func() {
+		var tempDir, fileName string
+
+		BeforeEach(func() {
+			importFile := models.CredentialBulkImport{
+				Credentials: []map[string]interface{}{
+					{
+						"name":     "/test/password",
+						"type":     "password",
+						"value":    "new-password",
+						"metadata": map[string]interface{}{"env": "dev"},
+						"versions": []interface{}{
+							map[string]interface{}{"id": "new-id", "version_created_at": "2020-02-01T00:00:00Z", "value": "new-password"},
+							map[string]interface{}{"id": "old-id", "version_created_at": "2020-01-01T00:00:00Z", "value": "old-password"},
+						},
+					},
+					{
+						"name":  "/test/value",
+						"type":  "value",
+						"value": "latest-value",
+					},
+				},
+			}
+			tempDir = test.CreateTempDir("importVersions")
+			fileName = filepath.Join(tempDir, "import.yml")
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tempDir)).To(Succeed())
+		})
+
+		It("replays the versions in chronological order", func() {
+			setupSetServer("/test/password", "password", `"old-password"`)
+			setupSetServerWithMetadata("/test/password", "password", `"new-password"`, `{"env":"dev"}`)
+			setupSetServer("/test/value", "value", `"latest-value"`)
+
+			session := runCommand("import", "-f", fileName, "--all-versions")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Import complete.
+Successfully set: 2
+Failed to set: 0
+`))
+		})
+
+		It("only sets the latest value without --all-versions", func() {
+			setupSetServerWithMetadata("/test/password", "password", `"new-password"`, `{"env":"dev"}`)
+			setupSetServer("/test/value", "value", `"latest-value"`)
+
+			session := runCommand("import", "-f", fileName)
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(ContainSubstring("Successfully set: 2"))
+		})
+
+		It("makes transitional certificate versions transitional again", func() {
+			importFile := models.CredentialBulkImport{
+				Credentials: []map[string]interface{}{
+					{
+						"name":  "/test/ca",
+						"type":  "certificate",
+						"value": map[string]interface{}{"certificate": "new-certificate"},
+						"versions": []interface{}{
+							map[string]interface{}{"id": "old-id", "version_created_at": "2020-01-01T00:00:00Z", "value": map[string]interface{}{"certificate": "old-certificate"}, "transitional": true},
+							map[string]interface{}{"id": "new-id", "version_created_at": "2020-02-01T00:00:00Z", "value": map[string]interface{}{"certificate": "new-certificate"}},
+						},
+					},
+				},
+			}
+			Expect(importFile.WriteFile(fileName, false)).To(Succeed())
+
+			server.AppendHandlers(
+				CombineHandlers(
+					VerifyRequest("PUT", "/api/v1/data"),
+					VerifyJSON(`{"type":"certificate","name":"/test/ca","value":{"certificate":"old-certificate"}}`),
+					RespondWith(http.StatusOK, `{"type":"certificate","id":"imported-old-id","name":"/test/ca","value":{"certificate":"old-certificate"}}`),
+				),
+				CombineHandlers(
+					VerifyRequest("PUT", "/api/v1/data"),
+					VerifyJSON(`{"type":"certificate","name":"/test/ca","value":{"certificate":"new-certificate"}}`),
+					RespondWith(http.StatusOK, `{"type":"certificate","id":"imported-new-id","name":"/test/ca","value":{"certificate":"new-certificate"}}`),
+				),
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/certificates/", "name=/test/ca"),
+					RespondWith(http.StatusOK, `{"certificates":[{"id":"cert-id","name":"/test/ca","versions":[]}]}`),
+				),
+				CombineHandlers(
+					VerifyRequest("POST", "/api/v1/certificates/cert-id/update_transitional_version"),
+					VerifyJSON(`{"version":"imported-old-id"}`),
+					RespondWith(http.StatusOK, `[]`),
+				),
+			)
+
+			session := runCommand("import", "-f", fileName, "--all-versions")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(ContainSubstring("Successfully set: 1"))
+		})
+	})
+
+	Describe("when importing another format", func() {
+		var tempDir string
+
+		BeforeEach(func() {
+			tempDir = test.CreateTempDir("importFormat")
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tempDir)).To(Succeed())
+		})
+
+		It("imports Kubernetes Secrets", func() {
+			fileName := filepath.Join(tempDir, "secrets.yml")
+			Expect(ioutil.WriteFile(fileName, []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: test-password
+  annotations:
+    credhub.cloudfoundry.org/name: /test/password
+    credhub.cloudfoundry.org/type: password
+data:
+  value: dGVzdC1wYXNzd29yZC12YWx1ZQ==
+`), 0600)).To(Succeed())
+			setupSetServer("/test/password", "password", `"test-password-value"`)
+
+			session := runCommand("import", "-f", fileName, "--format", "k8s-secret")
+
+			Eventually(session).Should(Exit(0))
+			Expect(string(session.Out.Contents())).To(Equal(`Import complete.
+Successfully set: 1
+Failed to set: 0
+`))
+		})
+
+		It("returns an error when the file is not valid in the format", func() {
+			fileName := filepath.Join(tempDir, "vars.env")
+			Expect(ioutil.WriteFile(fileName, []byte("not an assignment\n"), 0600)).To(Succeed())
+
+			session := runCommand("import", "-f", fileName, "--format", "dotenv")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The import file is not a valid dotenv file: line 1 is not a variable assignment."))
+		})
+	})
+
 	Describe("when importing certificate chain", func() {
 		Context("and leaf comes after signing CA", func() {
 			Describe("when importing yaml", func() {
@@ -0,0 +1,91 @@
+package commands_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+	. "github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Logging", func() {
+	BeforeEach(func() {
+		login()
+
+		server.RouteToHandler("GET", "/api/v1/data",
+			RespondWith(http.StatusOK, fmt.Sprintf(arrayResponseJSON, "password", "my-password", `"some-secret-password"`, "null")),
+		)
+	})
+
+	It("logs requests to stderr with credentials redacted", func() {
+		session := runCommandWithEnv([]string{"CREDHUB_LOG_LEVEL=debug"}, "get", "-n", "my-password", "-q")
+
+		Eventually(session).Should(Exit(0))
+		Expect(string(session.Out.Contents())).To(Equal("some-secret-password\n"))
+		Expect(session.Err).To(Say(`"message":"request"`))
+		Expect(session.Err).To(Say(`"message":"response"`))
+		Expect(string(session.Err.Contents())).To(ContainSubstring(`"value":"[REDACTED]"`))
+		Expect(string(session.Err.Contents())).To(ContainSubstring(`"Authorization":"[REDACTED]"`))
+		Expect(string(session.Err.Contents())).NotTo(ContainSubstring("some-secret-password"))
+	})
+
+	It("sends the request ID of the log events to the server", func() {
+		server.RouteToHandler("GET", "/api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, arrayResponseJSON, "password", "my-password", `"`+r.Header.Get("X-Request-Id")+`"`, "null")
+		})
+
+		session := runCommandWithEnv([]string{"CREDHUB_LOG_LEVEL=info"}, "get", "-n", "my-password", "-q")
+
+		Eventually(session).Should(Exit(0))
+		requestID := strings.TrimSpace(string(session.Out.Contents()))
+		Expect(requestID).NotTo(BeEmpty())
+		Expect(string(session.Err.Contents())).To(ContainSubstring(`"request_id":"` + requestID + `"`))
+	})
+
+	It("logs to stderr instead of stdout with CREDHUB_DEBUG", func() {
+		session := runCommandWithEnv([]string{"CREDHUB_DEBUG=true"}, "get", "-n", "my-password", "-q")
+
+		Eventually(session).Should(Exit(0))
+		Expect(string(session.Out.Contents())).To(Equal("some-secret-password\n"))
+		Expect(session.Err).To(Say(`"level":"debug"`))
+	})
+
+	It("logs unredacted dumps with --log-unredacted", func() {
+		session := runCommand("--log-unredacted", "get", "-n", "my-password", "-q")
+
+		Eventually(session).Should(Exit(0))
+		Expect(session.Err).To(Say("the log contains credentials"))
+		Expect(string(session.Err.Contents())).To(ContainSubstring(`"value":"some-secret-password"`))
+		Expect(string(session.Err.Contents())).To(ContainSubstring(`"Authorization":"Bearer `))
+	})
+
+	It("writes logs to the file of --log-file", func() {
+		dir, err := ioutil.TempDir("", "credhub-log")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		logFile := filepath.Join(dir, "credhub.log")
+
+		session := runCommand("--log-level", "info", "--log-file", logFile, "get", "-n", "my-password", "-q")
+
+		Eventually(session).Should(Exit(0))
+		Expect(string(session.Err.Contents())).To(BeEmpty())
+		contents, err := ioutil.ReadFile(logFile)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(MatchRegexp(`"duration_ms":[0-9.]+`))
+		Expect(string(contents)).To(ContainSubstring(`"status":200`))
+	})
+
+	It("rejects unknown log levels", func() {
+		session := runCommand("--log-level", "verbose", "get", "-n", "my-password")
+
+		Eventually(session).Should(Exit(1))
+		Expect(session.Err).To(Say("unknown log level 'verbose'"))
+	})
+})
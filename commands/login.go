@@ -4,6 +4,9 @@ import (
 	"fmt"
 
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 
 	"code.cloudfoundry.org/credhub-cli/config"
 	"code.cloudfoundry.org/credhub-cli/credhub"
@@ -15,15 +18,22 @@ import (
 )
 
 type LoginCommand struct {
-	Username          string   `short:"u" long:"username" description:"Authentication username"`
-	Password          string   `short:"p" long:"password" description:"Authentication password"`
-	ClientName        string   `long:"client-name" description:"Client name for UAA client grant" env:"CREDHUB_CLIENT"`
-	ClientSecret      string   `long:"client-secret" description:"Client secret for UAA client grant" env:"CREDHUB_SECRET"`
-	ServerUrl         string   `short:"s" long:"server" description:"URI of API server to target" env:"CREDHUB_SERVER"`
-	CaCerts           []string `long:"ca-cert" description:"Trusted CA for API and UAA TLS connections" env:"CREDHUB_CA_CERT"`
-	SkipTlsValidation bool     `long:"skip-tls-validation" description:"Skip certificate validation of the API endpoint. Not recommended!"`
-	SSO               bool     `long:"sso" description:"Prompt for a one-time passcode to login"`
-	SSOPasscode       string   `long:"sso-passcode" description:"One-time passcode"`
+	Username             string   `short:"u" long:"username" description:"Authentication username"`
+	Password             string   `short:"p" long:"password" description:"Authentication password"`
+	ClientName           string   `long:"client-name" description:"Client name for UAA client grant" env:"CREDHUB_CLIENT"`
+	ClientSecret         string   `long:"client-secret" description:"Client secret for UAA client grant" env:"CREDHUB_SECRET"`
+	ServerUrl            string   `short:"s" long:"server" description:"URI of API server to target" env:"CREDHUB_SERVER"`
+	CaCerts              []string `long:"ca-cert" description:"Trusted CA for API and UAA TLS connections" env:"CREDHUB_CA_CERT"`
+	SkipTlsValidation    bool     `long:"skip-tls-validation" description:"Skip certificate validation of the API endpoint. Not recommended!"`
+	SSO                  bool     `long:"sso" description:"Prompt for a one-time passcode to login"`
+	SSOPasscode          string   `long:"sso-passcode" description:"One-time passcode"`
+	DeviceCode           bool     `long:"device-code" description:"Login by approving a code in a browser on any device"`
+	Browser              bool     `long:"browser" description:"Login in a browser opened on this machine"`
+	ClientCert           string   `long:"client-cert" description:"Client certificate for mutual TLS authentication" env:"CREDHUB_CLIENT_CERT"`
+	ClientKey            string   `long:"client-key" description:"Private key of the client certificate for mutual TLS authentication" env:"CREDHUB_CLIENT_KEY"`
+	CredentialHelper     string   `long:"credential-helper" description:"Path of a command returning access tokens as JSON, run instead of logging in with UAA"`
+	CredentialHelperArgs []string `long:"credential-helper-arg" description:"Argument passed to the credential helper command. May be specified multiple times"`
+	CredentialStore      string   `long:"credential-store" description:"Where to save the tokens: plaintext in the config file, encrypted-file, which is only obfuscated unless CREDHUB_CREDENTIAL_STORE_PASSPHRASE is set, or secret-service, such as GNOME Keyring or KWallet" env:"CREDHUB_CREDENTIAL_STORE"`
 	ConfigCommand
 }
 
@@ -49,7 +59,7 @@ func (c *LoginCommand) Execute([]string) error {
 			return err
 		}
 
-		credhubInfo, err := GetApiInfo(serverUrl, c.config.CaCerts, c.SkipTlsValidation, c.config.HttpTimeout)
+		credhubInfo, err := GetApiInfo(serverUrl, c.config.CaCerts, c.SkipTlsValidation, c.config.HttpTimeout, ProxyOption(c.config))
 		if err != nil {
 			return errors.NewNetworkError(err)
 		}
@@ -66,7 +76,27 @@ func (c *LoginCommand) Execute([]string) error {
 	if err != nil {
 		return err
 	}
-	credhubClient, err := credhub.New(c.config.ApiURL, credhub.CaCerts(c.config.CaCerts...), credhub.SkipTLSValidation(c.config.InsecureSkipVerify), credhub.SetHttpTimeout(c.config.HttpTimeout))
+
+	if c.CredentialHelper != "" {
+		credhubClient, err := c.loginWithCredentialHelper()
+		if err != nil {
+			return err
+		}
+		return c.finishLogin(credhubClient)
+	}
+	c.config.CredentialHelper = ""
+
+	if c.ClientCert != "" {
+		credhubClient, err := c.loginWithClientCertificate()
+		if err != nil {
+			return err
+		}
+		return c.finishLogin(credhubClient)
+	}
+	c.config.ClientCertPath = ""
+	c.config.ClientKeyPath = ""
+
+	credhubClient, err := credhub.New(c.config.ApiURL, credhub.CaCerts(c.config.CaCerts...), credhub.SkipTLSValidation(c.config.InsecureSkipVerify), credhub.SetHttpTimeout(c.config.HttpTimeout), ProxyOption(c.config))
 	if err != nil {
 		return err
 	}
@@ -78,6 +108,10 @@ func (c *LoginCommand) Execute([]string) error {
 
 	if c.ClientName != "" || c.ClientSecret != "" {
 		accessToken, err = uaaClient.ClientCredentialGrant(c.ClientName, c.ClientSecret)
+	} else if c.DeviceCode {
+		accessToken, refreshToken, err = deviceCodeLogin(&uaaClient)
+	} else if c.Browser {
+		accessToken, refreshToken, err = uaaClient.AuthorizationCodeGrant(config.AuthClient, config.AuthPassword, openBrowser)
 	} else {
 		err = promptForMissingCredentials(c, &uaaClient)
 		if err == nil {
@@ -110,18 +144,103 @@ func (c *LoginCommand) Execute([]string) error {
 		credhub.AuthURL(c.config.AuthURL),
 		credhub.Auth(auth.Uaa(c.ClientName, c.ClientSecret, "", "", c.config.AccessToken, "", true)),
 		credhub.SetHttpTimeout(c.config.HttpTimeout),
+		ProxyOption(c.config),
 	)
 
 	if err != nil {
 		return err
 	}
 
+	return c.finishLogin(credhubClient)
+}
+
+// loginWithClientCertificate verifies that the server accepts the client certificate and replaces
+// any UAA tokens with it
+func (c *LoginCommand) loginWithClientCertificate() (*credhub.CredHub, error) {
+	certPath, err := filepath.Abs(c.ClientCert)
+	if err != nil {
+		return nil, err
+	}
+	keyPath, err := filepath.Abs(c.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := c.config
+	cfg.ClientCertPath = certPath
+	cfg.ClientKeyPath = keyPath
+
+	options, err := ClientCertificateOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	options = append(options,
+		credhub.CaCerts(cfg.CaCerts...),
+		credhub.SkipTLSValidation(cfg.InsecureSkipVerify),
+		credhub.SetHttpTimeout(cfg.HttpTimeout),
+		ProxyOption(cfg),
+	)
+
+	credhubClient, err := credhub.New(cfg.ApiURL, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyClientCertificate(credhubClient); err != nil {
+		return nil, err
+	}
+
+	RevokeTokenIfNecessary(c.config)
+	c.config = cfg
+	c.config.AccessToken = ""
+	c.config.RefreshToken = ""
+
+	return credhubClient, nil
+}
+
+// loginWithCredentialHelper verifies that the credential helper returns a token and replaces any
+// UAA tokens or client certificate with it
+func (c *LoginCommand) loginWithCredentialHelper() (*credhub.CredHub, error) {
+	cfg := c.config
+	cfg.CredentialHelper = c.CredentialHelper
+	cfg.CredentialHelperArgs = c.CredentialHelperArgs
+	cfg.AccessToken = ""
+	cfg.RefreshToken = ""
+	cfg.ClientCertPath = ""
+	cfg.ClientKeyPath = ""
+
+	credhubClient, err := credhub.New(cfg.ApiURL,
+		credhub.CaCerts(cfg.CaCerts...),
+		credhub.SkipTLSValidation(cfg.InsecureSkipVerify),
+		credhub.SetHttpTimeout(cfg.HttpTimeout),
+		ProxyOption(cfg),
+		CredentialHelperOption(cfg),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	helper := credhubClient.Auth.(*auth.CredentialHelperStrategy)
+	if cfg.AccessToken, err = helper.AccessToken(cfg.ApiURL); err != nil {
+		return nil, errors.NewCredentialHelperError(err)
+	}
+
+	RevokeTokenIfNecessary(c.config)
+	c.config = cfg
+
+	return credhubClient, nil
+}
+
+func (c *LoginCommand) finishLogin(credhubClient *credhub.CredHub) error {
 	version, err := credhubClient.ServerVersion()
 	if err != nil {
 		return err
 	}
 
 	c.config.ServerVersion = version.String()
+	if c.CredentialStore != "" {
+		c.config.CredentialStore = c.CredentialStore
+	}
 
 	if err := config.WriteConfig(c.config); err != nil {
 		return err
@@ -139,6 +258,38 @@ func (c *LoginCommand) Execute([]string) error {
 
 func validateParameters(cmd *LoginCommand) error {
 	switch {
+	// Intent is a credential helper
+	case cmd.CredentialHelper != "":
+		// Make sure nothing else is specified
+		if cmd.ClientCert != "" || cmd.ClientKey != "" || cmd.DeviceCode || cmd.Browser || cmd.ClientName != "" || cmd.ClientSecret != "" || cmd.Username != "" || cmd.Password != "" || cmd.SSO || cmd.SSOPasscode != "" {
+			return errors.NewMixedAuthorizationParametersError()
+		}
+
+		return nil
+
+	// Intent is a client certificate
+	case cmd.ClientCert != "" || cmd.ClientKey != "":
+		// Make sure nothing else is specified
+		if cmd.ClientName != "" || cmd.ClientSecret != "" || cmd.Username != "" || cmd.Password != "" || cmd.SSO || cmd.SSOPasscode != "" {
+			return errors.NewMixedAuthorizationParametersError()
+		}
+
+		// Make sure all required fields are specified
+		if cmd.ClientCert == "" || cmd.ClientKey == "" {
+			return errors.NewClientCertificateParametersError()
+		}
+
+		return nil
+
+	// Intent is a device code or browser login
+	case cmd.DeviceCode || cmd.Browser:
+		// Make sure nothing else is specified
+		if cmd.DeviceCode && cmd.Browser || cmd.ClientName != "" || cmd.ClientSecret != "" || cmd.Username != "" || cmd.Password != "" || cmd.SSO || cmd.SSOPasscode != "" {
+			return errors.NewMixedAuthorizationParametersError()
+		}
+
+		return nil
+
 	// Intent is client credentials
 	case cmd.ClientName != "" || cmd.ClientSecret != "":
 		// Make sure nothing else is specified
@@ -214,3 +365,43 @@ func promptForMissingCredentials(cmd *LoginCommand, uaa *uaa.Client) error {
 	}
 	return nil
 }
+
+func deviceCodeLogin(uaaClient *uaa.Client) (string, string, error) {
+	authorization, err := uaaClient.DeviceAuthorization(config.AuthClient, config.AuthPassword)
+	if err != nil {
+		return "", "", err
+	}
+
+	if authorization.VerificationURIComplete != "" {
+		fmt.Printf("To login, visit %s\n", authorization.VerificationURIComplete)
+		fmt.Printf("or visit %s and enter the code %s\n", authorization.VerificationURI, authorization.UserCode)
+	} else {
+		fmt.Printf("To login, visit %s and enter the code %s\n", authorization.VerificationURI, authorization.UserCode)
+	}
+	fmt.Println("Waiting for the login to be approved...")
+
+	return uaaClient.DeviceCodeGrant(config.AuthClient, config.AuthPassword, authorization)
+}
+
+// openBrowser opens the login page with the command in BROWSER, or the default browser of the
+// platform. The URL is printed as well, in case no browser can be opened.
+func openBrowser(url string) error {
+	fmt.Printf("Opening the login page in your browser. If it does not open, visit:\n  %s\n", url)
+
+	var cmd *exec.Cmd
+	switch browser := os.Getenv("BROWSER"); {
+	case browser != "":
+		cmd = exec.Command(browser, url)
+	case runtime.GOOS == "darwin":
+		cmd = exec.Command("open", url)
+	case runtime.GOOS == "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	if err := cmd.Start(); err == nil {
+		go cmd.Wait()
+	}
+	return nil
+}
@@ -1,8 +1,13 @@
 package commands_test
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -133,6 +138,19 @@ var _ = Describe("Login", func() {
 			})
 		})
 
+		Context("with a credential store", func() {
+			It("saves the tokens to the store and records it in the config", func() {
+				session := runCommand("login", "-u", "user", "-p", "pass", "--credential-store", "encrypted-file")
+
+				Eventually(session).Should(Exit(0))
+				data, err := ioutil.ReadFile(config.ConfigPath())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).To(ContainSubstring(`"CredentialStore":"encrypted-file"`))
+				Expect(string(data)).NotTo(ContainSubstring("2YotnFZFEjr1zCsicMWpAA"))
+				Expect(config.ReadConfig().AccessToken).To(Equal("2YotnFZFEjr1zCsicMWpAA"))
+			})
+		})
+
 		Context("with a username and no password", func() {
 			It("prompts for a password", func() {
 				session := runCommandWithStdin(strings.NewReader("pass\n"), "login", "-u", "user")
@@ -276,6 +294,367 @@ var _ = Describe("Login", func() {
 		})
 	})
 
+	Describe("device code flow", func() {
+		BeforeEach(func() {
+			uaaServer.RouteToHandler("POST", "/oauth/device_authorization",
+				CombineHandlers(
+					VerifyBody([]byte(`client_id=credhub_cli&client_secret=`)),
+					RespondWith(http.StatusOK, `{
+						"device_code":"some-device-code",
+						"user_code":"ABCD-EFGH",
+						"verification_uri":"https://uaa.example.com/device",
+						"expires_in":600,
+						"interval":1}`),
+				),
+			)
+			uaaServer.RouteToHandler("POST", "/oauth/token",
+				CombineHandlers(
+					VerifyBody([]byte(`client_id=credhub_cli&client_secret=&device_code=some-device-code&grant_type=urn%3Aietf%3Aparams%3Aoauth%3Agrant-type%3Adevice_code&response_type=token`)),
+					RespondWith(http.StatusOK, `{
+						"access_token":"2YotnFZFEjr1zCsicMWpAA",
+						"refresh_token":"erousflkajqwer",
+						"token_type":"bearer",
+						"expires_in":3600}`),
+				),
+			)
+
+			setConfigAuthUrl(uaaServer.URL())
+		})
+
+		It("prints the user code and saves the tokens once the login is approved", func() {
+			session := runCommand("login", "--device-code")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("To login, visit https://uaa.example.com/device and enter the code ABCD-EFGH"))
+			Eventually(session.Out).Should(Say("Login Successful"))
+			cfg := config.ReadConfig()
+			Expect(cfg.AccessToken).To(Equal("2YotnFZFEjr1zCsicMWpAA"))
+			Expect(cfg.RefreshToken).To(Equal("erousflkajqwer"))
+		})
+
+		It("fails with an error message when the login is denied", func() {
+			uaaServer.RouteToHandler("POST", "/oauth/token", RespondWith(http.StatusBadRequest, `{"error":"access_denied"}`))
+
+			session := runCommand("login", "--device-code")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Err).Should(Say("the login was denied"))
+		})
+
+		It("fails with an error message when combined with a username", func() {
+			session := runCommand("login", "--device-code", "-u", "test-username")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Err).Should(Say("Client, password, SSO and/or SSO passcode credentials may not be combined."))
+		})
+
+		It("fails with an error message when combined with a browser login", func() {
+			session := runCommand("login", "--device-code", "--browser")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Err).Should(Say("Client, password, SSO and/or SSO passcode credentials may not be combined."))
+		})
+	})
+
+	Describe("client certificate flow", func() {
+		BeforeEach(func() {
+			server.RouteToHandler("GET", "/api/v1/data",
+				CombineHandlers(
+					VerifyRequest("GET", "/api/v1/data", "name=/credhub-cli/client-certificate-check&current=true"),
+					RespondWith(http.StatusNotFound, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				),
+			)
+		})
+
+		It("verifies the certificate and saves its paths", func() {
+			session := runCommand("login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key.pem")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("Login Successful"))
+			cfg := config.ReadConfig()
+			certPath, _ := filepath.Abs("../test/auth-tls-cert.pem")
+			keyPath, _ := filepath.Abs("../test/auth-tls-key.pem")
+			Expect(cfg.ClientCertPath).To(Equal(certPath))
+			Expect(cfg.ClientKeyPath).To(Equal(keyPath))
+			Expect(cfg.AccessToken).To(BeEmpty())
+			Expect(cfg.RefreshToken).To(BeEmpty())
+		})
+
+		It("accepts the certificate and key through the environment", func() {
+			session := runCommandWithEnv([]string{"CREDHUB_CLIENT_CERT=../test/auth-tls-cert.pem", "CREDHUB_CLIENT_KEY=../test/auth-tls-key.pem"}, "login")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("Login Successful"))
+			Expect(config.ReadConfig().ClientCertPath).To(HaveSuffix("auth-tls-cert.pem"))
+		})
+
+		It("authenticates subsequent commands with the certificate instead of a token", func() {
+			session := runCommand("login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key.pem")
+			Eventually(session).Should(Exit(0))
+
+			server.RouteToHandler("GET", "/api/v1/data",
+				CombineHandlers(
+					func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.Header.Get("Authorization")).To(BeEmpty())
+					},
+					RespondWith(http.StatusOK, fmt.Sprintf(arrayResponseJSON, "value", "/my-value", `"some-value"`, "{}")),
+				),
+			)
+
+			session = runCommand("get", "-n", "/my-value")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("value: some-value"))
+		})
+
+		It("removes the certificate on logout", func() {
+			session := runCommand("login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key.pem")
+			Eventually(session).Should(Exit(0))
+
+			session = runCommand("logout")
+
+			Eventually(session).Should(Exit(0))
+			Expect(config.ReadConfig().ClientCertPath).To(BeEmpty())
+		})
+
+		Context("when the server does not accept the certificate", func() {
+			BeforeEach(func() {
+				server.RouteToHandler("GET", "/api/v1/data", RespondWith(http.StatusUnauthorized, `{"error":"access_denied"}`))
+			})
+
+			It("fails to login and does not save the certificate", func() {
+				session := runCommand("login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key.pem")
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say(`The server did not accept the client certificate for authentication \(HTTP 401\)`))
+				Expect(config.ReadConfig().ClientCertPath).To(BeEmpty())
+			})
+		})
+
+		Context("when the server rejects the certificate during the TLS handshake", func() {
+			var rejectingServer *Server
+
+			BeforeEach(func() {
+				rejectingServer = NewUnstartedServer()
+				tlsCert, err := tls.LoadX509KeyPair("../test/server-tls-cert.pem", "../test/server-tls-key.pem")
+				Expect(err).NotTo(HaveOccurred())
+				rejectingServer.HTTPTestServer.TLS = &tls.Config{
+					Certificates: []tls.Certificate{tlsCert},
+					ClientAuth:   tls.RequireAndVerifyClientCert,
+					ClientCAs:    x509.NewCertPool(),
+				}
+				rejectingServer.HTTPTestServer.StartTLS()
+
+				cfg := config.ReadConfig()
+				cfg.ApiURL = rejectingServer.URL()
+				Expect(config.WriteConfig(cfg)).To(Succeed())
+			})
+
+			AfterEach(func() {
+				rejectingServer.Close()
+			})
+
+			It("reports the rejection", func() {
+				session := runCommand("login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key.pem")
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("The server rejected the client certificate during the TLS handshake"))
+			})
+		})
+
+		Context("with an encrypted private key", func() {
+			It("decrypts the key with the passphrase from the environment", func() {
+				session := runCommandWithEnv([]string{"CREDHUB_CLIENT_KEY_PASSPHRASE=passphrase"}, "login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key-encrypted.pem")
+
+				Eventually(session).Should(Exit(0))
+				Eventually(session.Out).Should(Say("Login Successful"))
+			})
+
+			It("decrypts encrypted PKCS #8 keys", func() {
+				session := runCommandWithEnv([]string{"CREDHUB_CLIENT_KEY_PASSPHRASE=passphrase"}, "login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key-pkcs8-encrypted.pem")
+
+				Eventually(session).Should(Exit(0))
+				Eventually(session.Out).Should(Say("Login Successful"))
+			})
+
+			It("fails with an error message without a passphrase", func() {
+				session := runCommand("login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key-encrypted.pem")
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("The client private key is encrypted. Please set CREDHUB_CLIENT_KEY_PASSPHRASE"))
+			})
+
+			It("fails with an error message with the wrong passphrase", func() {
+				session := runCommandWithEnv([]string{"CREDHUB_CLIENT_KEY_PASSPHRASE=wrong"}, "login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key-encrypted.pem")
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("the client private key could not be decrypted with the provided passphrase"))
+			})
+		})
+
+		Context("with a certificate and no key", func() {
+			It("fails with an error message", func() {
+				session := runCommand("login", "--client-cert", "../test/auth-tls-cert.pem")
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("Both a client certificate and a client key must be provided."))
+			})
+		})
+
+		Context("with a certificate and a username", func() {
+			It("fails with an error message", func() {
+				session := runCommand("login", "--client-cert", "../test/auth-tls-cert.pem", "--client-key", "../test/auth-tls-key.pem", "-u", "test-username")
+
+				Eventually(session).Should(Exit(1))
+				Eventually(session.Err).Should(Say("Client, password, SSO and/or SSO passcode credentials may not be combined."))
+			})
+		})
+	})
+
+	Describe("credential helper flow", func() {
+		var helper string
+
+		BeforeEach(func() {
+			if runtime.GOOS == "windows" {
+				Skip("the credential helper is a shell script")
+			}
+
+			helper = filepath.Join(homeDir, "credhub-helper")
+			Expect(ioutil.WriteFile(helper, []byte("#!/bin/sh\necho '{\"access_token\": \"helper-token-'$1'\", \"expires_in\": 3600}'\n"), 0755)).To(Succeed())
+		})
+
+		It("verifies the helper returns a token and saves it", func() {
+			login()
+
+			session := runCommand("login", "--credential-helper", helper, "--credential-helper-arg", "dev")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("Login Successful"))
+			cfg := config.ReadConfig()
+			Expect(cfg.CredentialHelper).To(Equal(helper))
+			Expect(cfg.CredentialHelperArgs).To(Equal([]string{"dev"}))
+			Expect(cfg.AccessToken).To(Equal("helper-token-dev"))
+			Expect(cfg.RefreshToken).To(BeEmpty())
+		})
+
+		It("authenticates subsequent commands with tokens of the helper", func() {
+			session := runCommand("login", "--credential-helper", helper, "--credential-helper-arg", "dev")
+			Eventually(session).Should(Exit(0))
+
+			server.RouteToHandler("GET", "/api/v1/data",
+				CombineHandlers(
+					VerifyHeaderKV("Authorization", "Bearer helper-token-dev"),
+					RespondWith(http.StatusOK, fmt.Sprintf(arrayResponseJSON, "value", "/my-value", `"some-value"`, "{}")),
+				),
+			)
+
+			session = runCommand("get", "-n", "/my-value")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("value: some-value"))
+		})
+
+		It("uses the helper from the environment without logging in", func() {
+			server.RouteToHandler("GET", "/api/v1/data",
+				CombineHandlers(
+					VerifyHeaderKV("Authorization", "Bearer helper-token-prod"),
+					RespondWith(http.StatusOK, fmt.Sprintf(arrayResponseJSON, "value", "/my-value", `"some-value"`, "{}")),
+				),
+			)
+
+			session := runCommand("login", "--credential-helper", helper, "--credential-helper-arg", "dev")
+			Eventually(session).Should(Exit(0))
+
+			prodHelper := filepath.Join(homeDir, "credhub-prod-helper")
+			Expect(ioutil.WriteFile(prodHelper, []byte("#!/bin/sh\necho '{\"access_token\": \"helper-token-prod\"}'\n"), 0755)).To(Succeed())
+
+			session = runCommandWithEnv([]string{"CREDHUB_CREDENTIAL_HELPER=" + prodHelper}, "get", "-n", "/my-value")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("value: some-value"))
+			Expect(config.ReadConfig().AccessToken).To(Equal("helper-token-dev"))
+		})
+
+		It("reuses the token of the helper in later commands until the server rejects it", func() {
+			countingHelper := filepath.Join(homeDir, "credhub-counting-helper")
+			Expect(ioutil.WriteFile(countingHelper, []byte("#!/bin/sh\necho run >> \"$1\"\necho '{\"access_token\": \"helper-token-'$(wc -l < \"$1\" | tr -d ' ')'\"}'\n"), 0755)).To(Succeed())
+			runs := filepath.Join(homeDir, "helper-runs")
+
+			session := runCommand("login", "--credential-helper", countingHelper, "--credential-helper-arg", runs)
+			Eventually(session).Should(Exit(0))
+
+			server.RouteToHandler("GET", "/api/v1/data",
+				CombineHandlers(
+					VerifyHeaderKV("Authorization", "Bearer helper-token-1"),
+					RespondWith(http.StatusOK, fmt.Sprintf(arrayResponseJSON, "value", "/my-value", `"some-value"`, "{}")),
+				),
+			)
+			for i := 0; i < 2; i++ {
+				session = runCommand("get", "-n", "/my-value")
+				Eventually(session).Should(Exit(0))
+			}
+
+			server.RouteToHandler("GET", "/api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer helper-token-2" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				fmt.Fprintf(w, arrayResponseJSON, "value", "/my-value", `"some-value"`, "{}")
+			})
+			session = runCommand("get", "-n", "/my-value")
+			Eventually(session).Should(Exit(0))
+
+			Expect(config.ReadConfig().AccessToken).To(Equal("helper-token-2"))
+		})
+
+		It("runs a helper with a path containing spaces", func() {
+			dir := filepath.Join(homeDir, "credential helpers")
+			Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+			spacedHelper := filepath.Join(dir, "credhub-helper")
+			Expect(os.Rename(helper, spacedHelper)).To(Succeed())
+
+			session := runCommand("login", "--credential-helper", spacedHelper, "--credential-helper-arg", "dev")
+
+			Eventually(session).Should(Exit(0))
+			Expect(config.ReadConfig().CredentialHelper).To(Equal(spacedHelper))
+		})
+
+		It("prints the token of the helper with --token", func() {
+			session := runCommand("login", "--credential-helper", helper, "--credential-helper-arg", "dev")
+			Eventually(session).Should(Exit(0))
+
+			session = runCommand("--token")
+
+			Eventually(session).Should(Exit(0))
+			Eventually(session.Out).Should(Say("Bearer helper-token-dev"))
+		})
+
+		It("removes the helper on logout", func() {
+			session := runCommand("login", "--credential-helper", helper, "--credential-helper-arg", "dev")
+			Eventually(session).Should(Exit(0))
+
+			session = runCommand("logout")
+
+			Eventually(session).Should(Exit(0))
+			Expect(config.ReadConfig().CredentialHelper).To(BeEmpty())
+		})
+
+		It("fails to login and does not save a helper which returns no token", func() {
+			session := runCommand("login", "--credential-helper", "false")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Err).Should(Say("The credential helper did not return an access token: the credential helper false failed: exit status 1."))
+			Expect(config.ReadConfig().CredentialHelper).To(BeEmpty())
+		})
+
+		It("fails with an error message when combined with a username", func() {
+			session := runCommand("login", "--credential-helper", helper, "-u", "test-username")
+
+			Eventually(session).Should(Exit(1))
+			Eventually(session.Err).Should(Say("Client, password, SSO and/or SSO passcode credentials may not be combined."))
+		})
+	})
+
 	Describe("sso flow", func() {
 		BeforeEach(func() {
 			uaaServer.RouteToHandler("POST", "/oauth/token",
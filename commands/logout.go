@@ -17,6 +17,9 @@ func (c *LogoutCommand) Execute([]string) error {
 		return err
 	}
 	MarkTokensAsRevokedInConfig(&c.config)
+	c.config.ClientCertPath = ""
+	c.config.ClientKeyPath = ""
+	c.config.CredentialHelper = ""
 	if err := config.WriteConfig(c.config); err != nil {
 		return err
 	}
@@ -25,7 +28,7 @@ func (c *LogoutCommand) Execute([]string) error {
 }
 
 func RevokeTokenIfNecessary(cfg config.Config) error {
-	credhubClient, err := credhub.New(cfg.ApiURL, credhub.CaCerts(cfg.CaCerts...), credhub.SkipTLSValidation(cfg.InsecureSkipVerify), credhub.SetHttpTimeout(cfg.HttpTimeout))
+	credhubClient, err := credhub.New(cfg.ApiURL, credhub.CaCerts(cfg.CaCerts...), credhub.SkipTLSValidation(cfg.InsecureSkipVerify), credhub.SetHttpTimeout(cfg.HttpTimeout), ProxyOption(cfg))
 	if err != nil {
 		return err
 	}
@@ -35,7 +38,8 @@ func RevokeTokenIfNecessary(cfg config.Config) error {
 		Client:  credhubClient.Client(),
 	}
 
-	if cfg.AccessToken != "" && cfg.AccessToken != "revoked" {
+	// tokens of a credential helper are not issued by the UAA
+	if cfg.AccessToken != "" && cfg.AccessToken != "revoked" && !cfg.UsesCredentialHelper() {
 		return uaaClient.RevokeToken(cfg.AccessToken)
 	}
 
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"strings"
+
+	"code.cloudfoundry.org/credhub-cli/errors"
+	"code.cloudfoundry.org/credhub-cli/models"
+)
+
+// RewriteFlags rename credentials, the CA names referenced by certificates and permission paths
+// while exporting or importing. Prefix rewrites are applied before regex rewrites and only the
+// first matching rewrite renames a credential.
+type RewriteFlags struct {
+	RewritePrefix []string `long:"rewrite-prefix" description:"Replace the name prefix FROM with TO, given as FROM=TO (may be specified multiple times)"`
+	RewriteRegex  []string `long:"rewrite-regex" description:"Replace the matches of the regular expression PATTERN with REPLACEMENT, given as PATTERN=REPLACEMENT. REPLACEMENT may refer to submatches as $1 (may be specified multiple times)"`
+}
+
+// NameRewrites returns the rewrites given by the flags
+func (f RewriteFlags) NameRewrites() (models.NameRewrites, error) {
+	var rewrites models.NameRewrites
+
+	for _, rewrite := range f.RewritePrefix {
+		parts := strings.SplitN(rewrite, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.NewInvalidRewriteError("rewrite-prefix", rewrite)
+		}
+		rewrites = append(rewrites, models.NewPrefixRewrite(parts[0], parts[1]))
+	}
+
+	for _, rewrite := range f.RewriteRegex {
+		parts := strings.SplitN(rewrite, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.NewInvalidRewriteError("rewrite-regex", rewrite)
+		}
+		nameRewrite, err := models.NewRegexRewrite(parts[0], parts[1])
+		if err != nil {
+			return nil, errors.NewInvalidRewritePatternError(parts[0], err)
+		}
+		rewrites = append(rewrites, nameRewrite)
+	}
+
+	return rewrites, nil
+}
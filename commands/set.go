@@ -10,6 +10,7 @@ import (
 	"code.cloudfoundry.org/credhub-cli/credhub"
 
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
 	"code.cloudfoundry.org/credhub-cli/errors"
 	"code.cloudfoundry.org/credhub-cli/util"
@@ -25,6 +26,7 @@ type SetCommand struct {
 	Certificate          string `short:"c" long:"certificate" description:"[Certificate] Sets the certificate from file or value"`
 	Private              string `short:"p" long:"private" description:"[Certificate, SSH, RSA] Sets the private key from file or value"`
 	Public               string `short:"u" long:"public" description:"[SSH, RSA] Sets the public key from file or value"`
+	KeyAlgorithm         string `long:"key-algorithm" choice:"rsa" choice:"ecdsa-p256" choice:"ecdsa-p384" choice:"ed25519" description:"[Certificate, SSH, RSA] Requires the provided keys to use this algorithm"`
 	Username             string `short:"z" long:"username" description:"[User] Sets the username value of the credential"`
 	Password             string `short:"w" long:"password" description:"[Password, User] Sets the password value of the credential"`
 	OutputJSON           bool   `short:"j" long:"output-json" description:"Return response in JSON format"`
@@ -46,6 +48,10 @@ func (c *SetCommand) Execute([]string) error {
 		return err
 	}
 
+	if err := c.validateKeys(); err != nil {
+		return err
+	}
+
 	credential, err := c.setCredential()
 	if err != nil {
 		return err
@@ -151,6 +157,43 @@ func (c *SetCommand) setCredential() (credentials.Credential, error) {
 	return cred, err
 }
 
+// validateKeys checks that provided keys parse, belong together and use the requested key
+// algorithm. Values that are not PEM encoded or in authorized_keys format are only checked when
+// a key algorithm is requested.
+func (c *SetCommand) validateKeys() error {
+	if c.KeyAlgorithm != "" && !isKeyType(c.Type) {
+		return errors.NewKeyAlgorithmOnlyValidForKeyTypesError()
+	}
+
+	if c.KeyAlgorithm == "" && !looksLikeKey(c.Private) && !looksLikeKey(c.Public) && !looksLikeKey(c.Certificate) {
+		return nil
+	}
+
+	var err error
+	switch c.Type {
+	case "certificate":
+		err = generate.ValidateCertificate(values.Certificate{Certificate: c.Certificate, PrivateKey: c.Private}, c.KeyAlgorithm)
+	case "ssh":
+		err = generate.ValidateSSH(values.SSH{PublicKey: c.Public, PrivateKey: c.Private}, c.KeyAlgorithm)
+	case "rsa":
+		err = generate.ValidateRSA(values.RSA{PublicKey: c.Public, PrivateKey: c.Private}, c.KeyAlgorithm)
+	}
+
+	if err != nil {
+		return errors.NewInvalidKeyError(err)
+	}
+
+	return nil
+}
+
+func looksLikeKey(value string) bool {
+	return strings.Contains(value, "-----BEGIN ") || strings.HasPrefix(value, "ssh-") || strings.HasPrefix(value, "ecdsa-sha2-")
+}
+
+func isKeyType(credentialType string) bool {
+	return credentialType == "certificate" || credentialType == "ssh" || credentialType == "rsa"
+}
+
 func promptForInput(prompt string, value *string) {
 	fmt.Printf(prompt)
 	reader := bufio.NewReader(os.Stdin)
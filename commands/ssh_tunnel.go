@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/errors"
+	"github.com/howeyc/gopass"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// sshTunnelDialer opens the tunnels of ssh+socks5 proxies, sharing them with later commands
+// through a control master running this CLI in the background
+var sshTunnelDialer = &credhub.SSHTunnelDialer{
+	PassphrasePrompt: proxyKeyPassphrase,
+	ControlMaster:    sshControlMaster(),
+}
+
+type SSHTunnelCommand struct {
+	Args struct {
+		ProxyURL string `positional-arg-name:"PROXY_URL" required:"yes"`
+	} `positional-args:"yes"`
+}
+
+func (c *SSHTunnelCommand) Execute([]string) error {
+	return credhub.ServeSSHTunnel(c.Args.ProxyURL, os.Stdin, os.Stdout)
+}
+
+func sshControlMaster() []string {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	return []string{executable, "ssh-tunnel"}
+}
+
+func proxyKeyPassphrase(keyPath string) (string, error) {
+	if passphrase, ok := os.LookupEnv("CREDHUB_PROXY_KEY_PASSPHRASE"); ok {
+		return passphrase, nil
+	}
+
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.NewProxyKeyPassphraseRequiredError()
+	}
+
+	fmt.Fprintf(os.Stderr, "passphrase for %s: ", keyPath)
+	passphrase, err := gopass.GetPasswdMasked()
+	if err != nil {
+		return "", err
+	}
+	return string(passphrase), nil
+}
@@ -0,0 +1,112 @@
+// +build !windows
+
+package commands_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/credhub-cli/test"
+	"golang.org/x/crypto/ssh"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+	. "github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("SSH tunnel support", func() {
+	var (
+		dir        string
+		key        *rsa.PrivateKey
+		jumpbox    *test.SSHServer
+		proxyQuery string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "ssh-tunnel")
+		Expect(err).NotTo(HaveOccurred())
+
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+		jumpbox = test.StartSSHServer("jumpbox", publicKey)
+
+		knownHosts := filepath.Join(dir, "known_hosts")
+		Expect(ioutil.WriteFile(knownHosts, []byte(jumpbox.KnownHostsLine()+"\n"), 0600)).To(Succeed())
+		proxyQuery = "?known-hosts=" + knownHosts + "&control-path=" + filepath.Join(dir, "control.sock") + "&control-persist=1s"
+
+		server.RouteToHandler("GET", "/info", RespondWith(http.StatusOK, `{"auth-server":{"url":"`+authServer.URL()+`"}}`))
+		authServer.RouteToHandler("GET", "/info", RespondWith(http.StatusOK, ""))
+	})
+
+	AfterEach(func() {
+		jumpbox.Close()
+		os.RemoveAll(dir)
+	})
+
+	proxyWithKey := func(block *pem.Block) string {
+		keyPath := filepath.Join(dir, "id_rsa")
+		Expect(ioutil.WriteFile(keyPath, pem.EncodeToMemory(block), 0600)).To(Succeed())
+		return "CREDHUB_PROXY=ssh+socks5://jumpbox@" + jumpbox.Addr() + proxyQuery + "&private-key=" + keyPath
+	}
+
+	api := func(env ...string) *Session {
+		return runCommandWithEnv(env, "api", server.URL(), "--ca-cert", "../test/server-tls-ca.pem", "--ca-cert", "../test/auth-tls-ca.pem")
+	}
+
+	It("shares one tunnel across commands", func() {
+		proxy := proxyWithKey(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+		Eventually(api(proxy)).Should(Exit(0))
+		Eventually(api(proxy)).Should(Exit(0))
+
+		Expect(jumpbox.Connections()).To(Equal(1))
+		conn, err := net.Dial("unix", filepath.Join(dir, "control.sock"))
+		Expect(err).NotTo(HaveOccurred())
+		conn.Close()
+	})
+
+	Context("with an encrypted private key", func() {
+		var proxy string
+
+		BeforeEach(func() {
+			block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte("secret"), x509.PEMCipherAES256)
+			Expect(err).NotTo(HaveOccurred())
+			proxy = proxyWithKey(block)
+		})
+
+		It("decrypts the key with the passphrase in the environment", func() {
+			session := api(proxy, "CREDHUB_PROXY_KEY_PASSPHRASE=secret")
+
+			Eventually(session).Should(Exit(0))
+		})
+
+		It("requires a passphrase", func() {
+			session := api(proxy)
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The private key of the SSH proxy is encrypted. Please set CREDHUB_PROXY_KEY_PASSPHRASE"))
+		})
+	})
+
+	It("returns an error for hosts missing from known_hosts", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "known_hosts"), nil, 0600)).To(Succeed())
+		proxy := proxyWithKey(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+		session := api(proxy)
+
+		Eventually(session).Should(Exit(1))
+		Expect(session.Err).To(Say("the host key of " + jumpbox.Addr() + " is not in known_hosts"))
+	})
+})
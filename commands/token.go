@@ -6,13 +6,21 @@ import (
 
 	"code.cloudfoundry.org/credhub-cli/config"
 	"code.cloudfoundry.org/credhub-cli/credhub/auth"
+	"code.cloudfoundry.org/credhub-cli/errors"
 )
 
 func init() {
 	CredHub.Token = func() {
 		cfg := config.ReadConfig()
 
-		if cfg.AccessToken != "" && cfg.AccessToken != "revoked" {
+		if cfg.UsesCredentialHelper() && !clientCredentialsInEnvironment() {
+			accessToken, err := credentialHelperToken(cfg)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, errors.NewCredentialHelperError(err))
+				os.Exit(1)
+			}
+			fmt.Println("Bearer " + accessToken)
+		} else if cfg.AccessToken != "" && cfg.AccessToken != "revoked" {
 			cfg = refreshConfiguration(cfg)
 			config.WriteConfig(cfg)
 			fmt.Println("Bearer " + cfg.AccessToken)
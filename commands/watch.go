@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/credhub"
+)
+
+type WatchCommand struct {
+	Path       string        `short:"p" long:"path" required:"yes" description:"Path of the credentials to watch"`
+	Interval   time.Duration `short:"i" long:"interval" default:"30s" description:"Time between polls for changes (e.g. 10s, 5m)"`
+	Exec       string        `short:"e" long:"exec" description:"Command to run for each change. The change is provided in the CREDHUB_EVENT_TYPE, CREDHUB_CREDENTIAL_NAME and CREDHUB_VERSION_CREATED_AT environment variables"`
+	OutputJSON bool          `short:"j" long:"output-json" description:"Return each change as a line of JSON"`
+	ClientCommand
+}
+
+func (c *WatchCommand) Execute([]string) error {
+	var options []credhub.WatchOption
+	if c.Exec != "" {
+		options = append(options, credhub.WithWatchHook(c.runExecHook))
+	}
+
+	watcher, err := c.client.Watch(c.Path, c.Interval, options...)
+	if err != nil {
+		return err
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	for {
+		select {
+		case event := <-watcher.Events:
+			c.printEvent(event)
+		case err := <-watcher.Errors:
+			fmt.Fprintln(os.Stderr, err.Error())
+		case <-interrupt:
+			watcher.Stop()
+			return nil
+		}
+	}
+}
+
+func (c *WatchCommand) printEvent(event credhub.WatchEvent) {
+	if c.OutputJSON {
+		s, _ := json.Marshal(event)
+		fmt.Println(string(s))
+		return
+	}
+
+	fmt.Printf("%s %s (%s)\n", event.Type, event.Name, event.VersionCreatedAt)
+}
+
+func (c *WatchCommand) runExecHook(event credhub.WatchEvent) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", c.Exec)
+	} else {
+		cmd = exec.Command("sh", "-c", c.Exec)
+	}
+
+	cmd.Env = append(os.Environ(),
+		"CREDHUB_EVENT_TYPE="+string(event.Type),
+		"CREDHUB_CREDENTIAL_NAME="+event.Name,
+		"CREDHUB_VERSION_CREATED_AT="+event.VersionCreatedAt,
+	)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("The command for the change to '%s' failed: %v", event.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package commands_test
+
+import (
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+
+	"code.cloudfoundry.org/credhub-cli/commands"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+	. "github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Watch", func() {
+	var (
+		mu           sync.Mutex
+		findResponse string
+	)
+
+	setFindResponse := func(body string) {
+		mu.Lock()
+		defer mu.Unlock()
+		findResponse = body
+	}
+
+	startWatching := func(args ...string) *Session {
+		cmd := exec.Command(commandPath, append([]string{"watch"}, args...)...)
+		session, err := Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() int {
+			polls := 0
+			for _, request := range server.ReceivedRequests() {
+				if request.URL.Path == "/api/v1/data" {
+					polls++
+				}
+			}
+			return polls
+		}).Should(BeNumerically(">", 1))
+
+		return session
+	}
+
+	BeforeEach(func() {
+		login()
+
+		setFindResponse(`{"credentials":[{"name":"/app/password","version_created_at":"2019-01-01T00:00:00Z"}]}`)
+		server.RouteToHandler("GET", "/api/v1/data", CombineHandlers(
+			VerifyRequest("GET", "/api/v1/data", "path=/app"),
+			func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				w.Write([]byte(findResponse))
+			},
+		))
+	})
+
+	ItRequiresAuthentication("watch", "-p", "/app")
+	ItRequiresAnAPIToBeSet("watch", "-p", "/app")
+
+	Describe("Help", func() {
+		ItBehavesLikeHelp("watch", "w", func(session *Session) {
+			Expect(session.Err).To(Say("Usage"))
+			if runtime.GOOS == "windows" {
+				Expect(session.Err).To(Say("credhub-cli.exe \\[OPTIONS\\] watch \\[watch-OPTIONS\\]"))
+			} else {
+				Expect(session.Err).To(Say("credhub-cli \\[OPTIONS\\] watch \\[watch-OPTIONS\\]"))
+			}
+		})
+
+		It("short flags", func() {
+			Expect(commands.WatchCommand{}).To(SatisfyAll(
+				commands.HaveFlag("path", "p"),
+				commands.HaveFlag("interval", "i"),
+				commands.HaveFlag("exec", "e"),
+				commands.HaveFlag("output-json", "j"),
+			))
+		})
+	})
+
+	It("prints each change to the watched credentials", func() {
+		session := startWatching("-p", "/app", "-i", "50ms")
+		defer session.Kill()
+
+		setFindResponse(`{"credentials":[{"name":"/app/password","version_created_at":"2019-02-01T00:00:00Z"}]}`)
+		Eventually(session.Out).Should(Say(`updated /app/password \(2019-02-01T00:00:00Z\)`))
+
+		setFindResponse(`{"credentials":[]}`)
+		Eventually(session.Out).Should(Say(`deleted /app/password \(2019-02-01T00:00:00Z\)`))
+	})
+
+	It("prints each change as a line of JSON", func() {
+		session := startWatching("-p", "/app", "-i", "50ms", "-j")
+		defer session.Kill()
+
+		setFindResponse(`{"credentials":[
+			{"name":"/app/password","version_created_at":"2019-01-01T00:00:00Z"},
+			{"name":"/app/cert","version_created_at":"2019-02-01T00:00:00Z"}
+		]}`)
+		Eventually(session.Out).Should(Say(`{"type":"created","name":"/app/cert","version_created_at":"2019-02-01T00:00:00Z"}\n`))
+	})
+
+	if runtime.GOOS != "windows" {
+		It("runs the exec hook with the change in its environment", func() {
+			session := startWatching("-p", "/app", "-i", "50ms", "-e", "echo hook: $CREDHUB_EVENT_TYPE $CREDHUB_CREDENTIAL_NAME")
+			defer session.Kill()
+
+			setFindResponse(`{"credentials":[{"name":"/app/password","version_created_at":"2019-02-01T00:00:00Z"}]}`)
+			Eventually(session.Err).Should(Say("hook: updated /app/password"))
+		})
+
+		It("exits successfully when interrupted", func() {
+			session := startWatching("-p", "/app", "-i", "50ms")
+
+			session.Signal(os.Interrupt)
+			Eventually(session).Should(Exit(0))
+		})
+	}
+
+	It("fails when the path cannot be read", func() {
+		server.RouteToHandler("GET", "/api/v1/data", RespondWith(http.StatusForbidden, `{"error":"The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`))
+
+		session := runCommand("watch", "-p", "/app")
+
+		Eventually(session).Should(Exit(1))
+		Expect(session.Err).To(Say("you do not have sufficient authorization"))
+	})
+})
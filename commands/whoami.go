@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/config"
+	"code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/auth/oidc"
+	"code.cloudfoundry.org/credhub-cli/errors"
+)
+
+type WhoamiCommand struct {
+	Verify     bool `long:"verify" description:"Verify the token signature with the keys of the auth server"`
+	OutputJSON bool `short:"j" long:"output-json" description:"Return response in JSON format"`
+	ConfigCommand
+}
+
+type whoami struct {
+	ApiURL            string   `json:"api_url" yaml:"api_url"`
+	AuthURL           string   `json:"auth_url,omitempty" yaml:"auth_url,omitempty"`
+	AuthMethod        string   `json:"auth_method" yaml:"auth_method"`
+	UserName          string   `json:"user_name,omitempty" yaml:"user_name,omitempty"`
+	UserID            string   `json:"user_id,omitempty" yaml:"user_id,omitempty"`
+	ClientID          string   `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	GrantType         string   `json:"grant_type,omitempty" yaml:"grant_type,omitempty"`
+	Scopes            []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	Issuer            string   `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	ClientCertificate string   `json:"client_certificate,omitempty" yaml:"client_certificate,omitempty"`
+	Subject           string   `json:"subject,omitempty" yaml:"subject,omitempty"`
+	IssuedAt          string   `json:"issued_at,omitempty" yaml:"issued_at,omitempty"`
+	ExpiresAt         string   `json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+	ExpiresIn         string   `json:"expires_in,omitempty" yaml:"expires_in,omitempty"`
+	Verified          bool     `json:"verified" yaml:"verified"`
+}
+
+func (c *WhoamiCommand) Execute([]string) error {
+	cfg := c.config
+	if cfg.ApiURL == "" {
+		return errors.NewNoApiUrlSetError()
+	}
+
+	if cfg.UsesClientCertificate() && !cfg.UsesCredentialHelper() && !clientCredentialsInEnvironment() {
+		if c.Verify {
+			return errors.NewWhoamiVerifyClientCertificateError()
+		}
+		identity, err := clientCertificateIdentity(cfg)
+		if err != nil {
+			return err
+		}
+		formatOutput(c.OutputJSON, identity)
+		return nil
+	}
+
+	authMethod := "token"
+	if clientCredentialsInEnvironment() {
+		cfg = refreshConfiguration(cfg)
+	} else if cfg.UsesCredentialHelper() {
+		accessToken, err := credentialHelperToken(cfg)
+		if err != nil {
+			return errors.NewCredentialHelperError(err)
+		}
+		cfg.AccessToken = accessToken
+		authMethod = "credential helper"
+	}
+	if cfg.AccessToken == "" || cfg.AccessToken == "revoked" {
+		return errors.NewRevokedTokenError()
+	}
+
+	identity, err := c.tokenIdentity(cfg, authMethod)
+	if err != nil {
+		return err
+	}
+	formatOutput(c.OutputJSON, identity)
+	return nil
+}
+
+func (c *WhoamiCommand) tokenIdentity(cfg config.Config, authMethod string) (*whoami, error) {
+	var claims *oidc.Claims
+	var err error
+
+	if c.Verify {
+		claims, err = verifyAccessToken(cfg)
+		if err == oidc.ErrTokenExpired {
+			err = nil
+		}
+	} else {
+		claims, err = oidc.DecodeToken(cfg.AccessToken)
+	}
+	if err != nil {
+		return nil, errors.NewInvalidAccessTokenError(err)
+	}
+
+	identity := &whoami{
+		ApiURL:     cfg.ApiURL,
+		AuthURL:    cfg.AuthURL,
+		AuthMethod: authMethod,
+		UserName:   stringClaim(claims, "user_name"),
+		UserID:     stringClaim(claims, "user_id"),
+		ClientID:   claims.ClientID,
+		GrantType:  stringClaim(claims, "grant_type"),
+		Scopes:     claims.Scopes,
+		Issuer:     claims.Issuer,
+		Subject:    claims.Subject,
+		Verified:   c.Verify,
+	}
+	if !claims.IssuedAt.IsZero() {
+		identity.IssuedAt = claims.IssuedAt.UTC().Format(time.RFC3339)
+	}
+	if !claims.ExpiresAt.IsZero() {
+		identity.ExpiresAt = claims.ExpiresAt.UTC().Format(time.RFC3339)
+		identity.ExpiresIn = remainingLifetime(claims.ExpiresAt)
+	}
+
+	return identity, nil
+}
+
+// verifyAccessToken checks the token with the keys of the auth server. OpenID Connect providers
+// publish their keys in their discovery document, UAA publishes them at /token_keys.
+func verifyAccessToken(cfg config.Config) (*oidc.Claims, error) {
+	credhubClient, err := credhub.New(cfg.ApiURL, credhub.CaCerts(cfg.CaCerts...), credhub.SkipTLSValidation(cfg.InsecureSkipVerify), credhub.SetHttpTimeout(cfg.HttpTimeout), ProxyOption(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := oidc.Discover(credhubClient.Client(), cfg.AuthURL)
+	if err != nil {
+		authURL := strings.TrimSuffix(cfg.AuthURL, "/")
+		provider = &oidc.ProviderMetadata{
+			Issuer:  authURL + "/oauth/token",
+			JWKSURI: authURL + "/token_keys",
+		}
+	}
+
+	oidcClient := &oidc.Client{Provider: provider, Client: credhubClient.Client()}
+	return oidcClient.VerifyToken(cfg.AccessToken)
+}
+
+func clientCertificateIdentity(cfg config.Config) (*whoami, error) {
+	data, err := ioutil.ReadFile(cfg.ClientCertPath)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.NewInvalidClientCertificateError(cfg.ClientCertPath)
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.NewInvalidClientCertificateError(cfg.ClientCertPath)
+	}
+
+	return &whoami{
+		ApiURL:            cfg.ApiURL,
+		AuthMethod:        "client certificate",
+		ClientCertificate: cfg.ClientCertPath,
+		Subject:           certificate.Subject.String(),
+		Issuer:            certificate.Issuer.String(),
+		ExpiresAt:         certificate.NotAfter.UTC().Format(time.RFC3339),
+		ExpiresIn:         remainingLifetime(certificate.NotAfter),
+	}, nil
+}
+
+func stringClaim(claims *oidc.Claims, name string) string {
+	value, _ := claims.Raw[name].(string)
+	return value
+}
+
+func remainingLifetime(expiresAt time.Time) string {
+	remaining := time.Until(expiresAt).Round(time.Second)
+	if remaining <= 0 {
+		return "expired"
+	}
+	return remaining.String()
+}
@@ -0,0 +1,181 @@
+package commands_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+	. "github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Whoami", func() {
+	setAccessToken := func(accessToken string) {
+		cfg := config.ReadConfig()
+		cfg.AccessToken = accessToken
+		cfg.RefreshToken = "some-refresh-token"
+		config.WriteConfig(cfg)
+	}
+
+	ItRequiresAuthentication("whoami")
+
+	It("shows the claims of the access token", func() {
+		setAccessToken(validAccessToken)
+
+		session := runCommand("whoami")
+
+		Eventually(session).Should(Exit(0))
+		Expect(session.Out).To(Say("api_url: " + server.URL()))
+		Expect(session.Out).To(Say("auth_url: " + authServer.URL()))
+		Expect(session.Out).To(Say("auth_method: token"))
+		Expect(session.Out).To(Say("user_name: credhub"))
+		Expect(session.Out).To(Say("user_id: 6787bb7e-78bb-4be6-9583-42a75ddba3d5"))
+		Expect(session.Out).To(Say("client_id: credhub_cli"))
+		Expect(session.Out).To(Say("grant_type: password"))
+		Expect(session.Out).To(Say("scopes:\n- credhub.write\n- credhub.read"))
+		Expect(session.Out).To(Say("issuer: https://34.206.233.195:8443/oauth/token"))
+		Expect(session.Out).To(Say("expires_at: \"2017-09-08T21:59:45Z\""))
+		Expect(session.Out).To(Say("expires_in: expired"))
+		Expect(session.Out).To(Say("verified: false"))
+		Expect(authServer.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("shows the remaining lifetime of the access token", func() {
+		setAccessToken(jwtExpiringIn(time.Hour))
+
+		session := runCommand("whoami")
+
+		Eventually(session).Should(Exit(0))
+		Expect(session.Out).To(Say(`expires_in: (59m5\ds|1h0m0s)`))
+	})
+
+	It("returns JSON with --output-json", func() {
+		setAccessToken(validAccessToken)
+
+		session := runCommand("whoami", "--output-json")
+
+		Eventually(session).Should(Exit(0))
+		var identity map[string]interface{}
+		Expect(json.Unmarshal(session.Out.Contents(), &identity)).To(Succeed())
+		Expect(identity["user_name"]).To(Equal("credhub"))
+		Expect(identity["scopes"]).To(Equal([]interface{}{"credhub.write", "credhub.read"}))
+		Expect(identity["verified"]).To(Equal(false))
+	})
+
+	It("returns an error when the access token is not a JWT", func() {
+		setAccessToken("2YotnFZFEjr1zCsicMWpAA")
+
+		session := runCommand("whoami")
+
+		Eventually(session).Should(Exit(1))
+		Expect(session.Err).To(Say("The access token could not be read: the token is not a JWT."))
+	})
+
+	Context("with --verify", func() {
+		var key *rsa.PrivateKey
+
+		sign := func(claims map[string]interface{}) string {
+			header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "key-1", "typ": "JWT"})
+			payload, _ := json.Marshal(claims)
+			signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+			digest := sha256.Sum256([]byte(signed))
+			signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+			Expect(err).NotTo(HaveOccurred())
+			return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+		}
+
+		BeforeEach(func() {
+			var err error
+			key, err = rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+
+			authServer.RouteToHandler("GET", "/.well-known/openid-configuration", RespondWith(http.StatusNotFound, ""))
+			authServer.RouteToHandler("GET", "/token_keys", RespondWith(http.StatusOK, `{"keys": [{
+				"kty": "RSA",
+				"kid": "key-1",
+				"alg": "RS256",
+				"use": "sig",
+				"n": "`+base64.RawURLEncoding.EncodeToString(key.N.Bytes())+`",
+				"e": "`+base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())+`"
+			}]}`))
+		})
+
+		It("verifies the token with the keys of the auth server", func() {
+			setAccessToken(sign(map[string]interface{}{
+				"iss":        authServer.URL() + "/oauth/token",
+				"user_name":  "some-user",
+				"grant_type": "password",
+				"exp":        time.Now().Add(time.Hour).Unix(),
+			}))
+
+			session := runCommand("whoami", "--verify")
+
+			Eventually(session).Should(Exit(0))
+			Expect(session.Out).To(Say("user_name: some-user"))
+			Expect(session.Out).To(Say("verified: true"))
+		})
+
+		It("returns an error when the signature is invalid", func() {
+			segments := strings.Split(sign(map[string]interface{}{"iss": authServer.URL() + "/oauth/token"}), ".")
+			segments[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"iss": "` + authServer.URL() + `/oauth/token", "user_name": "admin"}`))
+			setAccessToken(strings.Join(segments, "."))
+
+			session := runCommand("whoami", "--verify")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The access token could not be read: the token signature is invalid."))
+		})
+
+		It("returns an error when the token was issued by another server", func() {
+			setAccessToken(sign(map[string]interface{}{"iss": "https://other.example.com/oauth/token"}))
+
+			session := runCommand("whoami", "--verify")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("the token was issued by https://other.example.com/oauth/token instead of " + authServer.URL() + "/oauth/token"))
+		})
+	})
+
+	Context("when logged in with a client certificate", func() {
+		BeforeEach(func() {
+			certPath, err := filepath.Abs("../test/auth-tls-cert.pem")
+			Expect(err).NotTo(HaveOccurred())
+			keyPath, err := filepath.Abs("../test/auth-tls-key.pem")
+			Expect(err).NotTo(HaveOccurred())
+
+			cfg := config.ReadConfig()
+			cfg.ClientCertPath = certPath
+			cfg.ClientKeyPath = keyPath
+			config.WriteConfig(cfg)
+		})
+
+		It("shows the subject of the certificate", func() {
+			session := runCommand("whoami")
+
+			Eventually(session).Should(Exit(0))
+			Expect(session.Out).To(Say("auth_method: client certificate"))
+			Expect(session.Out).To(Say("client_certificate: .*auth-tls-cert.pem"))
+			Expect(session.Out).To(Say("subject: "))
+			Expect(session.Out).To(Say("expires_at: "))
+		})
+
+		It("cannot verify the certificate", func() {
+			session := runCommand("whoami", "--verify")
+
+			Eventually(session).Should(Exit(1))
+			Expect(session.Err).To(Say("The --verify flag can only be used when authenticated with a token."))
+		})
+	})
+})
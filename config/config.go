@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/credhub-cli/util"
@@ -40,11 +41,25 @@ func ReadConfig() Config {
 
 	json.Unmarshal(data, &c)
 
+	// tokens left in the config file have not been moved to the recorded store yet
+	storedIn := c.CredentialStore
+	if c.AccessToken != "" || c.RefreshToken != "" {
+		storedIn = CredentialStorePlaintext
+	}
+	if err := loadTokens(&c, storedIn); err != nil {
+		fmt.Fprintf(os.Stderr, "error reading tokens from the %s credential store: %+v\n", storedIn, err)
+	}
+	migrateTokens(c, storedIn)
+
 	if server, ok := os.LookupEnv("CREDHUB_SERVER"); ok {
 		c.ApiURL = util.AddDefaultSchemeIfNecessary(server)
 		c.AuthURL = ""
 		c.AccessToken = ""
 		c.RefreshToken = ""
+		c.ClientCertPath = ""
+		c.ClientKeyPath = ""
+		c.Proxy = ""
+		c.NoProxy = ""
 	}
 	if client, ok := os.LookupEnv("CREDHUB_CLIENT"); ok {
 		c.ClientID = client
@@ -52,6 +67,16 @@ func ReadConfig() Config {
 	if clientSecret, ok := os.LookupEnv("CREDHUB_SECRET"); ok {
 		c.ClientSecret = clientSecret
 	}
+	if clientCert, ok := os.LookupEnv("CREDHUB_CLIENT_CERT"); ok {
+		c.ClientCertPath = clientCert
+	}
+	if clientKey, ok := os.LookupEnv("CREDHUB_CLIENT_KEY"); ok {
+		c.ClientKeyPath = clientKey
+	}
+	if credentialHelper, ok := os.LookupEnv("CREDHUB_CREDENTIAL_HELPER"); ok {
+		c.CredentialHelper = credentialHelper
+		c.CredentialHelperArgs = nil
+	}
 	if caCert, ok := os.LookupEnv("CREDHUB_CA_CERT"); ok {
 		certs, err := ReadOrGetCaCerts([]string{caCert})
 		if err != nil {
@@ -78,7 +103,12 @@ func WriteConfig(c Config) error {
 		return err
 	}
 
+	previousStore := recordedCredentialStore()
+
 	configWithoutSecrets := ConvertConfigToConfigWithoutSecrets(c)
+	if err := saveTokens(c, &configWithoutSecrets); err != nil {
+		return err
+	}
 
 	data, err := json.Marshal(configWithoutSecrets)
 	if err != nil {
@@ -86,13 +116,37 @@ func WriteConfig(c Config) error {
 	}
 
 	configPath := ConfigPath()
-	return ioutil.WriteFile(configPath, data, 0600)
+	if err := ioutil.WriteFile(configPath, data, 0600); err != nil {
+		return err
+	}
+
+	if storeName(previousStore) != storeName(c.CredentialStore) {
+		if err := deleteTokens(previousStore); err != nil {
+			fmt.Fprintf(os.Stderr, "error removing tokens from the %s credential store: %+v\n", storeName(previousStore), err)
+		}
+	}
+	return nil
 }
 
 func RemoveConfig() error {
+	if err := deleteTokens(recordedCredentialStore()); err != nil {
+		return err
+	}
 	return os.Remove(ConfigPath())
 }
 
+// UsesClientCertificate reports whether requests authenticate with a TLS client certificate
+// instead of a UAA token
+func (cfg Config) UsesClientCertificate() bool {
+	return cfg.ClientCertPath != "" && cfg.ClientKeyPath != ""
+}
+
+// UsesCredentialHelper reports whether requests authenticate with tokens of a credential helper
+// instead of a UAA token
+func (cfg Config) UsesCredentialHelper() bool {
+	return strings.TrimSpace(cfg.CredentialHelper) != ""
+}
+
 func (cfg *Config) UpdateTrustedCAs(caCerts []string) error {
 	var certs []string
 
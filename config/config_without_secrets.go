@@ -1,27 +1,47 @@
 package config
 
-import "time"
+import (
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+)
 
 type ConfigWithoutSecrets struct {
-	ApiURL             string
-	AuthURL            string
-	AccessToken        string
-	RefreshToken       string
-	InsecureSkipVerify bool
-	CaCerts            []string
-	ServerVersion      string
-	HttpTimeout        *time.Duration
+	ApiURL               string
+	AuthURL              string
+	AccessToken          string
+	RefreshToken         string
+	InsecureSkipVerify   bool
+	CaCerts              []string
+	ServerVersion        string
+	HttpTimeout          *time.Duration
+	PasswordPolicies     map[string]generate.PasswordPolicy `json:",omitempty"`
+	ClientCertPath       string                             `json:",omitempty"`
+	ClientKeyPath        string                             `json:",omitempty"`
+	CredentialStore      string                             `json:",omitempty"`
+	CredentialHelper     string                             `json:",omitempty"`
+	CredentialHelperArgs []string                           `json:",omitempty"`
+	Proxy                string                             `json:",omitempty"`
+	NoProxy              string                             `json:",omitempty"`
 }
 
 func ConvertConfigToConfigWithoutSecrets(config Config) ConfigWithoutSecrets {
 	return ConfigWithoutSecrets{
-		ApiURL:             config.ApiURL,
-		AuthURL:            config.AuthURL,
-		AccessToken:        config.AccessToken,
-		RefreshToken:       config.RefreshToken,
-		InsecureSkipVerify: config.InsecureSkipVerify,
-		CaCerts:            config.CaCerts,
-		ServerVersion:      config.ServerVersion,
-		HttpTimeout:        config.HttpTimeout,
+		ApiURL:               config.ApiURL,
+		AuthURL:              config.AuthURL,
+		AccessToken:          config.AccessToken,
+		RefreshToken:         config.RefreshToken,
+		InsecureSkipVerify:   config.InsecureSkipVerify,
+		CaCerts:              config.CaCerts,
+		ServerVersion:        config.ServerVersion,
+		HttpTimeout:          config.HttpTimeout,
+		PasswordPolicies:     config.PasswordPolicies,
+		ClientCertPath:       config.ClientCertPath,
+		ClientKeyPath:        config.ClientKeyPath,
+		CredentialStore:      config.CredentialStore,
+		CredentialHelper:     config.CredentialHelper,
+		CredentialHelperArgs: config.CredentialHelperArgs,
+		Proxy:                config.Proxy,
+		NoProxy:              config.NoProxy,
 	}
 }
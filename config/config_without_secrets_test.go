@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"code.cloudfoundry.org/credhub-cli/config"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -16,28 +17,44 @@ var _ = Describe("ConfigWithoutSecrets", func() {
 			timeout := 60 * time.Second
 			cliConfig := config.Config{
 				ConfigWithoutSecrets: config.ConfigWithoutSecrets{
-					ApiURL:             "apiURL",
-					AuthURL:            "authURL",
-					AccessToken:        "accessToken",
-					RefreshToken:       "refreshToken",
-					InsecureSkipVerify: true,
-					CaCerts:            []string{"cert1", "cert2"},
-					ServerVersion:      "version",
-					HttpTimeout:        &timeout,
+					ApiURL:               "apiURL",
+					AuthURL:              "authURL",
+					AccessToken:          "accessToken",
+					RefreshToken:         "refreshToken",
+					InsecureSkipVerify:   true,
+					CaCerts:              []string{"cert1", "cert2"},
+					ServerVersion:        "version",
+					HttpTimeout:          &timeout,
+					PasswordPolicies:     map[string]generate.PasswordPolicy{"strong": {Length: 40, MinSpecial: 2}},
+					ClientCertPath:       "/path/to/cert.pem",
+					ClientKeyPath:        "/path/to/key.pem",
+					CredentialStore:      "encrypted-file",
+					CredentialHelper:     "/path/to/credhub helper",
+					CredentialHelperArgs: []string{"--profile", "dev"},
+					Proxy:                "http://proxy.example.com:3128",
+					NoProxy:              "uaa.example.com",
 				},
 				ClientID:     "clientID",
 				ClientSecret: "clientSecret",
 			}
 
 			expectedState := config.ConfigWithoutSecrets{
-				ApiURL:             "apiURL",
-				AuthURL:            "authURL",
-				AccessToken:        "accessToken",
-				RefreshToken:       "refreshToken",
-				InsecureSkipVerify: true,
-				CaCerts:            []string{"cert1", "cert2"},
-				ServerVersion:      "version",
-				HttpTimeout:        &timeout,
+				ApiURL:               "apiURL",
+				AuthURL:              "authURL",
+				AccessToken:          "accessToken",
+				RefreshToken:         "refreshToken",
+				InsecureSkipVerify:   true,
+				CaCerts:              []string{"cert1", "cert2"},
+				ServerVersion:        "version",
+				HttpTimeout:          &timeout,
+				PasswordPolicies:     map[string]generate.PasswordPolicy{"strong": {Length: 40, MinSpecial: 2}},
+				ClientCertPath:       "/path/to/cert.pem",
+				ClientKeyPath:        "/path/to/key.pem",
+				CredentialStore:      "encrypted-file",
+				CredentialHelper:     "/path/to/credhub helper",
+				CredentialHelperArgs: []string{"--profile", "dev"},
+				Proxy:                "http://proxy.example.com:3128",
+				NoProxy:              "uaa.example.com",
 			}
 
 			actualState := config.ConvertConfigToConfigWithoutSecrets(cliConfig)
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// Names of the credential stores selectable with the CredentialStore field of the config or the
+// CREDHUB_CREDENTIAL_STORE environment variable
+const (
+	CredentialStorePlaintext     = "plaintext"
+	CredentialStoreSecretService = "secret-service"
+	CredentialStoreEncryptedFile = "encrypted-file"
+)
+
+// Keys of the config secrets kept in a credential store
+const (
+	accessTokenKey  = "access-token"
+	refreshTokenKey = "refresh-token"
+)
+
+// ErrCredentialNotFound is returned by CredentialStore.Get for keys without a value
+var ErrCredentialNotFound = errors.New("credential not found")
+
+// CredentialStore keeps the tokens of the config outside of the config file
+type CredentialStore interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+}
+
+var credentialStores = map[string]func() CredentialStore{
+	CredentialStoreSecretService: func() CredentialStore { return newSecretServiceStore() },
+	CredentialStoreEncryptedFile: func() CredentialStore { return newEncryptedFileStore() },
+}
+
+// RegisterCredentialStore makes a credential store selectable by name, replacing any store
+// registered with the same name
+func RegisterCredentialStore(name string, store func() CredentialStore) {
+	credentialStores[name] = store
+}
+
+// credentialStoreNamed returns the store with the given name, or nil for tokens kept in the
+// plaintext config file
+func credentialStoreNamed(name string) (CredentialStore, error) {
+	if storeName(name) == CredentialStorePlaintext {
+		return nil, nil
+	}
+
+	store, ok := credentialStores[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown credential store '%s'", name)
+	}
+	return store(), nil
+}
+
+// loadTokens reads the tokens of the config from the store it was written with
+func loadTokens(c *Config, storeName string) error {
+	store, err := credentialStoreNamed(storeName)
+	if err != nil || store == nil {
+		return err
+	}
+
+	if c.AccessToken, err = getCredential(store, accessTokenKey); err != nil {
+		return err
+	}
+	c.RefreshToken, err = getCredential(store, refreshTokenKey)
+	return err
+}
+
+// saveTokens writes the tokens of the config to its store and removes them from the config
+// written to the config file
+func saveTokens(c Config, configWithoutSecrets *ConfigWithoutSecrets) error {
+	store, err := credentialStoreNamed(c.CredentialStore)
+	if err != nil || store == nil {
+		return err
+	}
+
+	if err := setCredential(store, accessTokenKey, c.AccessToken); err != nil {
+		return err
+	}
+	if err := setCredential(store, refreshTokenKey, c.RefreshToken); err != nil {
+		return err
+	}
+
+	configWithoutSecrets.AccessToken = ""
+	configWithoutSecrets.RefreshToken = ""
+	return nil
+}
+
+// deleteTokens removes the tokens of the config from the store with the given name
+func deleteTokens(storeName string) error {
+	store, err := credentialStoreNamed(storeName)
+	if err != nil || store == nil {
+		return err
+	}
+
+	if err := store.Delete(accessTokenKey); err != nil {
+		return err
+	}
+	return store.Delete(refreshTokenKey)
+}
+
+// migrateTokens moves tokens left in the config file to the store selected in the config, which
+// happens when the store is selected by editing the config file after logging in
+func migrateTokens(c Config, from string) {
+	if storeName(c.CredentialStore) == storeName(from) || c.AccessToken == "" && c.RefreshToken == "" {
+		return
+	}
+
+	if err := WriteConfig(c); err != nil {
+		fmt.Fprintf(os.Stderr, "error moving tokens to the %s credential store: %+v\n", storeName(c.CredentialStore), err)
+	}
+}
+
+// recordedCredentialStore returns the store recorded in the config file, which holds the tokens
+// written with the file
+func recordedCredentialStore() string {
+	var c ConfigWithoutSecrets
+	if data, err := ioutil.ReadFile(ConfigPath()); err == nil {
+		json.Unmarshal(data, &c)
+	}
+	return c.CredentialStore
+}
+
+// storeName returns the name of the store, treating an unset store as the plaintext config file
+func storeName(name string) string {
+	if name == "" {
+		return CredentialStorePlaintext
+	}
+	return name
+}
+
+func getCredential(store CredentialStore, key string) (string, error) {
+	value, err := store.Get(key)
+	if err == ErrCredentialNotFound {
+		return "", nil
+	}
+	return value, err
+}
+
+func setCredential(store CredentialStore, key, value string) error {
+	if value == "" {
+		return store.Delete(key)
+	}
+	return store.Set(key, value)
+}
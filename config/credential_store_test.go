@@ -0,0 +1,496 @@
+// +build !windows
+
+package config_test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/credhub-cli/config"
+	"github.com/godbus/dbus/v5"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type memoryStore map[string]string
+
+func (s memoryStore) Get(key string) (string, error) {
+	value, ok := s[key]
+	if !ok {
+		return "", config.ErrCredentialNotFound
+	}
+	return value, nil
+}
+
+func (s memoryStore) Set(key, value string) error {
+	s[key] = value
+	return nil
+}
+
+func (s memoryStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+const dbusConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:tmpdir=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*"/>
+    <allow own="*"/>
+    <allow receive_sender="*"/>
+  </policy>
+</busconfig>
+`
+
+type fakeSecret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+type fakeSecretItem struct {
+	attributes map[string]string
+	value      string
+}
+
+// fakeSecretService implements the methods of the Secret Service API used by the secret-service
+// store. Unlocking a locked service completes a prompt without user interaction.
+type fakeSecretService struct {
+	conn    *dbus.Conn
+	mutex   sync.Mutex
+	items   map[dbus.ObjectPath]fakeSecretItem
+	created int
+	locked  bool
+	dismiss bool
+	prompts int
+}
+
+func startFakeSecretService(conn *dbus.Conn) (*fakeSecretService, error) {
+	service := &fakeSecretService{conn: conn, items: map[dbus.ObjectPath]fakeSecretItem{}}
+
+	err := conn.ExportMethodTable(map[string]interface{}{
+		"OpenSession": func(algorithm string, input dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+			if algorithm != "plain" {
+				return dbus.Variant{}, "", dbus.MakeFailedError(fmt.Errorf("unsupported algorithm %s", algorithm))
+			}
+			return dbus.MakeVariant(""), "/org/freedesktop/secrets/session/1", nil
+		},
+		"SearchItems": service.searchItems,
+		"Unlock":      service.unlock,
+	}, "/org/freedesktop/secrets", "org.freedesktop.Secret.Service")
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.ExportMethodTable(map[string]interface{}{
+		"CreateItem": service.createItem,
+	}, "/org/freedesktop/secrets/aliases/default", "org.freedesktop.Secret.Collection")
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.ExportMethodTable(map[string]interface{}{
+		"Close": func() *dbus.Error { return nil },
+	}, "/org/freedesktop/secrets/session/1", "org.freedesktop.Secret.Session")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.RequestName("org.freedesktop.secrets", dbus.NameFlagDoNotQueue)
+	return service, err
+}
+
+func (s *fakeSecretService) searchItems(attributes map[string]string) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	found := []dbus.ObjectPath{}
+	for path, item := range s.items {
+		if reflect.DeepEqual(item.attributes, attributes) {
+			found = append(found, path)
+		}
+	}
+	if s.locked {
+		return []dbus.ObjectPath{}, found, nil
+	}
+	return found, []dbus.ObjectPath{}, nil
+}
+
+func (s *fakeSecretService) unlock(objects []dbus.ObjectPath) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.locked {
+		return objects, "/", nil
+	}
+
+	prompt := dbus.ObjectPath("/org/freedesktop/secrets/prompt/unlock")
+	s.conn.ExportMethodTable(map[string]interface{}{
+		"Prompt": func(windowID string) *dbus.Error {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+
+			s.prompts++
+			s.locked = s.dismiss
+			go s.conn.Emit(prompt, "org.freedesktop.Secret.Prompt.Completed", s.dismiss, dbus.MakeVariant(objects))
+			return nil
+		},
+	}, prompt, "org.freedesktop.Secret.Prompt")
+	return []dbus.ObjectPath{}, prompt, nil
+}
+
+func (s *fakeSecretService) createItem(properties map[string]dbus.Variant, secret fakeSecret, replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.locked {
+		return "", "", dbus.MakeFailedError(errors.New("the collection is locked"))
+	}
+
+	attributes := properties["org.freedesktop.Secret.Item.Attributes"].Value().(map[string]string)
+	for path, item := range s.items {
+		if replace && reflect.DeepEqual(item.attributes, attributes) {
+			s.items[path] = fakeSecretItem{attributes: attributes, value: string(secret.Value)}
+			return path, "/", nil
+		}
+	}
+
+	s.created++
+	path := dbus.ObjectPath(fmt.Sprintf("/org/freedesktop/secrets/collection/login/%d", s.created))
+	s.items[path] = fakeSecretItem{attributes: attributes, value: string(secret.Value)}
+	s.conn.ExportMethodTable(map[string]interface{}{
+		"GetSecret": func(session dbus.ObjectPath) (fakeSecret, *dbus.Error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			return fakeSecret{Session: session, Parameters: []byte{}, Value: []byte(s.items[path].value), ContentType: "text/plain"}, nil
+		},
+		"Delete": func() (dbus.ObjectPath, *dbus.Error) {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			delete(s.items, path)
+			return "/", nil
+		},
+	}, path, "org.freedesktop.Secret.Item")
+	return path, "/", nil
+}
+
+// values returns the stored secrets by the key attribute
+func (s *fakeSecretService) values() map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	values := map[string]string{}
+	for _, item := range s.items {
+		values[item.attributes["key"]] = item.value
+	}
+	return values
+}
+
+var _ = Describe("Credential stores", func() {
+	var (
+		homeDir    string
+		oldHome    string
+		configPath string
+		cfg        config.Config
+	)
+
+	configFile := func() string {
+		data, err := ioutil.ReadFile(configPath)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	BeforeEach(func() {
+		var err error
+		homeDir, err = ioutil.TempDir("", "credhub-cli-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		oldHome = os.Getenv("HOME")
+		os.Setenv("HOME", homeDir)
+		configPath = path.Join(homeDir, ".credhub", "config.json")
+
+		cfg = config.Config{
+			ConfigWithoutSecrets: config.ConfigWithoutSecrets{
+				ApiURL:       "https://api.example.com",
+				AuthURL:      "https://auth.example.com",
+				AccessToken:  "some-access-token",
+				RefreshToken: "some-refresh-token",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		os.Setenv("HOME", oldHome)
+		os.Unsetenv("CREDHUB_CREDENTIAL_STORE")
+		os.Unsetenv("CREDHUB_CREDENTIAL_STORE_PASSPHRASE")
+		os.RemoveAll(homeDir)
+	})
+
+	Context("plaintext", func() {
+		It("keeps the tokens in the config file", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			Expect(configFile()).To(ContainSubstring("some-access-token"))
+			Expect(configFile()).NotTo(ContainSubstring("CredentialStore"))
+			Expect(config.ReadConfig().RefreshToken).To(Equal("some-refresh-token"))
+		})
+	})
+
+	Context("encrypted-file", func() {
+		BeforeEach(func() {
+			cfg.CredentialStore = config.CredentialStoreEncryptedFile
+		})
+
+		It("keeps the tokens out of the config file", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			Expect(configFile()).NotTo(ContainSubstring("some-access-token"))
+			Expect(configFile()).NotTo(ContainSubstring("some-refresh-token"))
+
+			encrypted, err := ioutil.ReadFile(path.Join(homeDir, ".credhub", "credentials.enc"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(encrypted)).NotTo(ContainSubstring("some-access-token"))
+
+			readCfg := config.ReadConfig()
+			Expect(readCfg.AccessToken).To(Equal("some-access-token"))
+			Expect(readCfg.RefreshToken).To(Equal("some-refresh-token"))
+		})
+
+		It("creates a key readable only by the user", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			info, err := os.Stat(path.Join(homeDir, ".credhub", "credentials.key"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+
+		It("encrypts with the same key when processes create it concurrently", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+					Expect(config.WriteConfig(cfg)).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			Expect(config.ReadConfig().AccessToken).To(Equal("some-access-token"))
+		})
+
+		It("removes the tokens on logout", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			cfg.AccessToken = ""
+			cfg.RefreshToken = ""
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			Expect(config.ReadConfig().AccessToken).To(BeEmpty())
+		})
+
+		It("removes the tokens with the config", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+			Expect(config.RemoveConfig()).To(Succeed())
+
+			cfg.AccessToken = ""
+			cfg.RefreshToken = ""
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+			Expect(config.ReadConfig().AccessToken).To(BeEmpty())
+		})
+
+		Context("with a passphrase", func() {
+			BeforeEach(func() {
+				os.Setenv("CREDHUB_CREDENTIAL_STORE_PASSPHRASE", "some-passphrase")
+			})
+
+			It("derives the key from the passphrase", func() {
+				Expect(config.WriteConfig(cfg)).To(Succeed())
+
+				_, err := os.Stat(path.Join(homeDir, ".credhub", "credentials.key"))
+				Expect(os.IsNotExist(err)).To(BeTrue())
+				Expect(config.ReadConfig().AccessToken).To(Equal("some-access-token"))
+			})
+
+			It("cannot read the tokens with another passphrase", func() {
+				Expect(config.WriteConfig(cfg)).To(Succeed())
+
+				os.Setenv("CREDHUB_CREDENTIAL_STORE_PASSPHRASE", "other-passphrase")
+
+				Expect(config.ReadConfig().AccessToken).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("secret-service", func() {
+		var (
+			daemon     *exec.Cmd
+			oldAddress string
+			conn       *dbus.Conn
+			service    *fakeSecretService
+		)
+
+		BeforeEach(func() {
+			if _, err := exec.LookPath("dbus-daemon"); err != nil {
+				Skip("dbus-daemon is not installed")
+			}
+
+			configFile := path.Join(homeDir, "bus.conf")
+			Expect(ioutil.WriteFile(configFile, []byte(fmt.Sprintf(dbusConfig, homeDir)), 0600)).To(Succeed())
+			daemon = exec.Command("dbus-daemon", "--config-file="+configFile, "--nofork", "--print-address")
+			stdout, err := daemon.StdoutPipe()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(daemon.Start()).To(Succeed())
+			address, err := bufio.NewReader(stdout).ReadString('\n')
+			Expect(err).NotTo(HaveOccurred())
+
+			oldAddress = os.Getenv("DBUS_SESSION_BUS_ADDRESS")
+			os.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(address))
+
+			conn, err = dbus.SessionBusPrivate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conn.Auth(nil)).To(Succeed())
+			Expect(conn.Hello()).To(Succeed())
+			service, err = startFakeSecretService(conn)
+			Expect(err).NotTo(HaveOccurred())
+
+			cfg.CredentialStore = config.CredentialStoreSecretService
+		})
+
+		AfterEach(func() {
+			if daemon == nil {
+				return
+			}
+			conn.Close()
+			daemon.Process.Kill()
+			daemon.Wait()
+			os.Setenv("DBUS_SESSION_BUS_ADDRESS", oldAddress)
+		})
+
+		It("keeps the tokens in the secret service", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			Expect(configFile()).NotTo(ContainSubstring("some-access-token"))
+			Expect(service.values()).To(Equal(map[string]string{"access-token": "some-access-token", "refresh-token": "some-refresh-token"}))
+
+			Expect(config.ReadConfig().RefreshToken).To(Equal("some-refresh-token"))
+		})
+
+		It("replaces and removes the tokens", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			cfg.AccessToken = "other-access-token"
+			cfg.RefreshToken = ""
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			Expect(service.values()).To(Equal(map[string]string{"access-token": "other-access-token"}))
+			Expect(config.ReadConfig().RefreshToken).To(BeEmpty())
+		})
+
+		It("unlocks a locked keyring with a prompt", func() {
+			service.locked = true
+
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			Expect(service.prompts).To(Equal(1))
+			Expect(config.ReadConfig().AccessToken).To(Equal("some-access-token"))
+		})
+
+		It("returns an error when the prompt is dismissed", func() {
+			service.locked = true
+			service.dismiss = true
+
+			Expect(config.WriteConfig(cfg)).To(MatchError("the Secret Service prompt was dismissed"))
+		})
+
+		It("returns an error when no secret service is running", func() {
+			conn.Close()
+
+			err := config.WriteConfig(cfg)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("the secret-service credential store requires a Secret Service such as GNOME Keyring or KWallet on the D-Bus session bus"))
+		})
+	})
+
+	Context("when the credential store changes", func() {
+		It("moves plaintext tokens to the selected store", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			cfg.CredentialStore = config.CredentialStoreEncryptedFile
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			Expect(configFile()).NotTo(ContainSubstring("some-access-token"))
+			Expect(configFile()).To(ContainSubstring(`"CredentialStore":"encrypted-file"`))
+			Expect(config.ReadConfig().RefreshToken).To(Equal("some-refresh-token"))
+		})
+
+		It("moves stored tokens back to the config file", func() {
+			cfg.CredentialStore = config.CredentialStoreEncryptedFile
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			cfg.CredentialStore = config.CredentialStorePlaintext
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			Expect(configFile()).To(ContainSubstring("some-access-token"))
+			Expect(configFile()).To(ContainSubstring(`"CredentialStore":"plaintext"`))
+
+			// the tokens are removed from the previous store
+			Expect(ioutil.WriteFile(configPath, []byte(`{"CredentialStore":"encrypted-file"}`), 0600)).To(Succeed())
+			Expect(config.ReadConfig().AccessToken).To(BeEmpty())
+		})
+
+		It("does not change the store when reading the config", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+			before := configFile()
+
+			os.Setenv("CREDHUB_CREDENTIAL_STORE", config.CredentialStoreEncryptedFile)
+			readCfg := config.ReadConfig()
+
+			Expect(readCfg.AccessToken).To(Equal("some-access-token"))
+			Expect(readCfg.CredentialStore).To(BeEmpty())
+			Expect(configFile()).To(Equal(before))
+			_, err := os.Stat(path.Join(homeDir, ".credhub", "credentials.enc"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("moves tokens left in the config file to the recorded store", func() {
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+			Expect(ioutil.WriteFile(configPath, []byte(`{"ApiURL":"https://api.example.com","AccessToken":"some-access-token","CredentialStore":"encrypted-file"}`), 0600)).To(Succeed())
+
+			Expect(config.ReadConfig().AccessToken).To(Equal("some-access-token"))
+			Expect(configFile()).NotTo(ContainSubstring("some-access-token"))
+		})
+	})
+
+	Context("RegisterCredentialStore()", func() {
+		It("makes other stores selectable", func() {
+			store := memoryStore{}
+			config.RegisterCredentialStore("memory", func() config.CredentialStore { return store })
+			cfg.CredentialStore = "memory"
+
+			Expect(config.WriteConfig(cfg)).To(Succeed())
+
+			Expect(store).To(Equal(memoryStore{"access-token": "some-access-token", "refresh-token": "some-refresh-token"}))
+			Expect(config.ReadConfig().AccessToken).To(Equal("some-access-token"))
+		})
+
+		It("rejects unknown stores", func() {
+			cfg.CredentialStore = "unknown"
+
+			Expect(config.WriteConfig(cfg)).To(MatchError("unknown credential store 'unknown'"))
+		})
+	})
+})
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"code.cloudfoundry.org/credhub-cli/util"
+	"golang.org/x/crypto/scrypt"
+)
+
+// encryptedFileStore keeps credentials in a file encrypted with AES-256-GCM. The key is derived
+// from CREDHUB_CREDENTIAL_STORE_PASSPHRASE when it is set. Otherwise a random key is kept in a
+// separate file readable only by the user, next to the encrypted file, which only obfuscates the
+// credentials: anyone able to read the config directory can decrypt them.
+type encryptedFileStore struct {
+	path       string
+	keyPath    string
+	passphrase string
+}
+
+type encryptedFile struct {
+	Salt  []byte `json:",omitempty"`
+	Nonce []byte
+	Data  []byte
+}
+
+func newEncryptedFileStore() *encryptedFileStore {
+	return &encryptedFileStore{
+		path:       path.Join(ConfigDir(), "credentials.enc"),
+		keyPath:    path.Join(ConfigDir(), "credentials.key"),
+		passphrase: os.Getenv("CREDHUB_CREDENTIAL_STORE_PASSPHRASE"),
+	}
+}
+
+func (s *encryptedFileStore) Get(key string) (string, error) {
+	credentials, err := s.read()
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := credentials[key]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return value, nil
+}
+
+func (s *encryptedFileStore) Set(key, value string) error {
+	credentials, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	credentials[key] = value
+	return s.write(credentials)
+}
+
+func (s *encryptedFileStore) Delete(key string) error {
+	credentials, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := credentials[key]; !ok {
+		return nil
+	}
+	delete(credentials, key)
+	return s.write(credentials)
+}
+
+func (s *encryptedFileStore) read() (map[string]string, error) {
+	credentials := map[string]string{}
+
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return credentials, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	gcm, err := s.cipher(file.Salt, false)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, errors.New("unable to decrypt " + s.path + ", the passphrase or key may have changed")
+	}
+
+	err = json.Unmarshal(plaintext, &credentials)
+	return credentials, err
+}
+
+func (s *encryptedFileStore) write(credentials map[string]string) error {
+	if err := makeDirectory(); err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+
+	var file encryptedFile
+	if s.passphrase != "" {
+		file.Salt = make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, file.Salt); err != nil {
+			return err
+		}
+	}
+
+	gcm, err := s.cipher(file.Salt, true)
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteFileAtomically(s.path, data, 0600, true)
+}
+
+// cipher returns the cipher for the passphrase and salt, or for the key file when the file was
+// written without a passphrase. A missing key file is only created when writing.
+func (s *encryptedFileStore) cipher(salt []byte, create bool) (cipher.AEAD, error) {
+	var key []byte
+	var err error
+
+	if salt != nil {
+		if s.passphrase == "" {
+			return nil, errors.New(s.path + " is encrypted with a passphrase. Please set CREDHUB_CREDENTIAL_STORE_PASSPHRASE and retry your request.")
+		}
+		key, err = scrypt.Key([]byte(s.passphrase), salt, 32768, 8, 1, 32)
+	} else {
+		key, err = s.fileKey(create)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *encryptedFileStore) fileKey(create bool) ([]byte, error) {
+	key, err := ioutil.ReadFile(s.keyPath)
+	if err == nil || !os.IsNotExist(err) || !create {
+		return key, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	if err := util.WriteFileAtomically(s.keyPath, key, 0600, false); err != nil {
+		// another process created the key first, use its key so that both encrypt with the same one
+		if os.IsExist(err) {
+			return ioutil.ReadFile(s.keyPath)
+		}
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Names of the Secret Service API on the D-Bus session bus
+// See: https://specifications.freedesktop.org/secret-service/
+const (
+	secretServiceName         = "org.freedesktop.secrets"
+	secretServicePath         = dbus.ObjectPath("/org/freedesktop/secrets")
+	secretDefaultCollection   = dbus.ObjectPath("/org/freedesktop/secrets/aliases/default")
+	secretServiceInterface    = "org.freedesktop.Secret.Service"
+	secretCollectionInterface = "org.freedesktop.Secret.Collection"
+	secretItemInterface       = "org.freedesktop.Secret.Item"
+	secretSessionInterface    = "org.freedesktop.Secret.Session"
+	secretPromptInterface     = "org.freedesktop.Secret.Prompt"
+
+	// secretNoPrompt is returned by methods which completed without prompting the user
+	secretNoPrompt = dbus.ObjectPath("/")
+)
+
+// secret is the Secret structure of the Secret Service API
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// secretServiceStore keeps credentials in the Secret Service of the desktop session, such as
+// GNOME Keyring or KWallet, which it reaches over the D-Bus session bus. Items are looked up by
+// the path of the config file, so that users with several config directories keep separate
+// tokens.
+type secretServiceStore struct {
+	configPath string
+}
+
+func newSecretServiceStore() *secretServiceStore {
+	return &secretServiceStore{configPath: ConfigPath()}
+}
+
+func (s *secretServiceStore) Get(key string) (string, error) {
+	var value string
+	err := s.withSession(func(conn *dbus.Conn, session dbus.ObjectPath) error {
+		items, err := s.searchItems(conn, key)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return ErrCredentialNotFound
+		}
+
+		var result secret
+		if err := conn.Object(secretServiceName, items[0]).Call(secretItemInterface+".GetSecret", 0, session).Store(&result); err != nil {
+			return err
+		}
+		value = string(result.Value)
+		return nil
+	})
+	return value, err
+}
+
+func (s *secretServiceStore) Set(key, value string) error {
+	return s.withSession(func(conn *dbus.Conn, session dbus.ObjectPath) error {
+		if _, err := unlockSecrets(conn, []dbus.ObjectPath{secretDefaultCollection}); err != nil {
+			return err
+		}
+
+		properties := map[string]dbus.Variant{
+			secretItemInterface + ".Label":      dbus.MakeVariant("CredHub CLI " + key),
+			secretItemInterface + ".Attributes": dbus.MakeVariant(s.attributes(key)),
+		}
+		item := secret{Session: session, Parameters: []byte{}, Value: []byte(value), ContentType: "text/plain"}
+
+		var created, prompt dbus.ObjectPath
+		err := conn.Object(secretServiceName, secretDefaultCollection).Call(secretCollectionInterface+".CreateItem", 0, properties, item, true).Store(&created, &prompt)
+		if err != nil {
+			return err
+		}
+		_, err = promptUser(conn, prompt)
+		return err
+	})
+}
+
+func (s *secretServiceStore) Delete(key string) error {
+	return s.withSession(func(conn *dbus.Conn, session dbus.ObjectPath) error {
+		items, err := s.searchItems(conn, key)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			var prompt dbus.ObjectPath
+			if err := conn.Object(secretServiceName, item).Call(secretItemInterface+".Delete", 0).Store(&prompt); err != nil {
+				return err
+			}
+			if _, err := promptUser(conn, prompt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *secretServiceStore) attributes(key string) map[string]string {
+	return map[string]string{"service": "credhub-cli", "config": s.configPath, "key": key}
+}
+
+// searchItems returns the items stored for key, unlocking locked items
+func (s *secretServiceStore) searchItems(conn *dbus.Conn, key string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	err := conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".SearchItems", 0, s.attributes(key)).Store(&unlocked, &locked)
+	if err != nil {
+		return nil, err
+	}
+	if len(locked) == 0 {
+		return unlocked, nil
+	}
+
+	unlockedNow, err := unlockSecrets(conn, locked)
+	if err != nil {
+		return nil, err
+	}
+	return append(unlocked, unlockedNow...), nil
+}
+
+// withSession connects to the session bus and opens a session with the Secret Service. Secrets
+// are transferred in plain text, as they do not leave the session bus of the user.
+func (s *secretServiceStore) withSession(run func(conn *dbus.Conn, session dbus.ObjectPath) error) error {
+	conn, err := dbus.SessionBusPrivate()
+	if err != nil {
+		return secretServiceUnavailable(err)
+	}
+	defer conn.Close()
+
+	if err := conn.Auth(nil); err != nil {
+		return secretServiceUnavailable(err)
+	}
+	if err := conn.Hello(); err != nil {
+		return secretServiceUnavailable(err)
+	}
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err = conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &session)
+	if err != nil {
+		return secretServiceUnavailable(err)
+	}
+	defer conn.Object(secretServiceName, session).Call(secretSessionInterface+".Close", 0)
+
+	return run(conn, session)
+}
+
+// unlockSecrets unlocks items or collections, prompting the user if the Secret Service asks for
+// it, and returns the unlocked objects
+func unlockSecrets(conn *dbus.Conn, objects []dbus.ObjectPath) ([]dbus.ObjectPath, error) {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	err := conn.Object(secretServiceName, secretServicePath).Call(secretServiceInterface+".Unlock", 0, objects).Store(&unlocked, &prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := promptUser(conn, prompt)
+	if err != nil {
+		return nil, err
+	}
+	if prompted, ok := result.Value().([]dbus.ObjectPath); ok {
+		unlocked = append(unlocked, prompted...)
+	}
+	return unlocked, nil
+}
+
+// promptUser shows a prompt of the Secret Service, such as the password dialog of a locked
+// keyring, and waits until the user completes it
+func promptUser(conn *dbus.Conn, prompt dbus.ObjectPath) (dbus.Variant, error) {
+	if prompt == secretNoPrompt || prompt == "" {
+		return dbus.Variant{}, nil
+	}
+
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface(secretPromptInterface),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := conn.AddMatchSignal(match...); err != nil {
+		return dbus.Variant{}, err
+	}
+	defer conn.RemoveMatchSignal(match...)
+
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	if err := conn.Object(secretServiceName, prompt).Call(secretPromptInterface+".Prompt", 0, "").Err; err != nil {
+		return dbus.Variant{}, err
+	}
+
+	for signal := range signals {
+		if signal.Path != prompt || signal.Name != secretPromptInterface+".Completed" || len(signal.Body) != 2 {
+			continue
+		}
+		if dismissed, _ := signal.Body[0].(bool); dismissed {
+			return dbus.Variant{}, errors.New("the Secret Service prompt was dismissed")
+		}
+		result, _ := signal.Body[1].(dbus.Variant)
+		return result, nil
+	}
+	return dbus.Variant{}, errors.New("the connection to the Secret Service was closed while prompting")
+}
+
+func secretServiceUnavailable(err error) error {
+	return fmt.Errorf("the %s credential store requires a Secret Service such as GNOME Keyring or KWallet on the D-Bus session bus, please start one or select another credential store: %v", CredentialStoreSecretService, err)
+}
@@ -6,6 +6,8 @@ func ValidateConfig(c Config) error {
 	err := ValidateConfigApi(c)
 	if err != nil {
 		return err
+	} else if c.UsesClientCertificate() || c.UsesCredentialHelper() {
+		return nil
 	} else if (c.AccessToken == "" || c.AccessToken == "revoked") && c.ClientID == "" {
 		return errors.NewRevokedTokenError()
 	}
@@ -42,4 +42,21 @@ var _ = Describe("Config validation", func() {
 		Expect(config.ValidateConfig(cfg)).To(Equal(errors.New("You are not currently authenticated. Please log in to continue.")))
 
 	})
+
+	It("does not require a token when a client certificate is configured", func() {
+		cfg := config.Config{}
+		cfg.ApiURL = "http://api.example.com"
+		cfg.ClientCertPath = "/path/to/cert.pem"
+		cfg.ClientKeyPath = "/path/to/key.pem"
+
+		Expect(config.ValidateConfig(cfg)).To(BeNil())
+	})
+
+	It("does not require a token when a credential helper is configured", func() {
+		cfg := config.Config{}
+		cfg.ApiURL = "http://api.example.com"
+		cfg.CredentialHelper = "credhub-helper"
+
+		Expect(config.ValidateConfig(cfg)).To(BeNil())
+	})
 })
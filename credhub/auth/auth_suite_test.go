@@ -5,6 +5,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"testing"
+	"time"
 )
 
 func TestAuth(t *testing.T) {
@@ -23,6 +24,9 @@ type dummyUaaClient struct {
 	NewAccessToken  string
 	NewRefreshToken string
 	Error           error
+
+	Grants int
+	Delay  time.Duration
 }
 
 func (d *dummyUaaClient) ClientCredentialGrant(clientId, clientSecret string) (string, error) {
@@ -45,6 +49,8 @@ func (d *dummyUaaClient) RefreshTokenGrant(clientId, clientSecret, refreshToken
 	d.ClientId = clientId
 	d.ClientSecret = clientSecret
 	d.RefreshToken = refreshToken
+	d.Grants++
+	time.Sleep(d.Delay)
 
 	return d.NewAccessToken, d.NewRefreshToken, d.Error
 }
@@ -3,6 +3,7 @@ package auth
 import (
 	"net/http"
 
+	"code.cloudfoundry.org/credhub-cli/credhub/auth/oidc"
 	"code.cloudfoundry.org/credhub-cli/credhub/auth/uaa"
 )
 
@@ -24,6 +25,18 @@ var Noop Builder = func(config Config) (Strategy, error) {
 	return &NoopStrategy{config.Client()}, nil
 }
 
+// CredentialHelper builds a CredentialHelperStrategy which obtains tokens by running the given
+// credential helper command
+func CredentialHelper(command string, args ...string) Builder {
+	return func(config Config) (Strategy, error) {
+		return &CredentialHelperStrategy{
+			Command:   command,
+			Args:      args,
+			ApiClient: config.Client(),
+		}, nil
+	}
+}
+
 // UaaPassword builds an OauthStrategy for UAA using password_grant token requests
 func UaaPassword(clientId, clientSecret, username, password string) Builder {
 	return Uaa(clientId, clientSecret, username, password, "", "", false)
@@ -64,3 +77,51 @@ func Uaa(clientId, clientSecret, username, password, accessToken, refreshToken s
 		return oauth, nil
 	}
 }
+
+// OIDCPassword builds an OauthStrategy for an OpenID Connect provider using password_grant token requests
+func OIDCPassword(issuer, clientId, clientSecret, username, password string) Builder {
+	return OIDC(issuer, clientId, clientSecret, username, password, "", "", false)
+}
+
+// OIDCClientCredentials builds an OauthStrategy for an OpenID Connect provider using client_credential_grant token requests
+func OIDCClientCredentials(issuer, clientId, clientSecret string) Builder {
+	return OIDC(issuer, clientId, clientSecret, "", "", "", "", true)
+}
+
+// OIDC builds an OauthStrategy for an OpenID Connect provider using existing tokens
+//
+// The endpoints of the provider are discovered from the issuer, or from the auth URL of the
+// CredHub server when the issuer is empty.
+func OIDC(issuer, clientId, clientSecret, username, password, accessToken, refreshToken string, usingClientCrendentials bool) Builder {
+	return func(config Config) (Strategy, error) {
+		httpClient := config.Client()
+
+		issuerUrl := issuer
+		if issuerUrl == "" {
+			authUrl, err := config.AuthURL()
+			if err != nil {
+				return nil, err
+			}
+			issuerUrl = authUrl
+		}
+
+		provider, err := oidc.Discover(httpClient, issuerUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		oauth := &OAuthStrategy{
+			Username:                username,
+			Password:                password,
+			ClientId:                clientId,
+			ClientSecret:            clientSecret,
+			ApiClient:               httpClient,
+			OAuthClient:             &oidc.Client{Provider: provider, Client: httpClient},
+			ClientCredentialRefresh: usingClientCrendentials,
+		}
+
+		oauth.SetTokens(accessToken, refreshToken)
+
+		return oauth, nil
+	}
+}
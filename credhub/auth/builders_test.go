@@ -3,7 +3,9 @@ package auth
 import (
 	"errors"
 	"net/http"
+	"net/http/httptest"
 
+	"code.cloudfoundry.org/credhub-cli/credhub/auth/oidc"
 	"code.cloudfoundry.org/credhub-cli/credhub/auth/uaa"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -22,6 +24,19 @@ func (d *DummyServerConfig) Client() *http.Client {
 }
 
 var _ = Describe("Constructors", func() {
+	Describe("CredentialHelper()", func() {
+		It("constructs a CredentialHelperStrategy running the helper", func() {
+			config := DummyServerConfig{}
+			builder := CredentialHelper("some-helper", "--some-flag")
+			strategy, err := builder(&config)
+			Expect(err).NotTo(HaveOccurred())
+			helper := strategy.(*CredentialHelperStrategy)
+			Expect(helper.Command).To(Equal("some-helper"))
+			Expect(helper.Args).To(Equal([]string{"--some-flag"}))
+			Expect(helper.ApiClient).To(BeIdenticalTo(config.Client()))
+		})
+	})
+
 	Describe("PasswordGrant()", func() {
 		It("constructs a OAuthStrategy auth using password grant", func() {
 			config := DummyServerConfig{}
@@ -124,4 +139,52 @@ var _ = Describe("Constructors", func() {
 			})
 		})
 	})
+
+	Describe("OIDC()", func() {
+		var provider *httptest.Server
+
+		BeforeEach(func() {
+			provider = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/.well-known/openid-configuration"))
+				w.Write([]byte(`{"issuer": "` + provider.URL + `", "token_endpoint": "` + provider.URL + `/token"}`))
+			}))
+		})
+
+		AfterEach(func() {
+			provider.Close()
+		})
+
+		It("constructs a OAuthStrategy auth for the discovered provider", func() {
+			config := DummyServerConfig{}
+			builder := OIDC(provider.URL, "some-client-id", "some-client-secret", "some-username", "some-password", "some-access-token", "some-refresh-token", false)
+			strategy, err := builder(&config)
+			Expect(err).NotTo(HaveOccurred())
+
+			auth := strategy.(*OAuthStrategy)
+			Expect(auth.ClientId).To(Equal("some-client-id"))
+			Expect(auth.Username).To(Equal("some-username"))
+			Expect(auth.AccessToken()).To(Equal("some-access-token"))
+			Expect(auth.RefreshToken()).To(Equal("some-refresh-token"))
+			Expect(auth.OAuthClient.(*oidc.Client).Provider.TokenEndpoint).To(Equal(provider.URL + "/token"))
+			Expect(auth.OAuthClient.(*oidc.Client).Client).To(BeIdenticalTo(config.Client()))
+		})
+
+		Context("without an issuer", func() {
+			It("returns an error when fetching an Auth URL fails", func() {
+				config := DummyServerConfig{
+					Error: errors.New("Failed to fetch Auth URL"),
+				}
+				_, err := OIDCClientCredentials("", "some-client-id", "some-client-secret")(&config)
+
+				Expect(err).To(MatchError("Failed to fetch Auth URL"))
+			})
+		})
+
+		It("returns an error when the provider cannot be discovered", func() {
+			config := DummyServerConfig{}
+			_, err := OIDCPassword("http://127.0.0.1:0", "some-client-id", "", "some-username", "some-password")(&config)
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
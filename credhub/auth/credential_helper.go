@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// CredentialHelperStrategy authenticates requests with access tokens returned by an external
+// credential helper, similar to git and docker credential helpers.
+//
+// The helper is run as `Command Args... get` with the URL of the CredHub server on stdin:
+//
+//	{"server_url": "https://credhub.example.com:8844"}
+//
+// and must print the token and, optionally, its expiry on stdout:
+//
+//	{"access_token": "...", "expires_at": "2020-01-01T00:00:00Z"}
+//	{"access_token": "...", "expires_in": 3600}
+//
+// Without an expiry, JWT access tokens are cached until their exp claim and other tokens until
+// the server rejects them. Anything the helper writes to stderr is shown to the user, so helpers
+// may prompt for input on the terminal.
+//
+// Tokens are only cached in memory. To reuse a token across processes, save the tokens reported
+// to OnTokenRefreshed and pass them to SetAccessToken.
+type CredentialHelperStrategy struct {
+	Command   string
+	Args      []string
+	ApiClient *http.Client
+
+	// RefreshSkew is how long before its expiry a cached token is replaced. DefaultRefreshSkew
+	// is used when zero; a negative skew keeps tokens until they expire or the server rejects them.
+	RefreshSkew time.Duration
+
+	// Stderr receives the stderr of the helper, os.Stderr when nil
+	Stderr io.Writer
+
+	// OnTokenRefreshed, if set, is called with each new token returned by the helper
+	OnTokenRefreshed func(accessToken string)
+
+	mu          sync.Mutex // guards accessToken & expiresAt, serializes helper runs
+	accessToken string
+	expiresAt   time.Time
+}
+
+// HelperToken is the output of a credential helper
+type HelperToken struct {
+	AccessToken string     `json:"access_token"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ExpiresIn   int64      `json:"expires_in,omitempty"`
+}
+
+var _ Strategy = new(CredentialHelperStrategy)
+
+// Do submits requests with bearer token authorization, using a token of the helper.
+//
+// The helper is run again when the cached token expires, or once when the server rejects it.
+func (s *CredentialHelperStrategy) Do(req *http.Request) (*http.Response, error) {
+	serverURL := helperServerURL(req.URL)
+
+	accessToken, err := s.token(serverURL, "")
+	if err != nil {
+		return nil, err
+	}
+
+	clone, err := cloneRequest(req)
+	if err != nil {
+		return nil, errors.New("failed to clone request body: " + err.Error())
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := s.ApiClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	accessToken, err = s.token(serverURL, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	clone.Header.Set("Authorization", "Bearer "+accessToken)
+	return s.ApiClient.Do(clone)
+}
+
+// AccessToken returns a token of the helper for the server at the given URL, running the helper
+// unless a cached token is still valid
+func (s *CredentialHelperStrategy) AccessToken(serverURL string) (string, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", err
+	}
+	return s.token(helperServerURL(u), "")
+}
+
+// SetAccessToken caches a token the helper returned earlier. The token is used until its exp claim
+// if it is a JWT, otherwise until the server rejects it.
+func (s *CredentialHelperStrategy) SetAccessToken(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accessToken = accessToken
+	s.expiresAt, _ = tokenExpiry(accessToken)
+}
+
+// helperServerURL returns the server URL given to the helper, the scheme and host of the URL
+func helperServerURL(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
+
+// token returns the cached token, or runs the helper when there is no valid cached token or the
+// cached token is the one the server rejected
+func (s *CredentialHelperStrategy) token(serverURL, rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != "" && s.accessToken != rejected && !s.expiring() {
+		return s.accessToken, nil
+	}
+
+	token, err := s.run(serverURL)
+	if err != nil {
+		return "", err
+	}
+
+	s.accessToken = token.AccessToken
+	switch {
+	case token.ExpiresAt != nil:
+		s.expiresAt = *token.ExpiresAt
+	case token.ExpiresIn > 0:
+		s.expiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	default:
+		s.expiresAt, _ = tokenExpiry(token.AccessToken)
+	}
+
+	if s.OnTokenRefreshed != nil {
+		s.OnTokenRefreshed(s.accessToken)
+	}
+
+	return s.accessToken, nil
+}
+
+func (s *CredentialHelperStrategy) expiring() bool {
+	if s.expiresAt.IsZero() {
+		return false
+	}
+
+	skew := s.RefreshSkew
+	if skew == 0 {
+		skew = DefaultRefreshSkew
+	}
+	if skew < 0 {
+		return !time.Now().Before(s.expiresAt)
+	}
+	return time.Until(s.expiresAt) < skew
+}
+
+func (s *CredentialHelperStrategy) run(serverURL string) (*HelperToken, error) {
+	input, err := json.Marshal(map[string]string{"server_url": serverURL})
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(append([]string{}, s.Args...), "get")
+	cmd := exec.Command(s.Command, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = s.Stderr
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("the credential helper %s failed: %v", s.Command, err)
+	}
+
+	var token HelperToken
+	if err := json.Unmarshal(output, &token); err != nil {
+		return nil, fmt.Errorf("the credential helper %s returned invalid JSON: %v", s.Command, err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("the credential helper %s returned no access_token", s.Command)
+	}
+
+	return &token, nil
+}
@@ -0,0 +1,185 @@
+// +build !windows
+
+package auth_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub/auth"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// helperScript prints tokens numbered by invocation, expiring after the number of seconds in
+// its second argument
+const helperScript = `#!/bin/sh
+[ "$3" = "get" ] || exit 2
+cat > "$1/input"
+count=$(cat "$1/count" 2>/dev/null || echo 0)
+count=$((count + 1))
+echo $count > "$1/count"
+echo "{\"access_token\": \"token-$count\", \"expires_in\": $2}"
+`
+
+var _ = Describe("CredentialHelperStrategy", func() {
+	var (
+		dir      string
+		helper   *CredentialHelperStrategy
+		server   *httptest.Server
+		rejected map[string]bool
+		received []string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "credential-helper")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "helper"), []byte(helperScript), 0755)).To(Succeed())
+
+		rejected = map[string]bool{}
+		received = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			received = append(received, r.Header.Get("Authorization")+" "+string(body))
+			if rejected[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+
+		helper = &CredentialHelperStrategy{
+			Command:   filepath.Join(dir, "helper"),
+			Args:      []string{dir, "3600"},
+			ApiClient: http.DefaultClient,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	get := func() *http.Response {
+		request, _ := http.NewRequest("POST", server.URL+"/api/v1/data", bytes.NewBufferString("some-body"))
+		response, err := helper.Do(request)
+		Expect(err).NotTo(HaveOccurred())
+		return response
+	}
+
+	It("authenticates requests with the token of the helper", func() {
+		Expect(get().StatusCode).To(Equal(http.StatusOK))
+
+		Expect(received).To(Equal([]string{"Bearer token-1 some-body"}))
+		input, err := ioutil.ReadFile(filepath.Join(dir, "input"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(input)).To(MatchJSON(`{"server_url": "` + server.URL + `"}`))
+	})
+
+	It("gives the helper the same server URL for tokens requested without a request", func() {
+		accessToken, err := helper.AccessToken(server.URL + "/api/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(accessToken).To(Equal("token-1"))
+
+		input, err := ioutil.ReadFile(filepath.Join(dir, "input"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(input)).To(MatchJSON(`{"server_url": "` + server.URL + `"}`))
+	})
+
+	It("caches the token until it expires", func() {
+		get()
+		get()
+
+		Expect(received).To(Equal([]string{"Bearer token-1 some-body", "Bearer token-1 some-body"}))
+	})
+
+	It("reports new tokens to OnTokenRefreshed", func() {
+		var refreshed []string
+		helper.OnTokenRefreshed = func(accessToken string) {
+			refreshed = append(refreshed, accessToken)
+		}
+
+		get()
+		get()
+
+		Expect(refreshed).To(Equal([]string{"token-1"}))
+	})
+
+	It("uses a token set with SetAccessToken without running the helper", func() {
+		helper.SetAccessToken("saved-token")
+
+		get()
+
+		Expect(received).To(Equal([]string{"Bearer saved-token some-body"}))
+		_, err := os.Stat(filepath.Join(dir, "count"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("runs the helper when the server rejects a token set with SetAccessToken", func() {
+		helper.SetAccessToken("saved-token")
+		rejected["saved-token"] = true
+
+		get()
+
+		Expect(received).To(Equal([]string{"Bearer saved-token some-body", "Bearer token-1 some-body"}))
+	})
+
+	It("runs the helper again for tokens about to expire", func() {
+		helper.Args = []string{dir, "10"}
+
+		get()
+		get()
+
+		Expect(received).To(Equal([]string{"Bearer token-1 some-body", "Bearer token-2 some-body"}))
+	})
+
+	It("runs the helper again and retries the request when the server rejects the token", func() {
+		rejected["token-1"] = true
+
+		Expect(get().StatusCode).To(Equal(http.StatusOK))
+
+		Expect(received).To(Equal([]string{"Bearer token-1 some-body", "Bearer token-2 some-body"}))
+	})
+
+	It("returns the response when the server rejects the new token too", func() {
+		rejected["token-1"] = true
+		rejected["token-2"] = true
+
+		Expect(get().StatusCode).To(Equal(http.StatusUnauthorized))
+		Expect(received).To(HaveLen(2))
+	})
+
+	It("returns an error when the helper fails", func() {
+		helper.Args = []string{dir, "3600", "extra-argument"}
+
+		request, _ := http.NewRequest("GET", server.URL, nil)
+		_, err := helper.Do(request)
+
+		Expect(err).To(MatchError("the credential helper " + helper.Command + " failed: exit status 2"))
+		Expect(received).To(BeEmpty())
+	})
+
+	It("returns an error when the helper returns no token", func() {
+		helper.Command = "sh"
+		helper.Args = []string{"-c", "echo {}"}
+
+		request, _ := http.NewRequest("GET", server.URL, nil)
+		_, err := helper.Do(request)
+
+		Expect(err).To(MatchError("the credential helper sh returned no access_token"))
+	})
+
+	It("returns an error when the helper does not return JSON", func() {
+		helper.Command = "echo"
+		helper.Args = nil
+
+		request, _ := http.NewRequest("GET", server.URL, nil)
+		_, err := helper.Do(request)
+
+		Expect(err).To(MatchError(HavePrefix("the credential helper echo returned invalid JSON")))
+	})
+})
@@ -9,14 +9,22 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/auth/oidc"
 )
 
+// DefaultRefreshSkew is how long before its expiry an access token is refreshed when the
+// RefreshSkew of an OAuthStrategy is zero
+const DefaultRefreshSkew = 30 * time.Second
+
 // OAuth authentication strategy
 type OAuthStrategy struct {
 	accessToken  string
 	refreshToken string
 
-	mu sync.RWMutex // guards AccessToken & Refresh Token
+	mu        sync.RWMutex // guards AccessToken & Refresh Token
+	refreshMu sync.Mutex   // serializes token requests
 
 	Username                string
 	Password                string
@@ -25,6 +33,14 @@ type OAuthStrategy struct {
 	ApiClient               *http.Client
 	OAuthClient             OAuthClient
 	ClientCredentialRefresh bool
+
+	// RefreshSkew is how long before the expiry of a JWT access token it is refreshed before
+	// submitting a request. DefaultRefreshSkew is used when zero; a negative skew only refreshes
+	// tokens after the server rejected them.
+	RefreshSkew time.Duration
+
+	// OnTokensRefreshed, if set, is called with the new tokens whenever a token grant succeeds
+	OnTokensRefreshed func(accessToken, refreshToken string)
 }
 
 type OAuthClient interface {
@@ -36,13 +52,21 @@ type OAuthClient interface {
 
 // Do submits requests with bearer token authorization, using the AccessToken as the bearer token.
 //
-// Will automatically refresh the AccessToken and retry the request if the token has expired.
+// Will refresh the AccessToken before submitting the request if it expires within RefreshSkew,
+// and refresh it and retry the request if the server reports that the token has expired.
+// Parallel callers share a single refresh.
 func (a *OAuthStrategy) Do(req *http.Request) (*http.Response, error) {
 	if err := a.Login(); err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+a.AccessToken())
+	accessToken := a.AccessToken()
+	if expiry, ok := tokenExpiry(accessToken); ok && a.RefreshSkew >= 0 && time.Until(expiry) < a.refreshSkew() {
+		// A token which has not expired yet can still be used if refreshing it failed
+		if err := a.refreshUnlessChanged(accessToken); err != nil && !time.Now().Before(expiry) {
+			return nil, err
+		}
+	}
 
 	clone, err := cloneRequest(req)
 
@@ -50,7 +74,8 @@ func (a *OAuthStrategy) Do(req *http.Request) (*http.Response, error) {
 		return nil, errors.New("failed to clone request body: " + err.Error())
 	}
 
-	req.Header.Set("Authorization", "Bearer "+a.AccessToken())
+	accessToken = a.AccessToken()
+	req.Header.Set("Authorization", "Bearer "+accessToken)
 	resp, err := a.ApiClient.Do(req)
 
 	if err != nil {
@@ -63,11 +88,11 @@ func (a *OAuthStrategy) Do(req *http.Request) (*http.Response, error) {
 		return resp, err
 	}
 
-	if err := a.Refresh(); err != nil {
+	if err := a.refreshUnlessChanged(accessToken); err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+a.AccessToken())
+	clone.Header.Set("Authorization", "Bearer "+a.AccessToken())
 	return a.ApiClient.Do(clone)
 }
 
@@ -76,6 +101,26 @@ func (a *OAuthStrategy) Do(req *http.Request) (*http.Response, error) {
 // If RefreshToken is available, a refresh token grant will be used, otherwise
 // client credential grant will be used.
 func (a *OAuthStrategy) Refresh() error {
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+
+	return a.refresh()
+}
+
+// refreshUnlessChanged refreshes the given access token, unless another caller already replaced
+// it while waiting for the refresh lock
+func (a *OAuthStrategy) refreshUnlessChanged(accessToken string) error {
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+
+	if a.AccessToken() != accessToken {
+		return nil
+	}
+
+	return a.refresh()
+}
+
+func (a *OAuthStrategy) refresh() error {
 	refreshToken := a.RefreshToken()
 
 	if refreshToken == "" {
@@ -98,11 +143,27 @@ func (a *OAuthStrategy) Refresh() error {
 		return err
 	}
 
-	a.SetTokens(accessToken, refreshToken)
+	a.updateTokens(accessToken, refreshToken)
 
 	return nil
 }
 
+func (a *OAuthStrategy) refreshSkew() time.Duration {
+	if a.RefreshSkew == 0 {
+		return DefaultRefreshSkew
+	}
+	return a.RefreshSkew
+}
+
+// updateTokens sets tokens returned by a token grant and reports them to OnTokensRefreshed
+func (a *OAuthStrategy) updateTokens(accessToken, refreshToken string) {
+	a.SetTokens(accessToken, refreshToken)
+
+	if a.OnTokensRefreshed != nil {
+		a.OnTokensRefreshed(accessToken, refreshToken)
+	}
+}
+
 // Logout will send a revoke token request
 //
 // On success, the AccessToken and RefreshToken will be empty
@@ -131,13 +192,25 @@ func (a *OAuthStrategy) Logout() error {
 //
 // Login will be a no-op if the AccessToken is not empty when invoked.
 func (a *OAuthStrategy) Login() error {
-	if a.AccessToken() != "" && a.AccessToken() != "revoked" {
+	if a.hasAccessToken() {
+		return nil
+	}
+
+	a.refreshMu.Lock()
+	defer a.refreshMu.Unlock()
+
+	if a.hasAccessToken() {
 		return nil
 	}
 
 	return a.requestToken()
 }
 
+func (a *OAuthStrategy) hasAccessToken() bool {
+	accessToken := a.AccessToken()
+	return accessToken != "" && accessToken != "revoked"
+}
+
 func (a *OAuthStrategy) requestToken() error {
 	var accessToken string
 	var refreshToken string
@@ -153,7 +226,7 @@ func (a *OAuthStrategy) requestToken() error {
 		return fmt.Errorf(fmt.Sprintf("Error getting token. Your token may have expired and could not be refreshed. Please try logging in again. [%s]", err.Error()))
 	}
 
-	a.SetTokens(accessToken, refreshToken)
+	a.updateTokens(accessToken, refreshToken)
 
 	return nil
 }
@@ -184,6 +257,17 @@ func (a *OAuthStrategy) SetTokens(access, refresh string) {
 	a.refreshToken = refresh
 }
 
+// tokenExpiry returns the time in the exp claim of a JWT access token. The token is not
+// verified, the expiry only tells when to refresh it.
+func tokenExpiry(accessToken string) (time.Time, bool) {
+	claims, err := oidc.DecodeToken(accessToken)
+	if err != nil || claims.ExpiresAt.IsZero() {
+		return time.Time{}, false
+	}
+
+	return claims.ExpiresAt, true
+}
+
 func tokenExpired(resp *http.Response) (bool, error) {
 	if resp.StatusCode < 400 {
 		return false, nil
@@ -217,6 +301,17 @@ func cloneRequest(r *http.Request) (*http.Request, error) {
 
 	r2 := new(http.Request)
 	*r2 = *r
+	r2.Header = r.Header.Clone()
+
+	// replay the body without buffering it when possible
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r2.Body = body
+		return r2, nil
+	}
 
 	// deep copy the body
 	buf, err := ioutil.ReadAll(r.Body)
@@ -1,11 +1,15 @@
 package auth_test
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"time"
 
 	"code.cloudfoundry.org/credhub-cli/credhub/auth"
 
@@ -285,6 +289,133 @@ var _ = Describe("OAuthStrategy", func() {
 
 	})
 
+	Context("Do() with a JWT access token", func() {
+		var (
+			apiServer      *httptest.Server
+			authHeaders    chan string
+			oauth          *auth.OAuthStrategy
+			expiringToken  string
+			refreshedToken string
+		)
+
+		BeforeEach(func() {
+			authHeaders = make(chan string, 10)
+			apiServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authHeaders <- r.Header.Get("Authorization")
+				w.Write([]byte("success"))
+			}))
+
+			expiringToken = jwtExpiringIn(10 * time.Second)
+			refreshedToken = jwtExpiringIn(time.Hour)
+			mockUaaClient.NewAccessToken = refreshedToken
+			mockUaaClient.NewRefreshToken = "new-refresh-token"
+
+			oauth = &auth.OAuthStrategy{
+				ApiClient:   http.DefaultClient,
+				OAuthClient: mockUaaClient,
+			}
+		})
+
+		AfterEach(func() {
+			apiServer.Close()
+		})
+
+		It("refreshes the token before the request when it expires within the skew", func() {
+			oauth.SetTokens(expiringToken, "old-refresh-token")
+			request, _ := http.NewRequest("GET", apiServer.URL, nil)
+
+			_, err := oauth.Do(request)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockUaaClient.RefreshToken).To(Equal("old-refresh-token"))
+			Expect(<-authHeaders).To(Equal("Bearer " + refreshedToken))
+			Expect(oauth.RefreshToken()).To(Equal("new-refresh-token"))
+		})
+
+		It("does not refresh tokens expiring after the skew", func() {
+			oauth.RefreshSkew = 5 * time.Second
+			oauth.SetTokens(expiringToken, "old-refresh-token")
+			request, _ := http.NewRequest("GET", apiServer.URL, nil)
+
+			_, err := oauth.Do(request)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockUaaClient.Grants).To(Equal(0))
+			Expect(<-authHeaders).To(Equal("Bearer " + expiringToken))
+		})
+
+		It("does not refresh proactively with a negative skew", func() {
+			oauth.RefreshSkew = -1
+			oauth.SetTokens(jwtExpiringIn(-time.Minute), "old-refresh-token")
+			request, _ := http.NewRequest("GET", apiServer.URL, nil)
+
+			_, err := oauth.Do(request)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mockUaaClient.Grants).To(Equal(0))
+		})
+
+		It("uses the token which has not expired yet when refreshing fails", func() {
+			mockUaaClient.Error = errors.New("failed to refresh")
+			oauth.SetTokens(expiringToken, "old-refresh-token")
+			request, _ := http.NewRequest("GET", apiServer.URL, nil)
+
+			_, err := oauth.Do(request)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(<-authHeaders).To(Equal("Bearer " + expiringToken))
+		})
+
+		It("returns the error when refreshing an expired token fails", func() {
+			mockUaaClient.Error = errors.New("failed to refresh")
+			oauth.SetTokens(jwtExpiringIn(-time.Minute), "old-refresh-token")
+			request, _ := http.NewRequest("GET", apiServer.URL, nil)
+
+			_, err := oauth.Do(request)
+
+			Expect(err).To(MatchError("failed to refresh"))
+			Expect(authHeaders).To(BeEmpty())
+		})
+
+		It("refreshes the token once for parallel requests", func() {
+			mockUaaClient.Delay = 50 * time.Millisecond
+			oauth.SetTokens(expiringToken, "old-refresh-token")
+
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					request, _ := http.NewRequest("GET", apiServer.URL, nil)
+					_, err := oauth.Do(request)
+					Expect(err).ToNot(HaveOccurred())
+				}()
+			}
+			wg.Wait()
+
+			Expect(mockUaaClient.Grants).To(Equal(1))
+			for i := 0; i < 5; i++ {
+				Expect(<-authHeaders).To(Equal("Bearer " + refreshedToken))
+			}
+		})
+
+		It("reports refreshed tokens", func() {
+			var accessToken, refreshToken string
+			oauth.OnTokensRefreshed = func(access, refresh string) {
+				accessToken, refreshToken = access, refresh
+			}
+			oauth.SetTokens(expiringToken, "old-refresh-token")
+			request, _ := http.NewRequest("GET", apiServer.URL, nil)
+
+			_, err := oauth.Do(request)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(accessToken).To(Equal(refreshedToken))
+			Expect(refreshToken).To(Equal("new-refresh-token"))
+		})
+	})
+
 	Context("Refresh()", func() {
 		BeforeEach(func() {
 			mockUaaClient.NewAccessToken = "new-access-token"
@@ -573,3 +704,9 @@ type errorReader struct {
 func (r *errorReader) Read(b []byte) (n int, err error) {
 	return 0, errors.New("error reading")
 }
+
+// jwtExpiringIn returns an unsigned JWT whose exp claim is the given duration from now
+func jwtExpiringIn(d time.Duration) string {
+	payload := fmt.Sprintf(`{"jti":"some-jti","exp":%d}`, time.Now().Add(d).Unix())
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+}
@@ -0,0 +1,160 @@
+package oidc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Client makes requests to the endpoints of the OpenID Connect provider described by Provider
+type Client struct {
+	Provider *ProviderMetadata
+	Client   *http.Client
+
+	// KeyRefetchInterval is the minimum time between fetches of the key set for tokens signed
+	// with an unknown key. It defaults to DefaultKeyRefetchInterval.
+	KeyRefetchInterval time.Duration
+
+	mu   sync.Mutex // guards keys
+	keys *keySet
+}
+
+type token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+}
+
+type responseError struct {
+	Name        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *responseError) Error() string {
+	if e.Description == "" {
+		return e.Name
+	}
+
+	return fmt.Sprintf("%s %s", e.Name, e.Description)
+}
+
+// ClientCredentialGrant requests a token using client_credentials grant type
+func (c *Client) ClientCredentialGrant(clientId, clientSecret string) (string, error) {
+	values := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+	}
+
+	token, err := c.tokenGrantRequest(values)
+
+	return token.AccessToken, err
+}
+
+// PasswordGrant requests an access token and refresh token using password grant type
+func (c *Client) PasswordGrant(clientId, clientSecret, username, password string) (string, string, error) {
+	values := url.Values{
+		"grant_type":    {"password"},
+		"username":      {username},
+		"password":      {password},
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+	}
+
+	token, err := c.tokenGrantRequest(values)
+
+	return token.AccessToken, token.RefreshToken, err
+}
+
+// RefreshTokenGrant requests a new access token and refresh token using refresh_token grant type.
+// Providers that do not rotate refresh tokens return no new refresh token, in which case the
+// given refresh token is returned.
+func (c *Client) RefreshTokenGrant(clientId, clientSecret, refreshToken string) (string, string, error) {
+	values := url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+		"refresh_token": {refreshToken},
+	}
+
+	token, err := c.tokenGrantRequest(values)
+	if err == nil && token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	return token.AccessToken, token.RefreshToken, err
+}
+
+// RevokeToken revokes the given access token at the revocation endpoint. Tokens of providers
+// without a revocation endpoint expire on their own, so revoking is a no-op for them.
+// See: https://tools.ietf.org/html/rfc7009
+func (c *Client) RevokeToken(accessToken string) error {
+	if c.Provider.RevocationEndpoint == "" {
+		return nil
+	}
+
+	values := url.Values{
+		"token":           {accessToken},
+		"token_type_hint": {"access_token"},
+	}
+
+	response, err := c.postForm(c.Provider.RevocationEndpoint, values)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		body, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("Received HTTP %d error while revoking token from auth server: %q", response.StatusCode, body)
+	}
+
+	return nil
+}
+
+func (c *Client) tokenGrantRequest(values url.Values) (token, error) {
+	var t token
+
+	response, err := c.postForm(c.Provider.TokenEndpoint, values)
+	if err != nil {
+		return t, err
+	}
+	defer response.Body.Close()
+	defer io.Copy(ioutil.Discard, response.Body)
+
+	decoder := json.NewDecoder(response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		err = decoder.Decode(&t)
+		return t, err
+	}
+
+	respErr := responseError{}
+
+	if err := decoder.Decode(&respErr); err != nil {
+		return t, fmt.Errorf("Received HTTP %d error from the token endpoint", response.StatusCode)
+	}
+
+	return t, &respErr
+}
+
+func (c *Client) postForm(endpoint string, values url.Values) (*http.Response, error) {
+	request, err := http.NewRequest("POST", endpoint, bytes.NewBufferString(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Add("Accept", "application/json")
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.Client.Do(request)
+}
@@ -0,0 +1,169 @@
+package oidc_test
+
+import (
+	"net/http"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub/auth/oidc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		provider *Server
+		client   *Client
+	)
+
+	BeforeEach(func() {
+		provider = NewServer()
+		client = &Client{
+			Provider: &ProviderMetadata{
+				Issuer:             provider.URL(),
+				TokenEndpoint:      provider.URL() + "/token",
+				RevocationEndpoint: provider.URL() + "/revoke",
+			},
+			Client: http.DefaultClient,
+		}
+	})
+
+	AfterEach(func() {
+		provider.Close()
+	})
+
+	Context("Discover()", func() {
+		It("reads the provider metadata", func() {
+			provider.AppendHandlers(CombineHandlers(
+				VerifyRequest("GET", "/.well-known/openid-configuration"),
+				RespondWith(http.StatusOK, `{
+					"issuer": "`+provider.URL()+`",
+					"token_endpoint": "`+provider.URL()+`/token",
+					"jwks_uri": "`+provider.URL()+`/keys",
+					"grant_types_supported": ["password", "refresh_token"]
+				}`),
+			))
+
+			metadata, err := Discover(http.DefaultClient, provider.URL())
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.Issuer).To(Equal(provider.URL()))
+			Expect(metadata.TokenEndpoint).To(Equal(provider.URL() + "/token"))
+			Expect(metadata.JWKSURI).To(Equal(provider.URL() + "/keys"))
+			Expect(metadata.GrantTypesSupported).To(Equal([]string{"password", "refresh_token"}))
+		})
+
+		It("accepts issuers ending in a slash", func() {
+			provider.AppendHandlers(CombineHandlers(
+				VerifyRequest("GET", "/.well-known/openid-configuration"),
+				RespondWith(http.StatusOK, `{"issuer": "`+provider.URL()+`/", "token_endpoint": "`+provider.URL()+`/oauth/token"}`),
+			))
+
+			metadata, err := Discover(http.DefaultClient, provider.URL()+"/")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.Issuer).To(Equal(provider.URL() + "/"))
+		})
+
+		It("rejects metadata of another issuer", func() {
+			provider.AppendHandlers(RespondWith(http.StatusOK, `{"issuer": "https://other.example.com", "token_endpoint": "https://other.example.com/token"}`))
+
+			_, err := Discover(http.DefaultClient, provider.URL())
+
+			Expect(err).To(MatchError("the OpenID Connect configuration of " + provider.URL() + " belongs to the issuer https://other.example.com"))
+		})
+
+		It("returns an error when the metadata cannot be fetched", func() {
+			provider.AppendHandlers(RespondWith(http.StatusNotFound, ""))
+
+			_, err := Discover(http.DefaultClient, provider.URL())
+
+			Expect(err).To(MatchError(ContainSubstring("HTTP 404")))
+		})
+	})
+
+	Context("ClientCredentialGrant()", func() {
+		It("requests a token from the discovered token endpoint", func() {
+			provider.AppendHandlers(CombineHandlers(
+				VerifyRequest("POST", "/token"),
+				VerifyContentType("application/x-www-form-urlencoded"),
+				VerifyBody([]byte("client_id=client-id&client_secret=client-secret&grant_type=client_credentials")),
+				RespondWith(http.StatusOK, `{"access_token": "access-token", "token_type": "bearer"}`),
+			))
+
+			accessToken, err := client.ClientCredentialGrant("client-id", "client-secret")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accessToken).To(Equal("access-token"))
+		})
+	})
+
+	Context("PasswordGrant()", func() {
+		It("requests an access token and refresh token", func() {
+			provider.AppendHandlers(CombineHandlers(
+				VerifyRequest("POST", "/token"),
+				VerifyBody([]byte("client_id=client-id&client_secret=&grant_type=password&password=password&username=username")),
+				RespondWith(http.StatusOK, `{"access_token": "access-token", "refresh_token": "refresh-token", "token_type": "bearer"}`),
+			))
+
+			accessToken, refreshToken, err := client.PasswordGrant("client-id", "", "username", "password")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accessToken).To(Equal("access-token"))
+			Expect(refreshToken).To(Equal("refresh-token"))
+		})
+
+		It("returns the error of the provider", func() {
+			provider.AppendHandlers(RespondWith(http.StatusBadRequest, `{"error": "invalid_grant", "error_description": "Invalid user credentials"}`))
+
+			_, _, err := client.PasswordGrant("client-id", "", "username", "wrong")
+
+			Expect(err).To(MatchError("invalid_grant Invalid user credentials"))
+		})
+	})
+
+	Context("RefreshTokenGrant()", func() {
+		It("requests a new access token", func() {
+			provider.AppendHandlers(CombineHandlers(
+				VerifyRequest("POST", "/token"),
+				VerifyBody([]byte("client_id=client-id&client_secret=&grant_type=refresh_token&refresh_token=refresh-token")),
+				RespondWith(http.StatusOK, `{"access_token": "new-access-token", "refresh_token": "new-refresh-token", "token_type": "bearer"}`),
+			))
+
+			accessToken, refreshToken, err := client.RefreshTokenGrant("client-id", "", "refresh-token")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accessToken).To(Equal("new-access-token"))
+			Expect(refreshToken).To(Equal("new-refresh-token"))
+		})
+
+		It("keeps the refresh token when the provider does not rotate it", func() {
+			provider.AppendHandlers(RespondWith(http.StatusOK, `{"access_token": "new-access-token", "token_type": "bearer"}`))
+
+			_, refreshToken, err := client.RefreshTokenGrant("client-id", "", "refresh-token")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(refreshToken).To(Equal("refresh-token"))
+		})
+	})
+
+	Context("RevokeToken()", func() {
+		It("revokes the token at the revocation endpoint", func() {
+			provider.AppendHandlers(CombineHandlers(
+				VerifyRequest("POST", "/revoke"),
+				VerifyBody([]byte("token=access-token&token_type_hint=access_token")),
+				RespondWith(http.StatusOK, ""),
+			))
+
+			Expect(client.RevokeToken("access-token")).To(Succeed())
+			Expect(provider.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("does nothing without a revocation endpoint", func() {
+			client.Provider.RevocationEndpoint = ""
+
+			Expect(client.RevokeToken("access-token")).To(Succeed())
+			Expect(provider.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+})
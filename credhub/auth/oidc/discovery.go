@@ -0,0 +1,62 @@
+// OpenID Connect client for token grants, revocation and token inspection
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// DiscoveryPath is appended to the issuer to fetch the provider metadata
+const DiscoveryPath = "/.well-known/openid-configuration"
+
+// ProviderMetadata captures the provider metadata returned by the discovery endpoint of an OpenID
+// Connect provider. This fields are not exhaustive and can be added to over time.
+// See: https://openid.net/specs/openid-connect-discovery-1_0.html#ProviderMetadata
+type ProviderMetadata struct {
+	Issuer                      string   `json:"issuer"`
+	AuthorizationEndpoint       string   `json:"authorization_endpoint"`
+	TokenEndpoint               string   `json:"token_endpoint"`
+	RevocationEndpoint          string   `json:"revocation_endpoint"`
+	DeviceAuthorizationEndpoint string   `json:"device_authorization_endpoint"`
+	JWKSURI                     string   `json:"jwks_uri"`
+	GrantTypesSupported         []string `json:"grant_types_supported"`
+}
+
+// Discover fetches the provider metadata of the issuer. The issuer in the metadata has to match
+// the configured issuer exactly, including a trailing slash.
+func Discover(client *http.Client, issuer string) (*ProviderMetadata, error) {
+	request, err := http.NewRequest("GET", strings.TrimSuffix(issuer, "/")+DiscoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Accept", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	defer io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch the OpenID Connect configuration of %s: HTTP %d", issuer, response.StatusCode)
+	}
+
+	var metadata ProviderMetadata
+	if err := json.NewDecoder(response.Body).Decode(&metadata); err != nil {
+		return nil, err
+	}
+
+	if metadata.Issuer != issuer {
+		return nil, fmt.Errorf("the OpenID Connect configuration of %s belongs to the issuer %s", issuer, metadata.Issuer)
+	}
+	if metadata.TokenEndpoint == "" {
+		return nil, fmt.Errorf("the OpenID Connect configuration of %s has no token endpoint", issuer)
+	}
+
+	return &metadata, nil
+}
@@ -0,0 +1,365 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrTokenExpired is returned by VerifyToken for tokens past their expiry
+var ErrTokenExpired = errors.New("the token has expired")
+
+// Claims are the claims of a verified access token
+type Claims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ClientID  string
+	Scopes    []string
+	ExpiresAt time.Time
+	IssuedAt  time.Time
+
+	// Raw holds all claims of the token, including the ones above
+	Raw map[string]interface{}
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type keySet struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// DefaultKeyRefetchInterval limits how often tokens signed with unknown keys fetch the key set
+const DefaultKeyRefetchInterval = time.Minute
+
+// VerifyToken checks the signature of a JWT access token against the keys published at the JWKS
+// URI of the provider and returns its claims. The token must be issued by the provider and must
+// not have expired.
+func (c *Client) VerifyToken(accessToken string) (*Claims, error) {
+	segments := strings.Split(accessToken, ".")
+	if len(segments) != 3 {
+		return nil, errors.New("the token is not a JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(segments[0], &header); err != nil {
+		return nil, fmt.Errorf("could not decode the token header: %v", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return nil, errors.New("could not base64 decode the token signature")
+	}
+
+	key, err := c.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(header.Alg, key, []byte(segments[0]+"."+segments[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := decodeSegment(segments[1], &raw); err != nil {
+		return nil, fmt.Errorf("could not decode the token payload: %v", err)
+	}
+
+	claims := parseClaims(raw)
+	if claims.Issuer != c.Provider.Issuer {
+		return nil, fmt.Errorf("the token was issued by %s instead of %s", claims.Issuer, c.Provider.Issuer)
+	}
+	if !claims.ExpiresAt.IsZero() && time.Now().After(claims.ExpiresAt) {
+		return claims, ErrTokenExpired
+	}
+
+	return claims, nil
+}
+
+// DecodeToken returns the claims of a JWT access token without verifying its signature, issuer
+// or expiry. The claims must not be trusted for anything but displaying them.
+func DecodeToken(accessToken string) (*Claims, error) {
+	segments := strings.Split(accessToken, ".")
+	if len(segments) != 3 {
+		return nil, errors.New("the token is not a JWT")
+	}
+
+	var raw map[string]interface{}
+	if err := decodeSegment(segments[1], &raw); err != nil {
+		return nil, fmt.Errorf("could not decode the token payload: %v", err)
+	}
+
+	return parseClaims(raw), nil
+}
+
+// key returns the key with the given ID. The key set is fetched again when the key is unknown,
+// in case the provider rotated its keys, but at most once per KeyRefetchInterval.
+func (c *Client) key(kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	interval := c.KeyRefetchInterval
+	if interval == 0 {
+		interval = DefaultKeyRefetchInterval
+	}
+
+	if c.keys == nil || (!c.keys.has(kid) && time.Since(c.keys.fetchedAt) >= interval) {
+		keys, err := c.fetchKeys()
+		if err != nil {
+			return nil, err
+		}
+		c.keys = keys
+	}
+
+	if key, ok := c.keys.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(c.keys.keys) == 1 {
+		for _, key := range c.keys.keys {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the key %q of the token is not published by %s", kid, c.Provider.JWKSURI)
+}
+
+// has reports whether the key set contains the key with the given ID
+func (k *keySet) has(kid string) bool {
+	_, ok := k.keys[kid]
+	return ok || (kid == "" && len(k.keys) == 1)
+}
+
+func (c *Client) fetchKeys() (*keySet, error) {
+	if c.Provider.JWKSURI == "" {
+		return nil, errors.New("the OpenID Connect provider does not publish a JWKS URI")
+	}
+
+	request, err := http.NewRequest("GET", c.Provider.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Accept", "application/json")
+
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	defer io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch the keys of the OpenID Connect provider: HTTP %d", response.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&jwks); err != nil {
+		return nil, err
+	}
+
+	keys := &keySet{keys: map[string]crypto.PublicKey{}, fetchedAt: time.Now()}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys.keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	hasher := hash.New()
+	hasher.Write(signed)
+	digest := hasher.Sum(nil)
+
+	invalid := errors.New("the token signature is invalid")
+
+	switch alg[:2] {
+	case "RS", "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return invalid
+		}
+		if alg[0] == 'P' {
+			if rsa.VerifyPSS(rsaKey, hash, digest, signature, nil) != nil {
+				return invalid
+			}
+			return nil
+		}
+		if rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature) != nil {
+			return invalid
+		}
+		return nil
+	case "ES":
+		// the signature is r and s of the fixed size of the curve of the algorithm
+		curve, size := ecdsaCurve(hash)
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != curve || len(signature) != 2*size {
+			return invalid
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return invalid
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+}
+
+// ecdsaCurve returns the curve of the ES algorithm using the hash, and the size of its coordinates
+func ecdsaCurve(hash crypto.Hash) (elliptic.Curve, int) {
+	switch hash {
+	case crypto.SHA256:
+		return elliptic.P256(), 32
+	case crypto.SHA384:
+		return elliptic.P384(), 48
+	default:
+		return elliptic.P521(), 66
+	}
+}
+
+func parseClaims(raw map[string]interface{}) *Claims {
+	claims := &Claims{Raw: raw}
+	claims.Issuer, _ = raw["iss"].(string)
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Audience = stringList(raw["aud"])
+	claims.Scopes = stringList(raw["scope"])
+	claims.ExpiresAt = unixTime(raw["exp"])
+	claims.IssuedAt = unixTime(raw["iat"])
+
+	for _, claim := range []string{"client_id", "azp", "cid"} {
+		if clientID, ok := raw[claim].(string); ok {
+			claims.ClientID = clientID
+			break
+		}
+	}
+
+	return claims
+}
+
+// stringList returns a claim which is either a space separated string or an array of strings
+func stringList(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+func unixTime(claim interface{}) time.Time {
+	if seconds, ok := claim.(float64); ok {
+		return time.Unix(int64(seconds), 0)
+	}
+	return time.Time{}
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
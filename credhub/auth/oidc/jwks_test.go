@@ -0,0 +1,231 @@
+package oidc_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub/auth/oidc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("VerifyToken()", func() {
+	var (
+		provider *Server
+		client   *Client
+		rsaKey   *rsa.PrivateKey
+		ecKey    *ecdsa.PrivateKey
+	)
+
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		Expect(err).NotTo(HaveOccurred())
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	sign := func(alg, kid string, claims map[string]interface{}) string {
+		signed := encode(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encode(claims)
+		digest := sha256.Sum256([]byte(signed))
+
+		var signature []byte
+		if alg == "ES256" {
+			r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+			Expect(err).NotTo(HaveOccurred())
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		} else {
+			var err error
+			signature, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	jwks := func() string {
+		return `{"keys": [
+			{"kty": "RSA", "kid": "rsa-key", "use": "sig", "n": "` + base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()) + `", "e": "` + base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()) + `"},
+			{"kty": "EC", "kid": "ec-key", "crv": "P-256", "x": "` + base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))) + `", "y": "` + base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))) + `"},
+			{"kty": "RSA", "kid": "encryption-key", "use": "enc", "n": "AQAB", "e": "AQAB"}
+		]}`
+	}
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":       provider.URL(),
+			"sub":       "some-user",
+			"aud":       []string{"credhub"},
+			"scope":     "credhub.read credhub.write",
+			"client_id": "credhub_cli",
+			"iat":       time.Now().Unix(),
+			"exp":       time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		provider = NewServer()
+		provider.RouteToHandler("GET", "/keys", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(jwks()))
+		})
+		client = &Client{
+			Provider: &ProviderMetadata{Issuer: provider.URL(), JWKSURI: provider.URL() + "/keys"},
+			Client:   http.DefaultClient,
+		}
+	})
+
+	AfterEach(func() {
+		provider.Close()
+	})
+
+	It("returns the claims of tokens signed with an RSA key", func() {
+		claims, err := client.VerifyToken(sign("RS256", "rsa-key", validClaims()))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(claims.Issuer).To(Equal(provider.URL()))
+		Expect(claims.Subject).To(Equal("some-user"))
+		Expect(claims.Audience).To(Equal([]string{"credhub"}))
+		Expect(claims.Scopes).To(Equal([]string{"credhub.read", "credhub.write"}))
+		Expect(claims.ClientID).To(Equal("credhub_cli"))
+		Expect(claims.ExpiresAt).To(BeTemporally("~", time.Now().Add(time.Hour), time.Second))
+	})
+
+	It("returns the claims of tokens signed with an EC key", func() {
+		claims, err := client.VerifyToken(sign("ES256", "ec-key", validClaims()))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(claims.Subject).To(Equal("some-user"))
+	})
+
+	It("caches the keys", func() {
+		client.VerifyToken(sign("RS256", "rsa-key", validClaims()))
+		client.VerifyToken(sign("ES256", "ec-key", validClaims()))
+
+		Expect(provider.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("fetches the keys again for unknown key IDs once the refetch interval passed", func() {
+		client.KeyRefetchInterval = 10 * time.Millisecond
+		_, err := client.VerifyToken(sign("RS256", "rsa-key", validClaims()))
+		Expect(err).NotTo(HaveOccurred())
+		time.Sleep(20 * time.Millisecond)
+
+		_, err = client.VerifyToken(sign("RS256", "rotated-key", validClaims()))
+
+		Expect(err).To(MatchError(`the key "rotated-key" of the token is not published by ` + provider.URL() + "/keys"))
+		Expect(provider.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("does not fetch the keys again for unknown key IDs within the refetch interval", func() {
+		for i := 0; i < 3; i++ {
+			_, err := client.VerifyToken(sign("RS256", "unknown-key", validClaims()))
+			Expect(err).To(HaveOccurred())
+		}
+
+		Expect(provider.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("rejects EC signatures which do not have the size of the curve of the algorithm", func() {
+		token := sign("ES256", "ec-key", validClaims())
+		segments := strings.Split(token, ".")
+		signature, _ := base64.RawURLEncoding.DecodeString(segments[2])
+		padded := append(append([]byte{0}, signature[:32]...), append([]byte{0}, signature[32:]...)...)
+
+		_, err := client.VerifyToken(segments[0] + "." + segments[1] + "." + base64.RawURLEncoding.EncodeToString(padded))
+
+		Expect(err).To(MatchError("the token signature is invalid"))
+	})
+
+	It("rejects EC keys on another curve than the one of the algorithm", func() {
+		token := sign("ES256", "ec-key", validClaims())
+		segments := strings.Split(token, ".")
+		header := encode(map[string]string{"alg": "ES384", "kid": "ec-key", "typ": "JWT"})
+
+		_, err := client.VerifyToken(header + "." + segments[1] + "." + segments[2])
+
+		Expect(err).To(MatchError("the token signature is invalid"))
+	})
+
+	It("rejects tokens with an invalid signature", func() {
+		segments := strings.Split(sign("RS256", "rsa-key", validClaims()), ".")
+		claims := validClaims()
+		claims["sub"] = "admin"
+		segments[1] = encode(claims)
+
+		_, err := client.VerifyToken(strings.Join(segments, "."))
+
+		Expect(err).To(MatchError("the token signature is invalid"))
+	})
+
+	It("rejects tokens signed with a key of another type", func() {
+		segments := strings.Split(sign("ES256", "ec-key", validClaims()), ".")
+		segments[0] = encode(map[string]string{"alg": "ES256", "kid": "rsa-key", "typ": "JWT"})
+
+		_, err := client.VerifyToken(strings.Join(segments, "."))
+
+		Expect(err).To(MatchError("the token signature is invalid"))
+	})
+
+	It("rejects tokens of another issuer", func() {
+		claims := validClaims()
+		claims["iss"] = "https://other.example.com"
+
+		_, err := client.VerifyToken(sign("RS256", "rsa-key", claims))
+
+		Expect(err).To(MatchError("the token was issued by https://other.example.com instead of " + provider.URL()))
+	})
+
+	It("returns ErrTokenExpired with the claims of expired tokens", func() {
+		claims := validClaims()
+		claims["exp"] = time.Now().Add(-time.Minute).Unix()
+
+		verified, err := client.VerifyToken(sign("RS256", "rsa-key", claims))
+
+		Expect(err).To(Equal(ErrTokenExpired))
+		Expect(verified.Subject).To(Equal("some-user"))
+	})
+
+	It("rejects unsigned tokens", func() {
+		token := encode(map[string]string{"alg": "none"}) + "." + encode(validClaims()) + "."
+
+		_, err := client.VerifyToken(token)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("DecodeToken()", func() {
+	It("returns the claims without verifying the token", func() {
+		payload := base64.RawURLEncoding.EncodeToString([]byte(`{"iss": "https://issuer.example.com", "user_name": "some-user", "scope": ["credhub.read"], "exp": 1600000000}`))
+
+		claims, err := DecodeToken("eyJhbGciOiJSUzI1NiJ9." + payload + ".signature")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(claims.Issuer).To(Equal("https://issuer.example.com"))
+		Expect(claims.Scopes).To(Equal([]string{"credhub.read"}))
+		Expect(claims.ExpiresAt).To(Equal(time.Unix(1600000000, 0)))
+		Expect(claims.Raw["user_name"]).To(Equal("some-user"))
+	})
+
+	It("rejects tokens which are not JWTs", func() {
+		_, err := DecodeToken("2YotnFZFEjr1zCsicMWpAA")
+
+		Expect(err).To(MatchError("the token is not a JWT"))
+	})
+})
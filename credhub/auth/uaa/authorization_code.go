@@ -0,0 +1,136 @@
+package uaa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// AuthorizationCodeTimeout is how long AuthorizationCodeGrant waits for the browser to be
+// redirected to the loopback listener
+var AuthorizationCodeTimeout = 5 * time.Minute
+
+const callbackPath = "/callback"
+
+const callbackPage = `<!DOCTYPE html>
+<html><head><title>CredHub CLI</title></head>
+<body><p>%s You can close this window and return to the CredHub CLI.</p></body></html>
+`
+
+// ErrAuthorizationCodeTimeout is returned when the browser was not redirected back in time
+var ErrAuthorizationCodeTimeout = errors.New("timed out waiting for the login to complete in the browser")
+
+type authorizationCallback struct {
+	code string
+	err  error
+}
+
+// AuthorizationCodeGrant requests an access token and refresh token using the authorization_code
+// grant type with PKCE. It listens for the redirect on a loopback address and calls open with the
+// URL the user has to visit in a browser to log in.
+// See: https://tools.ietf.org/html/rfc7636 and https://tools.ietf.org/html/rfc8252#section-7.3
+func (u *Client) AuthorizationCodeGrant(clientId, clientSecret string, open func(authorizeURL string) error) (string, string, error) {
+	verifier, err := randomURLSafeString(32)
+	if err != nil {
+		return "", "", err
+	}
+	state, err := randomURLSafeString(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", "", err
+	}
+	redirectURI := "http://" + listener.Addr().String() + callbackPath
+
+	callbacks := make(chan authorizationCallback, 1)
+	server := &http.Server{Handler: callbackHandler(state, callbacks)}
+	go server.Serve(listener)
+	defer server.Close()
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {clientId},
+		"redirect_uri":          {redirectURI},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if err := open(u.AuthURL + "/oauth/authorize?" + query.Encode()); err != nil {
+		return "", "", err
+	}
+
+	var callback authorizationCallback
+	select {
+	case callback = <-callbacks:
+	case <-time.After(AuthorizationCodeTimeout):
+		return "", "", ErrAuthorizationCodeTimeout
+	}
+	if callback.err != nil {
+		return "", "", callback.err
+	}
+
+	values := url.Values{
+		"grant_type":    {"authorization_code"},
+		"response_type": {"token"},
+		"code":          {callback.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+	}
+
+	token, err := u.tokenGrantRequest(values)
+
+	return token.AccessToken, token.RefreshToken, err
+}
+
+func callbackHandler(state string, callbacks chan<- authorizationCallback) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		var callback authorizationCallback
+		switch {
+		case query.Get("state") != state:
+			callback.err = errors.New("the authorization response did not match the login request")
+		case query.Get("error") != "":
+			callback.err = &responseError{Name: query.Get("error"), Description: query.Get("error_description")}
+		case query.Get("code") == "":
+			callback.err = errors.New("the authorization response did not contain a code")
+		default:
+			callback.code = query.Get("code")
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if callback.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, callbackPage, "Login failed.")
+		} else {
+			fmt.Fprintf(w, callbackPage, "Login successful.")
+		}
+
+		select {
+		case callbacks <- callback:
+		default:
+		}
+	})
+	return mux
+}
+
+func randomURLSafeString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
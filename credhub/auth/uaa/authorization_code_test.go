@@ -0,0 +1,129 @@
+package uaa_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub/auth/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuthorizationCodeGrant()", func() {
+	var (
+		uaaServer    *httptest.Server
+		client       Client
+		codeVerifier string
+		redirectURI  string
+	)
+
+	BeforeEach(func() {
+		codeVerifier = ""
+		uaaServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+
+			Expect(r.URL.Path).To(Equal("/oauth/token"))
+			Expect(r.PostForm.Get("grant_type")).To(Equal("authorization_code"))
+			Expect(r.PostForm.Get("code")).To(Equal("some-code"))
+			Expect(r.PostForm.Get("redirect_uri")).To(Equal(redirectURI))
+			Expect(r.PostForm.Get("client_id")).To(Equal("client-id"))
+			codeVerifier = r.PostForm.Get("code_verifier")
+
+			w.Write([]byte(`{"access_token": "access-token", "refresh_token": "refresh-token", "token_type": "bearer"}`))
+		}))
+		client = Client{AuthURL: uaaServer.URL, Client: http.DefaultClient}
+	})
+
+	AfterEach(func() {
+		uaaServer.Close()
+	})
+
+	// redirect follows the authorize URL as a browser would after the user logged in
+	redirect := func(authorizeURL string, query func(url.Values) url.Values) *http.Response {
+		parsed, err := url.Parse(authorizeURL)
+		Expect(err).NotTo(HaveOccurred())
+		params := parsed.Query()
+		redirectURI = params.Get("redirect_uri")
+
+		callback := url.Values{"state": {params.Get("state")}, "code": {"some-code"}}
+		resp, err := http.Get(redirectURI + "?" + query(callback).Encode())
+		Expect(err).NotTo(HaveOccurred())
+		return resp
+	}
+
+	It("exchanges the code returned to the loopback listener with a PKCE verifier", func() {
+		var challenge string
+		accessToken, refreshToken, err := client.AuthorizationCodeGrant("client-id", "", func(authorizeURL string) error {
+			Expect(authorizeURL).To(HavePrefix(uaaServer.URL + "/oauth/authorize?"))
+			parsed, _ := url.Parse(authorizeURL)
+			Expect(parsed.Query().Get("response_type")).To(Equal("code"))
+			Expect(parsed.Query().Get("client_id")).To(Equal("client-id"))
+			Expect(parsed.Query().Get("code_challenge_method")).To(Equal("S256"))
+			Expect(parsed.Query().Get("redirect_uri")).To(MatchRegexp(`^http://127\.0\.0\.1:\d+/callback$`))
+			challenge = parsed.Query().Get("code_challenge")
+
+			resp := redirect(authorizeURL, func(v url.Values) url.Values { return v })
+			body, _ := ioutil.ReadAll(resp.Body)
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(string(body)).To(ContainSubstring("Login successful."))
+			return nil
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(accessToken).To(Equal("access-token"))
+		Expect(refreshToken).To(Equal("refresh-token"))
+
+		sum := sha256.Sum256([]byte(codeVerifier))
+		Expect(base64.RawURLEncoding.EncodeToString(sum[:])).To(Equal(challenge))
+	})
+
+	It("returns the error of the authorization response", func() {
+		_, _, err := client.AuthorizationCodeGrant("client-id", "", func(authorizeURL string) error {
+			resp := redirect(authorizeURL, func(v url.Values) url.Values {
+				v.Del("code")
+				v.Set("error", "access_denied")
+				v.Set("error_description", "user denied")
+				return v
+			})
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+			return nil
+		})
+
+		Expect(err).To(MatchError("access_denied user denied"))
+	})
+
+	It("rejects responses for a different login request", func() {
+		_, _, err := client.AuthorizationCodeGrant("client-id", "", func(authorizeURL string) error {
+			redirect(authorizeURL, func(v url.Values) url.Values {
+				v.Set("state", "other-state")
+				return v
+			})
+			return nil
+		})
+
+		Expect(err).To(MatchError(ContainSubstring("did not match the login request")))
+	})
+
+	It("times out when the browser is not redirected", func() {
+		timeout := AuthorizationCodeTimeout
+		AuthorizationCodeTimeout = 10 * time.Millisecond
+		defer func() { AuthorizationCodeTimeout = timeout }()
+
+		_, _, err := client.AuthorizationCodeGrant("client-id", "", func(string) error { return nil })
+
+		Expect(err).To(Equal(ErrAuthorizationCodeTimeout))
+	})
+
+	It("returns the error of open", func() {
+		_, _, err := client.AuthorizationCodeGrant("client-id", "", func(string) error { return errors.New("no browser") })
+
+		Expect(err).To(MatchError("no browser"))
+	})
+})
@@ -0,0 +1,133 @@
+package uaa
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DeviceAuthorizationPath is the endpoint of the auth server issuing device codes
+const DeviceAuthorizationPath = "/oauth/device_authorization"
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// defaultDeviceInterval is the polling interval to use when the auth server does not return one.
+// See: https://tools.ietf.org/html/rfc8628#section-3.2
+const defaultDeviceInterval = 5 * time.Second
+
+// ErrDeviceCodeExpired is returned when the user did not authorize the device before the device
+// code expired
+var ErrDeviceCodeExpired = errors.New("the device code expired before the login was approved")
+
+// ErrDeviceAccessDenied is returned when the user denied the authorization request
+var ErrDeviceAccessDenied = errors.New("the login was denied")
+
+// DeviceAuthorization captures the response of a device authorization request. The user has to
+// visit VerificationURI and enter UserCode to approve the login.
+// See: https://tools.ietf.org/html/rfc8628#section-3.2
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// DeviceAuthorization requests a device code and a user code for the device authorization grant
+func (u *Client) DeviceAuthorization(clientId, clientSecret string, scopes ...string) (*DeviceAuthorization, error) {
+	values := url.Values{
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+	}
+	if len(scopes) > 0 {
+		values.Set("scope", strings.Join(scopes, " "))
+	}
+
+	request, err := http.NewRequest("POST", u.AuthURL+DeviceAuthorizationPath, bytes.NewBufferString(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Add("Accept", "application/json")
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := u.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	defer io.Copy(ioutil.Discard, response.Body)
+
+	decoder := json.NewDecoder(response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		respErr := responseError{}
+		if err := decoder.Decode(&respErr); err != nil {
+			return nil, err
+		}
+		return nil, &respErr
+	}
+
+	var authorization DeviceAuthorization
+	if err := decoder.Decode(&authorization); err != nil {
+		return nil, err
+	}
+
+	return &authorization, nil
+}
+
+// DeviceCodeGrant polls for an access token and refresh token using the device_code grant type
+// until the user approved or denied the device authorization, or the device code expired
+func (u *Client) DeviceCodeGrant(clientId, clientSecret string, authorization *DeviceAuthorization) (string, string, error) {
+	values := url.Values{
+		"grant_type":    {deviceCodeGrantType},
+		"response_type": {"token"},
+		"device_code":   {authorization.DeviceCode},
+		"client_id":     {clientId},
+		"client_secret": {clientSecret},
+	}
+
+	interval := time.Duration(authorization.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDeviceInterval
+	}
+
+	var expires <-chan time.Time
+	if authorization.ExpiresIn > 0 {
+		expires = time.After(time.Duration(authorization.ExpiresIn) * time.Second)
+	}
+
+	for {
+		select {
+		case <-expires:
+			return "", "", ErrDeviceCodeExpired
+		case <-time.After(interval):
+		}
+
+		token, err := u.tokenGrantRequest(values)
+
+		respErr, ok := err.(*responseError)
+		if !ok {
+			return token.AccessToken, token.RefreshToken, err
+		}
+
+		switch respErr.Name {
+		case "authorization_pending":
+		case "slow_down":
+			interval += defaultDeviceInterval
+		case "expired_token":
+			return "", "", ErrDeviceCodeExpired
+		case "access_denied":
+			return "", "", ErrDeviceAccessDenied
+		default:
+			return "", "", err
+		}
+	}
+}
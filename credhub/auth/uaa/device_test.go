@@ -0,0 +1,146 @@
+package uaa_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub/auth/uaa"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Device authorization", func() {
+	Context("DeviceAuthorization()", func() {
+		It("should request a device code", func() {
+			uaaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.URL.Path).To(Equal("/oauth/device_authorization"))
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/x-www-form-urlencoded"))
+
+				Expect(r.PostForm.Get("client_id")).To(Equal("client-id"))
+				Expect(r.PostForm.Get("client_secret")).To(Equal("client-secret"))
+				Expect(r.PostForm.Get("scope")).To(Equal("credhub.read credhub.write"))
+
+				w.Write([]byte(`{
+					"device_code": "device-code",
+					"user_code": "ABCD-EFGH",
+					"verification_uri": "https://uaa.example.com/device",
+					"verification_uri_complete": "https://uaa.example.com/device?user_code=ABCD-EFGH",
+					"expires_in": 600,
+					"interval": 5
+				}`))
+			}))
+			defer uaaServer.Close()
+
+			client := Client{AuthURL: uaaServer.URL, Client: http.DefaultClient}
+
+			authorization, err := client.DeviceAuthorization("client-id", "client-secret", "credhub.read", "credhub.write")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*authorization).To(Equal(DeviceAuthorization{
+				DeviceCode:              "device-code",
+				UserCode:                "ABCD-EFGH",
+				VerificationURI:         "https://uaa.example.com/device",
+				VerificationURIComplete: "https://uaa.example.com/device?user_code=ABCD-EFGH",
+				ExpiresIn:               600,
+				Interval:                5,
+			}))
+		})
+
+		It("should return the error of the auth server", func() {
+			uaaServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error": "unauthorized_client", "error_description": "device flow not allowed"}`))
+			}))
+			defer uaaServer.Close()
+
+			client := Client{AuthURL: uaaServer.URL, Client: http.DefaultClient}
+
+			_, err := client.DeviceAuthorization("client-id", "client-secret")
+
+			Expect(err).To(MatchError("unauthorized_client device flow not allowed"))
+		})
+	})
+
+	Context("DeviceCodeGrant()", func() {
+		var (
+			responses []string
+			requests  int
+			uaaServer *httptest.Server
+			client    Client
+		)
+
+		BeforeEach(func() {
+			requests = 0
+			uaaServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+
+				Expect(r.URL.Path).To(Equal("/oauth/token"))
+				Expect(r.PostForm.Get("grant_type")).To(Equal("urn:ietf:params:oauth:grant-type:device_code"))
+				Expect(r.PostForm.Get("device_code")).To(Equal("device-code"))
+				Expect(r.PostForm.Get("client_id")).To(Equal("client-id"))
+
+				response := responses[requests]
+				requests++
+				if response[0] == '!' {
+					w.WriteHeader(http.StatusBadRequest)
+					response = response[1:]
+				}
+				w.Write([]byte(response))
+			}))
+			client = Client{AuthURL: uaaServer.URL, Client: http.DefaultClient}
+		})
+
+		AfterEach(func() {
+			uaaServer.Close()
+		})
+
+		It("should poll until the login is approved", func() {
+			responses = []string{
+				`!{"error": "authorization_pending"}`,
+				`{"access_token": "access-token", "refresh_token": "refresh-token", "token_type": "bearer"}`,
+			}
+
+			accessToken, refreshToken, err := client.DeviceCodeGrant("client-id", "", &DeviceAuthorization{DeviceCode: "device-code", Interval: 1})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accessToken).To(Equal("access-token"))
+			Expect(refreshToken).To(Equal("refresh-token"))
+			Expect(requests).To(Equal(2))
+		})
+
+		It("should return ErrDeviceAccessDenied when the login is denied", func() {
+			responses = []string{`!{"error": "access_denied"}`}
+
+			_, _, err := client.DeviceCodeGrant("client-id", "", &DeviceAuthorization{DeviceCode: "device-code", Interval: 1})
+
+			Expect(err).To(Equal(ErrDeviceAccessDenied))
+		})
+
+		It("should return ErrDeviceCodeExpired when the device code expired", func() {
+			responses = []string{`!{"error": "expired_token"}`}
+
+			_, _, err := client.DeviceCodeGrant("client-id", "", &DeviceAuthorization{DeviceCode: "device-code", Interval: 1})
+
+			Expect(err).To(Equal(ErrDeviceCodeExpired))
+		})
+
+		It("should stop polling when the device code expires", func() {
+			_, _, err := client.DeviceCodeGrant("client-id", "", &DeviceAuthorization{DeviceCode: "device-code", Interval: 2, ExpiresIn: 1})
+
+			Expect(err).To(Equal(ErrDeviceCodeExpired))
+			Expect(requests).To(Equal(0))
+		})
+
+		It("should return other errors of the auth server", func() {
+			responses = []string{`!{"error": "invalid_client", "error_description": "bad client"}`}
+
+			_, _, err := client.DeviceCodeGrant("client-id", "", &DeviceAuthorization{DeviceCode: "device-code", Interval: 1})
+
+			Expect(err).To(MatchError("invalid_client bad client"))
+		})
+	})
+})
@@ -2,9 +2,11 @@ package credhub
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
 
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
 )
@@ -30,3 +32,125 @@ func (ch *CredHub) BulkRegenerate(signedBy string) (credentials.BulkRegenerateRe
 
 	return creds, err
 }
+
+type BulkRegenerateOption func(*BulkRegenerateOptions) error
+
+// BulkRegenerateOptions control how BulkRegenerateMatching regenerates credentials
+type BulkRegenerateOptions struct {
+	Concurrency int
+	DryRun      bool
+}
+
+// BulkRegenerateConcurrency limits the number of credentials regenerated at the same time. Defaults to 1.
+func BulkRegenerateConcurrency(concurrency int) BulkRegenerateOption {
+	return func(o *BulkRegenerateOptions) error {
+		if concurrency < 1 {
+			return errors.New("bulk regenerate concurrency must be at least 1")
+		}
+		o.Concurrency = concurrency
+		return nil
+	}
+}
+
+// BulkRegenerateDryRun reports the credentials that would be regenerated without regenerating them
+func BulkRegenerateDryRun() BulkRegenerateOption {
+	return func(o *BulkRegenerateOptions) error {
+		o.DryRun = true
+		return nil
+	}
+}
+
+// BulkRegenerateMatching regenerates every credential under path that matches filter.
+//
+// Unlike BulkRegenerate, matching credentials are resolved client-side and regenerated one by one with
+// Regenerate, preserving their metadata. A credential that fails to regenerate does not stop the others;
+// it is reported in the Failed field of the results.
+func (ch *CredHub) BulkRegenerateMatching(path string, filter FindFilter, options ...BulkRegenerateOption) (credentials.BulkRegenerateResults, error) {
+	opts := BulkRegenerateOptions{Concurrency: 1}
+	for _, option := range options {
+		if err := option(&opts); err != nil {
+			return credentials.BulkRegenerateResults{}, err
+		}
+	}
+
+	it, err := ch.FindByPathIterator(path, FindMatching(filter), FindSortBy("name", false))
+	if err != nil {
+		return credentials.BulkRegenerateResults{}, err
+	}
+	defer it.Close()
+
+	var matches []credentials.FindResult
+	var fetched []*credentials.Credential
+	for it.Next() {
+		matches = append(matches, it.Result())
+		if cred, ok := it.Credential(); ok {
+			fetched = append(fetched, &cred)
+		} else {
+			fetched = append(fetched, nil)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return credentials.BulkRegenerateResults{}, err
+	}
+
+	results := credentials.BulkRegenerateResults{Certificates: []string{}, DryRun: opts.DryRun}
+
+	if opts.DryRun {
+		for _, match := range matches {
+			results.Certificates = append(results.Certificates, match.Name)
+		}
+		return results, nil
+	}
+
+	errs := make([]error, len(matches))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = ch.regeneratePreservingMetadata(matches[i].Name, fetched[i])
+			}
+		}()
+	}
+
+	for i := range matches {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for i, match := range matches {
+		if errs[i] != nil {
+			results.Failed = append(results.Failed, credentials.BulkRegenerateFailure{Name: match.Name, Error: errs[i].Error()})
+			continue
+		}
+		results.Certificates = append(results.Certificates, match.Name)
+	}
+
+	return results, nil
+}
+
+func (ch *CredHub) regeneratePreservingMetadata(name string, cred *credentials.Credential) error {
+	if cred == nil {
+		latest, err := ch.GetLatestVersion(name)
+		if err != nil {
+			return err
+		}
+		cred = &latest
+	}
+
+	var options []RegenerateOption
+	if cred.Metadata != nil {
+		metadata := cred.Metadata
+		options = append(options, func(o *RegenerateOptions) error {
+			o.Metadata = metadata
+			return nil
+		})
+	}
+
+	_, err := ch.Regenerate(name, options...)
+	return err
+}
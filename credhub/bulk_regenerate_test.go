@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	. "code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 )
 
 var _ = Describe("Bulk Regenerate", func() {
@@ -68,4 +71,83 @@ var _ = Describe("Bulk Regenerate", func() {
 			Expect(err).To(HaveOccurred())
 		})
 	})
+
+	Describe("BulkRegenerateMatching()", func() {
+		var (
+			server      *ghttp.Server
+			ch          *CredHub
+			regenerated []map[string]interface{}
+		)
+
+		BeforeEach(func() {
+			regenerated = nil
+			server = ghttp.NewServer()
+			server.RouteToHandler("GET", "/api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Query().Get("name") {
+				case "":
+					w.Write([]byte(`{"credentials": [
+						{"name": "/team/b", "version_created_at": "2000-01-01T00:00:00Z"},
+						{"name": "/team/a", "version_created_at": "2000-01-01T00:00:00Z"},
+						{"name": "/team/new", "version_created_at": "2999-01-01T00:00:00Z"}
+					]}`))
+				case "/team/a":
+					w.Write([]byte(`{"data": [{"type": "password", "name": "/team/a", "value": "a", "metadata": {"owner": "ops"}, "version_created_at": "2000-01-01T00:00:00Z"}]}`))
+				case "/team/b":
+					w.Write([]byte(`{"data": [{"type": "password", "name": "/team/b", "value": "b", "version_created_at": "2000-01-01T00:00:00Z"}]}`))
+				}
+			})
+			server.RouteToHandler("POST", "/api/v1/data", func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				regenerated = append(regenerated, body)
+				if body["name"] == "/team/b" {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(`{"error": "could not regenerate"}`))
+					return
+				}
+				w.Write([]byte(`{"type": "password", "name": "` + body["name"].(string) + `", "value": "new"}`))
+			})
+
+			var err error
+			ch, err = New(server.URL(), ServerVersion("2.6.0"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("regenerates matching credentials preserving their metadata and reports failures", func() {
+			results, err := ch.BulkRegenerateMatching("/team", FindFilter{Types: []string{"password"}, CreatedBefore: time.Now()})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(results.Certificates).To(Equal([]string{"/team/a"}))
+			Expect(results.Failed).To(Equal([]credentials.BulkRegenerateFailure{{Name: "/team/b", Error: "could not regenerate"}}))
+			Expect(regenerated).To(HaveLen(2))
+			Expect(regenerated[0]).To(Equal(map[string]interface{}{"name": "/team/a", "regenerate": true, "metadata": map[string]interface{}{"owner": "ops"}}))
+			Expect(regenerated[1]).To(Equal(map[string]interface{}{"name": "/team/b", "regenerate": true}))
+		})
+
+		It("regenerates credentials concurrently", func() {
+			results, err := ch.BulkRegenerateMatching("/team", FindFilter{CreatedBefore: time.Now()}, BulkRegenerateConcurrency(2))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(results.Certificates).To(Equal([]string{"/team/a"}))
+			Expect(results.Failed).To(HaveLen(1))
+		})
+
+		It("does not regenerate anything in dry-run mode", func() {
+			results, err := ch.BulkRegenerateMatching("/team", FindFilter{CreatedBefore: time.Now()}, BulkRegenerateDryRun())
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(results.DryRun).To(BeTrue())
+			Expect(results.Certificates).To(Equal([]string{"/team/a", "/team/b"}))
+			Expect(regenerated).To(BeEmpty())
+		})
+
+		It("rejects a concurrency below 1", func() {
+			_, err := ch.BulkRegenerateMatching("/team", FindFilter{}, BulkRegenerateConcurrency(0))
+			Expect(err).To(MatchError("bulk regenerate concurrency must be at least 1"))
+		})
+	})
 })
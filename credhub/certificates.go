@@ -55,3 +55,23 @@ func (ch *CredHub) makeGetCertificatesRequest(query url.Values) ([]credentials.C
 
 	return data, nil
 }
+
+// UpdateTransitionalVersion marks a version of the certificate with the given ID as transitional.
+// An empty version ID clears the transitional version.
+func (ch *CredHub) UpdateTransitionalVersion(certificateId, versionId string) error {
+	request := map[string]interface{}{"version": nil}
+	if versionId != "" {
+		request["version"] = versionId
+	}
+
+	resp, err := ch.Request(http.MethodPost, "/api/v1/certificates/"+certificateId+"/update_transitional_version", nil, request, true)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	return nil
+}
@@ -2,6 +2,7 @@ package credhub_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 
@@ -161,4 +162,38 @@ var _ = Describe("Certificates", func() {
 			})
 		})
 	})
+
+	Context("updating the transitional version", func() {
+		It("requests to mark the version as transitional", func() {
+			dummy := &DummyAuth{Response: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString("[]")),
+			}}
+
+			ch, _ := New("https://example.com", Auth(dummy.Builder()))
+			err := ch.UpdateTransitionalVersion("some-id", "some-version-id")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dummy.Request.URL.String()).To(Equal("https://example.com/api/v1/certificates/some-id/update_transitional_version"))
+			Expect(dummy.Request.Method).To(Equal(http.MethodPost))
+
+			body, _ := ioutil.ReadAll(dummy.Request.Body)
+			Expect(body).To(MatchJSON(`{"version":"some-version-id"}`))
+		})
+
+		It("clears the transitional version when no version is given", func() {
+			dummy := &DummyAuth{Response: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewBufferString("[]")),
+			}}
+
+			ch, _ := New("https://example.com", Auth(dummy.Builder()))
+			Expect(ch.UpdateTransitionalVersion("some-id", "")).To(Succeed())
+
+			var requestBody map[string]interface{}
+			body, _ := ioutil.ReadAll(dummy.Request.Body)
+			Expect(json.Unmarshal(body, &requestBody)).To(Succeed())
+			Expect(requestBody).To(HaveKeyWithValue("version", BeNil()))
+		})
+	})
 })
@@ -6,14 +6,16 @@ import (
 	"net"
 	"net/http"
 	"time"
-
-	proxy "github.com/cloudfoundry/socks5-proxy"
 )
 
-// Client provides an unauthenticated http.Client to the CredHub server
+// Client provides an unauthenticated http.Client to the CredHub server. When the client has a
+// logger, the requests of the http.Client are logged, including the ones of auth strategies.
 func (ch *CredHub) Client() *http.Client {
 	if ch.defaultClient == nil {
 		ch.defaultClient = ch.client()
+		if ch.logger != nil {
+			ch.defaultClient.Transport = &loggingTransport{logger: ch.logger, next: ch.defaultClient.Transport}
+		}
 	}
 
 	return ch.defaultClient
@@ -21,10 +23,17 @@ func (ch *CredHub) Client() *http.Client {
 
 func (ch *CredHub) client() *http.Client {
 	if ch.baseURL.Scheme == "https" {
-		return httpsClient(ch.insecureSkipVerify, ch.caCerts, ch.clientCertificate, ch.httpTimeout)
+		return httpsClient(ch.insecureSkipVerify, ch.caCerts, ch.clientCertificate, ch.httpTimeout, ch.proxyURL, ch.noProxy, ch.sshTunnels)
+	}
+
+	client := httpClient(ch.httpTimeout)
+	if usesProxy(ch.proxyURL) {
+		transport := &http.Transport{MaxIdleConnsPerHost: 100}
+		configureProxy(transport, ch.proxyURL, ch.noProxy, ch.sshTunnels)
+		client.Transport = transport
 	}
 
-	return httpClient(ch.httpTimeout)
+	return client
 }
 
 func httpClient(timeout *time.Duration) *http.Client {
@@ -40,19 +49,14 @@ func httpClient(timeout *time.Duration) *http.Client {
 
 var defaultDialer net.Dialer
 
-func httpsClient(insecureSkipVerify bool, rootCAs *x509.CertPool, cert *tls.Certificate, timeout *time.Duration) *http.Client {
+func httpsClient(insecureSkipVerify bool, rootCAs *x509.CertPool, cert *tls.Certificate, timeout *time.Duration, proxyURL, noProxy string, sshTunnels *SSHTunnelDialer) *http.Client {
 	client := httpClient(timeout)
 	var certs []tls.Certificate
 	if cert != nil {
 		certs = []tls.Certificate{*cert}
 	}
 
-	var dialer = SOCKS5DialFuncFromEnvironment((&net.Dialer{
-		Timeout:   30 * time.Second,
-		KeepAlive: 30 * time.Second,
-	}).Dial, proxy.NewSocks5Proxy(proxy.NewHostKey(), nil, 30*time.Second))
-
-	client.Transport = &http.Transport{
+	transport := &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify:       insecureSkipVerify,
 			PreferServerCipherSuites: true,
@@ -60,10 +64,10 @@ func httpsClient(insecureSkipVerify bool, rootCAs *x509.CertPool, cert *tls.Cert
 			RootCAs:                  rootCAs,
 			MinVersion:               tls.VersionTLS12,
 		},
-		Proxy:               http.ProxyFromEnvironment,
-		Dial:                dialer,
 		MaxIdleConnsPerHost: 100,
 	}
+	configureProxy(transport, proxyURL, noProxy, sshTunnels)
+	client.Transport = transport
 
 	return client
 }
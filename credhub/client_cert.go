@@ -0,0 +1,71 @@
+package credhub
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+)
+
+// ErrClientKeyEncrypted is returned when an encrypted client private key is used without a passphrase
+var ErrClientKeyEncrypted = errors.New("the client private key is encrypted and requires a passphrase")
+
+// ErrClientKeyPassphrase is returned when the passphrase does not decrypt the client private key
+var ErrClientKeyPassphrase = errors.New("the client private key could not be decrypted with the provided passphrase")
+
+// IsEncryptedKey reports whether PEM encoded key data contains an encrypted private key
+func IsEncryptedKey(keyPEM []byte) bool {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return false
+	}
+	return block.Type == "ENCRYPTED PRIVATE KEY" || x509.IsEncryptedPEMBlock(block)
+}
+
+func loadClientCertificate(certificate, key, passphrase string) (*tls.Certificate, error) {
+	certPEM, err := ioutil.ReadFile(certificate)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := ioutil.ReadFile(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsEncryptedKey(keyPEM) {
+		if keyPEM, err = decryptKey(keyPEM, passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// decryptKey decrypts an encrypted PKCS #8 private key, the format written by 'openssl genpkey'
+// and 'openssl pkcs8', or a key encrypted with legacy RFC 1423 PEM encryption.
+func decryptKey(keyPEM []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrClientKeyEncrypted
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		der, err := decryptPKCS8(block.Bytes, []byte(passphrase))
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	}
+
+	der, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+	if err != nil {
+		return nil, ErrClientKeyPassphrase
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
 	. "code.cloudfoundry.org/credhub-cli/credhub"
@@ -77,6 +78,52 @@ var _ = Describe("Client()", func() {
 			_, err := New("https://example.com", ClientCert("./fixtures/auth-tls-key.pem", "./fixtures/auth-tls-cert.pem"))
 			Expect(err).To(HaveOccurred())
 		})
+
+		Context("with an encrypted private key", func() {
+			It("decrypts the key with the passphrase", func() {
+				ch, err := New("https://example.com", ClientCertWithPassphrase("./fixtures/auth-tls-cert.pem", "./fixtures/auth-tls-key-encrypted.pem", "passphrase"))
+				Expect(err).NotTo(HaveOccurred())
+
+				transport := ch.Client().Transport.(*http.Transport)
+				Expect(transport.TLSClientConfig.Certificates).To(HaveLen(1))
+			})
+
+			It("returns ErrClientKeyEncrypted without a passphrase", func() {
+				_, err := New("https://example.com", ClientCert("./fixtures/auth-tls-cert.pem", "./fixtures/auth-tls-key-encrypted.pem"))
+				Expect(err).To(Equal(ErrClientKeyEncrypted))
+			})
+
+			It("returns ErrClientKeyPassphrase with the wrong passphrase", func() {
+				_, err := New("https://example.com", ClientCertWithPassphrase("./fixtures/auth-tls-cert.pem", "./fixtures/auth-tls-key-encrypted.pem", "wrong"))
+				Expect(err).To(Equal(ErrClientKeyPassphrase))
+			})
+
+			DescribeTable("decrypts encrypted PKCS #8 keys",
+				func(keyFile string) {
+					ch, err := New("https://example.com", ClientCertWithPassphrase("./fixtures/auth-tls-cert.pem", keyFile, "passphrase"))
+					Expect(err).NotTo(HaveOccurred())
+
+					transport := ch.Client().Transport.(*http.Transport)
+					Expect(transport.TLSClientConfig.Certificates).To(HaveLen(1))
+				},
+				Entry("with PBKDF2 and AES", "./fixtures/auth-tls-key-pkcs8-encrypted.pem"),
+				Entry("with scrypt", "./fixtures/auth-tls-key-pkcs8-scrypt.pem"),
+				Entry("with PBKDF2-SHA1 and 3DES", "./fixtures/auth-tls-key-pkcs8-des3.pem"),
+			)
+
+			It("returns ErrClientKeyPassphrase for encrypted PKCS #8 keys with the wrong passphrase", func() {
+				_, err := New("https://example.com", ClientCertWithPassphrase("./fixtures/auth-tls-cert.pem", "./fixtures/auth-tls-key-pkcs8-encrypted.pem", "wrong"))
+				Expect(err).To(Equal(ErrClientKeyPassphrase))
+			})
+		})
+
+		It("reports whether a key is encrypted", func() {
+			encrypted, _ := ioutil.ReadFile("./fixtures/auth-tls-key-encrypted.pem")
+			plain, _ := ioutil.ReadFile("./fixtures/auth-tls-key.pem")
+
+			Expect(IsEncryptedKey(encrypted)).To(BeTrue())
+			Expect(IsEncryptedKey(plain)).To(BeFalse())
+		})
 	})
 
 	Context("With CaCerts", func() {
@@ -0,0 +1,13 @@
+package generate_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Generate Suite")
+}
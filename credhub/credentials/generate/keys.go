@@ -0,0 +1,268 @@
+package generate
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
+	"golang.org/x/crypto/ssh"
+)
+
+// Key algorithms supported by the KeyAlgorithm parameters. An empty key algorithm means RSA.
+const (
+	KeyAlgorithmRSA       = "rsa"
+	KeyAlgorithmECDSAP256 = "ecdsa-p256"
+	KeyAlgorithmECDSAP384 = "ecdsa-p384"
+	KeyAlgorithmEd25519   = "ed25519"
+)
+
+// KeyAlgorithms lists the supported key algorithms
+var KeyAlgorithms = []string{KeyAlgorithmRSA, KeyAlgorithmECDSAP256, KeyAlgorithmECDSAP384, KeyAlgorithmEd25519}
+
+// KeyAlgorithmOf returns the key algorithm of a public key
+func KeyAlgorithmOf(key crypto.PublicKey) (string, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return KeyAlgorithmRSA, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return KeyAlgorithmECDSAP256, nil
+		case elliptic.P384():
+			return KeyAlgorithmECDSAP384, nil
+		}
+		return "", fmt.Errorf("the ECDSA curve %s is not supported", k.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return KeyAlgorithmEd25519, nil
+	}
+	return "", fmt.Errorf("the key type %T is not supported", key)
+}
+
+// ValidateRSA checks that the provided keys parse, belong together and use the given key algorithm.
+// Either key may be empty. An empty algorithm accepts any supported algorithm.
+func ValidateRSA(value values.RSA, algorithm string) error {
+	var public crypto.PublicKey
+	if value.PublicKey != "" {
+		block, _ := pem.Decode([]byte(value.PublicKey))
+		if block == nil {
+			return errors.New("the public key is not PEM encoded")
+		}
+
+		var err error
+		if public, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			if public, err = x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+				return fmt.Errorf("the public key could not be parsed: %v", err)
+			}
+		}
+	}
+
+	return validateKeyPair(value.PrivateKey, public, algorithm)
+}
+
+// ValidateSSH checks that the provided keys parse, belong together and use the given key algorithm.
+// The public key must be in authorized_keys format. Either key may be empty.
+func ValidateSSH(value values.SSH, algorithm string) error {
+	var public crypto.PublicKey
+	if value.PublicKey != "" {
+		sshKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(value.PublicKey))
+		if err != nil {
+			return fmt.Errorf("the public key could not be parsed: %v", err)
+		}
+
+		cryptoKey, ok := sshKey.(ssh.CryptoPublicKey)
+		if !ok {
+			return fmt.Errorf("the public key type %s is not supported", sshKey.Type())
+		}
+		public = cryptoKey.CryptoPublicKey()
+	}
+
+	return validateKeyPair(value.PrivateKey, public, algorithm)
+}
+
+// ValidateCertificate checks that the certificate and private key parse, belong together and
+// use the given key algorithm. Either may be empty.
+func ValidateCertificate(value values.Certificate, algorithm string) error {
+	var public crypto.PublicKey
+	if value.Certificate != "" {
+		block, _ := pem.Decode([]byte(value.Certificate))
+		if block == nil {
+			return errors.New("the certificate is not PEM encoded")
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("the certificate could not be parsed: %v", err)
+		}
+		public = cert.PublicKey
+	}
+
+	return validateKeyPair(value.PrivateKey, public, algorithm)
+}
+
+// SSHFingerprint returns the SHA-256 fingerprint of an authorized_keys formatted public key,
+// base64 encoded without padding as reported by CredHub
+func SSHFingerprint(publicKey string) (string, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(key.Marshal())
+	return base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+func validateKeyPair(privateKeyPEM string, public crypto.PublicKey, algorithm string) error {
+	if privateKeyPEM != "" {
+		private, err := privateKeyPublic(privateKeyPEM)
+		if err != nil {
+			return fmt.Errorf("the private key could not be parsed: %v", err)
+		}
+
+		if public != nil && !publicKeysEqual(private, public) {
+			return errors.New("the private key does not match the public key")
+		}
+		public = private
+	}
+
+	if public == nil || algorithm == "" {
+		return nil
+	}
+
+	actual, err := KeyAlgorithmOf(public)
+	if err != nil {
+		return err
+	}
+
+	if actual != algorithm {
+		return fmt.Errorf("the key algorithm is %s but %s was expected", actual, algorithm)
+	}
+
+	return nil
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	if key, ok := a.(interface{ Equal(crypto.PublicKey) bool }); ok {
+		return key.Equal(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func generateKey(algorithm string, length int) (crypto.Signer, error) {
+	switch strings.ToLower(algorithm) {
+	case "", KeyAlgorithmRSA:
+		return rsa.GenerateKey(rand.Reader, keyLength(length))
+	}
+
+	if length != 0 {
+		return nil, fmt.Errorf("a key length cannot be used with %s keys", algorithm)
+	}
+
+	switch strings.ToLower(algorithm) {
+	case KeyAlgorithmECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyAlgorithmECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+
+	return nil, fmt.Errorf("the key algorithm '%s' is not supported", algorithm)
+}
+
+// encodePrivateKey encodes RSA keys as PKCS#1, ECDSA keys as SEC 1 and Ed25519 keys as PKCS#8
+func encodePrivateKey(key crypto.Signer) (string, error) {
+	var block *pem.Block
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			return "", err
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// privateKeyPublic returns the public key of a private key. Passphrase protected keys in the
+// OpenSSH format are accepted, since their public key is stored unencrypted.
+func privateKeyPublic(keyPEM string) (crypto.PublicKey, error) {
+	private, err := parsePrivateKey(keyPEM)
+	if err == nil {
+		return private.Public(), nil
+	}
+
+	if missing, ok := err.(*ssh.PassphraseMissingError); ok && missing.PublicKey != nil {
+		if cryptoKey, ok := missing.PublicKey.(ssh.CryptoPublicKey); ok {
+			return cryptoKey.CryptoPublicKey(), nil
+		}
+	}
+	return nil, err
+}
+
+// parsePrivateKey parses PEM encoded PKCS#1, SEC 1 and PKCS#8 private keys, and keys in the
+// OpenSSH format written by ssh-keygen
+func parsePrivateKey(keyPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+
+	if block.Type == "OPENSSH PRIVATE KEY" {
+		key, err := ssh.ParseRawPrivateKey([]byte(keyPEM))
+		if err != nil {
+			return nil, err
+		}
+		// Ed25519 keys are returned by pointer
+		if ed25519Key, ok := key.(*ed25519.PrivateKey); ok {
+			return *ed25519Key, nil
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key type")
+		}
+		return signer, nil
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+
+	return signer, nil
+}
@@ -0,0 +1,331 @@
+package generate
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	defaultKeyLength          = 2048
+	defaultCertificateDays    = 365
+	defaultGeneratedUserChars = 20
+)
+
+// Generate returns a password generated locally from the parameters.
+// Like the server, at least one character of every included character class is used.
+func (p Password) Generate() (values.Password, error) {
+	value, err := p.policy().Generate()
+	return values.Password(value), err
+}
+
+// Generate returns a user generated locally from the parameters. A random username
+// is generated when Username is empty.
+func (u User) Generate() (values.User, error) {
+	username := u.Username
+	if username == "" {
+		generated, err := PasswordPolicy{Length: defaultGeneratedUserChars, ExcludeUpper: true, ExcludeNumber: true}.Generate()
+		if err != nil {
+			return values.User{}, err
+		}
+		username = generated
+	}
+
+	password, err := Password{
+		Length:         u.Length,
+		IncludeSpecial: u.IncludeSpecial,
+		ExcludeNumber:  u.ExcludeNumber,
+		ExcludeUpper:   u.ExcludeUpper,
+		ExcludeLower:   u.ExcludeLower,
+	}.Generate()
+
+	return values.User{Username: username, Password: string(password)}, err
+}
+
+// Generate returns a key pair generated locally from the parameters. Despite the credential
+// type, the key pair uses KeyAlgorithm when it is set.
+func (r RSA) Generate() (values.RSA, error) {
+	key, err := generateKey(r.KeyAlgorithm, r.KeyLength)
+	if err != nil {
+		return values.RSA{}, err
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return values.RSA{}, err
+	}
+
+	privateKey, err := encodePrivateKey(key)
+	if err != nil {
+		return values.RSA{}, err
+	}
+
+	return values.RSA{
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// Generate returns an SSH key pair generated locally from the parameters. The public key is
+// in authorized_keys format followed by the comment, if any.
+func (s SSH) Generate() (values.SSH, error) {
+	key, err := generateKey(s.KeyAlgorithm, s.KeyLength)
+	if err != nil {
+		return values.SSH{}, err
+	}
+
+	publicKey, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return values.SSH{}, err
+	}
+
+	privateKey, err := encodePrivateKey(key)
+	if err != nil {
+		return values.SSH{}, err
+	}
+
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+	if s.Comment != "" {
+		authorizedKey += " " + s.Comment
+	}
+
+	return values.SSH{
+		PublicKey:  authorizedKey,
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// Generate returns a certificate generated locally from the parameters.
+//
+// The certificate is self-signed when SelfSign is set, or when IsCA is set and Ca is empty.
+// Otherwise it is signed by ca, which must contain the certificate and private key of the CA
+// named by Ca.
+func (c Certificate) Generate(ca *values.Certificate) (values.Certificate, error) {
+	selfSigned := c.SelfSign || (c.IsCA && c.Ca == "")
+	if !selfSigned && c.Ca == "" {
+		return values.Certificate{}, errors.New("a certificate must be self-signed, a CA, or signed by a CA")
+	}
+	if !selfSigned && ca == nil {
+		return values.Certificate{}, fmt.Errorf("the CA '%s' is required to sign the certificate", c.Ca)
+	}
+
+	key, err := generateKey(c.KeyAlgorithm, c.KeyLength)
+	if err != nil {
+		return values.Certificate{}, err
+	}
+
+	privateKey, err := encodePrivateKey(key)
+	if err != nil {
+		return values.Certificate{}, err
+	}
+
+	template, err := c.template()
+	if err != nil {
+		return values.Certificate{}, err
+	}
+
+	parent := template
+	signer := key
+	if !selfSigned {
+		parent, signer, err = parseCA(c.Ca, ca)
+		if err != nil {
+			return values.Certificate{}, err
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		return values.Certificate{}, err
+	}
+
+	cert := values.Certificate{
+		Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKey:  privateKey,
+	}
+
+	if selfSigned {
+		cert.Ca = cert.Certificate
+	} else {
+		cert.Ca = ca.Certificate
+		cert.CaName = c.Ca
+	}
+
+	return cert, nil
+}
+
+// Value generates a credential value locally for the given credential type and parameters.
+// Certificates signed by a CA require the CA value; it is ignored for other types.
+func Value(credType string, params interface{}, ca *values.Certificate) (interface{}, error) {
+	switch p := params.(type) {
+	case Password:
+		return p.Generate()
+	case User:
+		return p.Generate()
+	case RSA:
+		return p.Generate()
+	case SSH:
+		return p.Generate()
+	case Certificate:
+		return p.Generate(ca)
+	default:
+		return nil, fmt.Errorf("credentials of type '%s' cannot be generated locally", credType)
+	}
+}
+
+func (p Password) policy() PasswordPolicy {
+	policy := PasswordPolicy{
+		Length:         p.Length,
+		IncludeSpecial: p.IncludeSpecial,
+		ExcludeNumber:  p.ExcludeNumber,
+		ExcludeUpper:   p.ExcludeUpper,
+		ExcludeLower:   p.ExcludeLower,
+	}
+
+	for _, class := range []struct {
+		enabled bool
+		min     *int
+	}{
+		{!p.ExcludeUpper, &policy.MinUpper},
+		{!p.ExcludeLower, &policy.MinLower},
+		{!p.ExcludeNumber, &policy.MinNumber},
+		{p.IncludeSpecial, &policy.MinSpecial},
+	} {
+		if class.enabled {
+			*class.min = 1
+		}
+	}
+
+	return policy
+}
+
+func (c Certificate) template() (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	days := c.Duration
+	if days == 0 {
+		days = defaultCertificateDays
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      c.subject(),
+		NotBefore:    now,
+		NotAfter:     now.AddDate(0, 0, days),
+	}
+
+	for _, name := range c.AlternativeNames {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	for _, usage := range c.KeyUsage {
+		keyUsage, ok := keyUsages[usage]
+		if !ok {
+			return nil, fmt.Errorf("the key usage '%s' is not supported", usage)
+		}
+		template.KeyUsage |= keyUsage
+	}
+
+	for _, usage := range c.ExtendedKeyUsage {
+		extKeyUsage, ok := extendedKeyUsages[usage]
+		if !ok {
+			return nil, fmt.Errorf("the extended key usage '%s' is not supported", usage)
+		}
+		template.ExtKeyUsage = append(template.ExtKeyUsage, extKeyUsage)
+	}
+
+	if c.IsCA {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+
+	return template, nil
+}
+
+func (c Certificate) subject() pkix.Name {
+	name := pkix.Name{CommonName: c.CommonName}
+	for _, field := range []struct {
+		value  string
+		target *[]string
+	}{
+		{c.Organization, &name.Organization},
+		{c.OrganizationUnit, &name.OrganizationalUnit},
+		{c.Locality, &name.Locality},
+		{c.State, &name.Province},
+		{c.Country, &name.Country},
+	} {
+		if field.value != "" {
+			*field.target = []string{field.value}
+		}
+	}
+	return name
+}
+
+var keyUsages = map[string]x509.KeyUsage{
+	"digital_signature": x509.KeyUsageDigitalSignature,
+	"non_repudiation":   x509.KeyUsageContentCommitment,
+	"key_encipherment":  x509.KeyUsageKeyEncipherment,
+	"data_encipherment": x509.KeyUsageDataEncipherment,
+	"key_agreement":     x509.KeyUsageKeyAgreement,
+	"key_cert_sign":     x509.KeyUsageCertSign,
+	"crl_sign":          x509.KeyUsageCRLSign,
+	"encipher_only":     x509.KeyUsageEncipherOnly,
+	"decipher_only":     x509.KeyUsageDecipherOnly,
+}
+
+var extendedKeyUsages = map[string]x509.ExtKeyUsage{
+	"server_auth":      x509.ExtKeyUsageServerAuth,
+	"client_auth":      x509.ExtKeyUsageClientAuth,
+	"code_signing":     x509.ExtKeyUsageCodeSigning,
+	"email_protection": x509.ExtKeyUsageEmailProtection,
+	"timestamping":     x509.ExtKeyUsageTimeStamping,
+}
+
+func parseCA(name string, ca *values.Certificate) (*x509.Certificate, crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(ca.Certificate))
+	if block == nil {
+		return nil, nil, fmt.Errorf("the CA '%s' does not contain a PEM encoded certificate", name)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("the certificate of CA '%s' could not be parsed: %v", name, err)
+	}
+
+	if !cert.IsCA {
+		return nil, nil, fmt.Errorf("the certificate '%s' is not a CA", name)
+	}
+
+	signer, err := parsePrivateKey(ca.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("the private key of CA '%s' could not be parsed: %v", name, err)
+	}
+
+	return cert, signer, nil
+}
+
+func keyLength(length int) int {
+	if length == 0 {
+		return defaultKeyLength
+	}
+	return length
+}
@@ -0,0 +1,155 @@
+package generate_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+)
+
+var _ = Describe("Local generation", func() {
+	parseCertificate := func(certPEM string) *x509.Certificate {
+		block, _ := pem.Decode([]byte(certPEM))
+		Expect(block).NotTo(BeNil())
+		cert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).NotTo(HaveOccurred())
+		return cert
+	}
+
+	Describe("Password", func() {
+		It("uses the default length and includes every enabled character class", func() {
+			value, err := Password{}.Generate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(value)).To(HaveLen(30))
+			Expect(string(value)).To(MatchRegexp(`[A-Z]`))
+			Expect(string(value)).To(MatchRegexp(`[a-z]`))
+			Expect(string(value)).To(MatchRegexp(`[0-9]`))
+			Expect(string(value)).To(MatchRegexp(`^[A-Za-z0-9]+$`))
+		})
+
+		It("honors the include and exclude parameters", func() {
+			value, err := Password{Length: 12, IncludeSpecial: true, ExcludeUpper: true, ExcludeLower: true}.Generate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(value)).To(HaveLen(12))
+			Expect(string(value)).NotTo(MatchRegexp(`[A-Za-z]`))
+			Expect(string(value)).To(MatchRegexp(`[^0-9]`))
+		})
+	})
+
+	Describe("User", func() {
+		It("keeps the provided username", func() {
+			user, err := User{Username: "admin", Length: 10}.Generate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Username).To(Equal("admin"))
+			Expect(user.Password).To(HaveLen(10))
+		})
+
+		It("generates a username when none is provided", func() {
+			user, err := User{}.Generate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Username).To(MatchRegexp(`^[a-z]{20}$`))
+		})
+	})
+
+	Describe("RSA", func() {
+		It("generates a PEM encoded key pair of the given length", func() {
+			rsa, err := RSA{KeyLength: 1024}.Generate()
+			Expect(err).NotTo(HaveOccurred())
+
+			block, _ := pem.Decode([]byte(rsa.PrivateKey))
+			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key.N.BitLen()).To(Equal(1024))
+			Expect(rsa.PublicKey).To(HavePrefix("-----BEGIN PUBLIC KEY-----"))
+		})
+	})
+
+	Describe("SSH", func() {
+		It("generates an authorized key with the comment", func() {
+			key, err := SSH{KeyLength: 1024, Comment: "me@example.com"}.Generate()
+			Expect(err).NotTo(HaveOccurred())
+
+			publicKey, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(key.PublicKey))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(publicKey.Type()).To(Equal("ssh-rsa"))
+			Expect(comment).To(Equal("me@example.com"))
+
+			_, err = ssh.ParseRawPrivateKey([]byte(key.PrivateKey))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("Certificate", func() {
+		var ca values.Certificate
+
+		BeforeEach(func() {
+			var err error
+			ca, err = Certificate{CommonName: "example-ca", IsCA: true, KeyLength: 1024, Duration: 30}.Generate(nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("generates a self-signed CA", func() {
+			cert := parseCertificate(ca.Certificate)
+			Expect(cert.IsCA).To(BeTrue())
+			Expect(cert.Subject.CommonName).To(Equal("example-ca"))
+			Expect(cert.CheckSignatureFrom(cert)).To(Succeed())
+			Expect(ca.Ca).To(Equal(ca.Certificate))
+			Expect(cert.NotAfter.Sub(cert.NotBefore).Hours()).To(BeNumerically("==", 30*24))
+		})
+
+		It("generates a certificate signed by the given CA", func() {
+			value, err := Certificate{
+				CommonName:       "example.com",
+				Organization:     "Example",
+				AlternativeNames: []string{"example.com", "10.0.0.1"},
+				KeyUsage:         []string{"digital_signature"},
+				ExtendedKeyUsage: []string{"server_auth"},
+				Ca:               "/example-ca",
+				KeyLength:        1024,
+			}.Generate(&ca)
+			Expect(err).NotTo(HaveOccurred())
+
+			cert := parseCertificate(value.Certificate)
+			Expect(cert.CheckSignatureFrom(parseCertificate(ca.Certificate))).To(Succeed())
+			Expect(cert.Subject.Organization).To(Equal([]string{"Example"}))
+			Expect(cert.DNSNames).To(Equal([]string{"example.com"}))
+			Expect(cert.IPAddresses[0].String()).To(Equal("10.0.0.1"))
+			Expect(cert.KeyUsage).To(Equal(x509.KeyUsageDigitalSignature))
+			Expect(cert.ExtKeyUsage).To(Equal([]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}))
+			Expect(value.Ca).To(Equal(ca.Certificate))
+			Expect(value.CaName).To(Equal("/example-ca"))
+		})
+
+		It("requires the CA when signing", func() {
+			_, err := Certificate{CommonName: "example.com", Ca: "/example-ca"}.Generate(nil)
+			Expect(err).To(MatchError("the CA '/example-ca' is required to sign the certificate"))
+		})
+
+		It("rejects certificates which are not signed", func() {
+			_, err := Certificate{CommonName: "example.com"}.Generate(nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects unknown key usages", func() {
+			_, err := Certificate{SelfSign: true, KeyLength: 1024, KeyUsage: []string{"everything"}}.Generate(nil)
+			Expect(err).To(MatchError("the key usage 'everything' is not supported"))
+		})
+	})
+
+	Describe("Value()", func() {
+		It("dispatches on the parameter type", func() {
+			value, err := Value("password", Password{Length: 5}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(value.(values.Password))).To(HaveLen(5))
+		})
+
+		It("rejects types which cannot be generated locally", func() {
+			_, err := Value("value", "something", nil)
+			Expect(err).To(MatchError("credentials of type 'value' cannot be generated locally"))
+		})
+	})
+})
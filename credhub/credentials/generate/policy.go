@@ -0,0 +1,321 @@
+package generate
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+const (
+	upperCharacters   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lowerCharacters   = "abcdefghijklmnopqrstuvwxyz"
+	numberCharacters  = "0123456789"
+	specialCharacters = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+	defaultPasswordLength = 30
+	defaultWordListFile   = "/usr/share/dict/words"
+)
+
+// PasswordPolicy describes the values a generated password must satisfy.
+//
+// Policies that only use Length, the include/exclude flags and minimum counts of at most one per
+// character class can be generated by the server. Any other policy must be generated locally.
+type PasswordPolicy struct {
+	Length         int  `json:"length,omitempty" yaml:"length,omitempty"`
+	IncludeSpecial bool `json:"include_special,omitempty" yaml:"include_special,omitempty"`
+	ExcludeNumber  bool `json:"exclude_number,omitempty" yaml:"exclude_number,omitempty"`
+	ExcludeUpper   bool `json:"exclude_upper,omitempty" yaml:"exclude_upper,omitempty"`
+	ExcludeLower   bool `json:"exclude_lower,omitempty" yaml:"exclude_lower,omitempty"`
+
+	MinUpper   int `json:"min_upper,omitempty" yaml:"min_upper,omitempty"`
+	MinLower   int `json:"min_lower,omitempty" yaml:"min_lower,omitempty"`
+	MinNumber  int `json:"min_number,omitempty" yaml:"min_number,omitempty"`
+	MinSpecial int `json:"min_special,omitempty" yaml:"min_special,omitempty"`
+
+	// AllowedCharacters replaces the characters selected by the include/exclude flags
+	AllowedCharacters string `json:"allowed_characters,omitempty" yaml:"allowed_characters,omitempty"`
+	// ForbiddenCharacters are never used in a generated value
+	ForbiddenCharacters string `json:"forbidden_characters,omitempty" yaml:"forbidden_characters,omitempty"`
+
+	// Passphrase generates a sequence of words instead of characters when set
+	Passphrase *PassphrasePolicy `json:"passphrase,omitempty" yaml:"passphrase,omitempty"`
+}
+
+// PassphrasePolicy generates passwords made of randomly chosen words
+type PassphrasePolicy struct {
+	Words     int    `json:"words,omitempty" yaml:"words,omitempty"`
+	Separator string `json:"separator,omitempty" yaml:"separator,omitempty"`
+	// WordListFile contains one word per line. Defaults to /usr/share/dict/words.
+	WordListFile string `json:"word_list_file,omitempty" yaml:"word_list_file,omitempty"`
+	// WordList is used instead of WordListFile when it is not empty
+	WordList []string `json:"word_list,omitempty" yaml:"word_list,omitempty"`
+}
+
+// ServerParameters returns the server generation parameters equivalent to the policy.
+// It returns false when the server cannot generate values satisfying the policy.
+func (p PasswordPolicy) ServerParameters() (Password, bool) {
+	params := Password{
+		Length:         p.Length,
+		IncludeSpecial: p.IncludeSpecial,
+		ExcludeNumber:  p.ExcludeNumber,
+		ExcludeUpper:   p.ExcludeUpper,
+		ExcludeLower:   p.ExcludeLower,
+	}
+
+	// the server includes at least one character of every enabled class
+	if p.MinSpecial > 0 {
+		params.IncludeSpecial = true
+	}
+
+	if p.Passphrase != nil || p.AllowedCharacters != "" || p.ForbiddenCharacters != "" {
+		return params, false
+	}
+
+	for _, class := range p.classes() {
+		if class.min > 1 || (class.min > 0 && !class.enabled) {
+			return params, false
+		}
+	}
+
+	return params, true
+}
+
+// Generate returns a random value satisfying the policy
+func (p PasswordPolicy) Generate() (string, error) {
+	if p.Passphrase != nil {
+		return p.Passphrase.generate()
+	}
+
+	pool, err := p.pool()
+	if err != nil {
+		return "", err
+	}
+
+	length := p.length()
+	var chars []rune
+	for _, class := range p.classes() {
+		classPool := intersect(class.characters, pool)
+		if class.min > 0 && classPool == "" {
+			return "", fmt.Errorf("the password policy requires %d %s characters but none are allowed", class.min, class.name)
+		}
+		for i := 0; i < class.min; i++ {
+			c, err := randomRune(classPool)
+			if err != nil {
+				return "", err
+			}
+			chars = append(chars, c)
+		}
+	}
+
+	if len(chars) > length {
+		return "", fmt.Errorf("the password policy requires %d characters but the length is %d", len(chars), length)
+	}
+
+	for len(chars) < length {
+		c, err := randomRune(pool)
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, c)
+	}
+
+	if err := shuffle(chars); err != nil {
+		return "", err
+	}
+
+	return string(chars), nil
+}
+
+// Validate returns an error describing the first requirement of the policy the value does not satisfy
+func (p PasswordPolicy) Validate(value string) error {
+	if p.Passphrase != nil {
+		return p.Passphrase.validate(value)
+	}
+
+	if length := len([]rune(value)); length != p.length() {
+		return fmt.Errorf("the password must be %d characters long but is %d", p.length(), length)
+	}
+
+	pool, err := p.pool()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range value {
+		if !strings.ContainsRune(pool, c) {
+			return fmt.Errorf("the password contains the character '%c' which is not allowed", c)
+		}
+	}
+
+	for _, class := range p.classes() {
+		if count := countIn(value, class.characters); count < class.min {
+			return fmt.Errorf("the password must contain at least %d %s characters but contains %d", class.min, class.name, count)
+		}
+	}
+
+	return nil
+}
+
+type characterClass struct {
+	name       string
+	characters string
+	enabled    bool
+	min        int
+}
+
+func (p PasswordPolicy) classes() []characterClass {
+	return []characterClass{
+		{"upper", upperCharacters, !p.ExcludeUpper, p.MinUpper},
+		{"lower", lowerCharacters, !p.ExcludeLower, p.MinLower},
+		{"number", numberCharacters, !p.ExcludeNumber, p.MinNumber},
+		{"special", specialCharacters, p.IncludeSpecial || p.MinSpecial > 0, p.MinSpecial},
+	}
+}
+
+func (p PasswordPolicy) length() int {
+	if p.Length == 0 {
+		return defaultPasswordLength
+	}
+	return p.Length
+}
+
+// pool returns every character a generated value may contain
+func (p PasswordPolicy) pool() (string, error) {
+	pool := p.AllowedCharacters
+	if pool == "" {
+		for _, class := range p.classes() {
+			if class.enabled {
+				pool += class.characters
+			}
+		}
+	}
+
+	pool = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(p.ForbiddenCharacters, r) {
+			return -1
+		}
+		return r
+	}, pool)
+
+	if pool == "" {
+		return "", errors.New("the password policy does not allow any characters")
+	}
+
+	return pool, nil
+}
+
+func (p PassphrasePolicy) generate() (string, error) {
+	words, err := p.words()
+	if err != nil {
+		return "", err
+	}
+
+	chosen := make([]string, p.wordCount())
+	for i := range chosen {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(words))))
+		if err != nil {
+			return "", err
+		}
+		chosen[i] = words[n.Int64()]
+	}
+
+	return strings.Join(chosen, p.separator()), nil
+}
+
+func (p PassphrasePolicy) validate(value string) error {
+	if count := len(strings.Split(value, p.separator())); count != p.wordCount() {
+		return fmt.Errorf("the passphrase must contain %d words but contains %d", p.wordCount(), count)
+	}
+	return nil
+}
+
+func (p PassphrasePolicy) wordCount() int {
+	if p.Words == 0 {
+		return 4
+	}
+	return p.Words
+}
+
+func (p PassphrasePolicy) separator() string {
+	if p.Separator == "" {
+		return "-"
+	}
+	return p.Separator
+}
+
+// words returns the usable words of the word list. Words containing the separator or
+// non-letter characters are skipped.
+func (p PassphrasePolicy) words() ([]string, error) {
+	list := p.WordList
+	if len(list) == 0 {
+		file := p.WordListFile
+		if file == "" {
+			file = defaultWordListFile
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("the passphrase word list could not be read: %v", err)
+		}
+		list = strings.Split(string(data), "\n")
+	}
+
+	var words []string
+	for _, word := range list {
+		word = strings.TrimSpace(word)
+		if word == "" || strings.Contains(word, p.separator()) || strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }) >= 0 {
+			continue
+		}
+		words = append(words, word)
+	}
+
+	if len(words) == 0 {
+		return nil, errors.New("the passphrase word list does not contain any words")
+	}
+
+	return words, nil
+}
+
+func intersect(a, b string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(b, r) {
+			return r
+		}
+		return -1
+	}, a)
+}
+
+func countIn(value, characters string) int {
+	count := 0
+	for _, c := range value {
+		if strings.ContainsRune(characters, c) {
+			count++
+		}
+	}
+	return count
+}
+
+func randomRune(pool string) (rune, error) {
+	runes := []rune(pool)
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(runes))))
+	if err != nil {
+		return 0, err
+	}
+	return runes[n.Int64()], nil
+}
+
+func shuffle(chars []rune) error {
+	for i := len(chars) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return err
+		}
+		j := n.Int64()
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return nil
+}
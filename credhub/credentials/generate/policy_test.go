@@ -0,0 +1,124 @@
+package generate_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PasswordPolicy", func() {
+	Describe("ServerParameters()", func() {
+		It("returns the equivalent server parameters for simple policies", func() {
+			params, ok := PasswordPolicy{Length: 20, ExcludeUpper: true, MinNumber: 1, MinSpecial: 1}.ServerParameters()
+
+			Expect(ok).To(BeTrue())
+			Expect(params).To(Equal(Password{Length: 20, ExcludeUpper: true, IncludeSpecial: true}))
+		})
+
+		It("cannot be expressed with minimum counts above one", func() {
+			_, ok := PasswordPolicy{MinNumber: 2}.ServerParameters()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("cannot be expressed with a minimum count of an excluded class", func() {
+			_, ok := PasswordPolicy{ExcludeUpper: true, MinUpper: 1}.ServerParameters()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("cannot be expressed with custom character sets or passphrases", func() {
+			_, ok := PasswordPolicy{AllowedCharacters: "abc"}.ServerParameters()
+			Expect(ok).To(BeFalse())
+
+			_, ok = PasswordPolicy{ForbiddenCharacters: "0O"}.ServerParameters()
+			Expect(ok).To(BeFalse())
+
+			_, ok = PasswordPolicy{Passphrase: &PassphrasePolicy{}}.ServerParameters()
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("Generate()", func() {
+		It("generates values satisfying minimum counts and forbidden characters", func() {
+			policy := PasswordPolicy{Length: 16, MinUpper: 3, MinNumber: 4, MinSpecial: 2, ForbiddenCharacters: "0Ol1"}
+
+			for i := 0; i < 50; i++ {
+				value, err := policy.Generate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(policy.Validate(value)).To(Succeed())
+				Expect(value).NotTo(ContainSubstring("0"))
+				Expect(value).NotTo(ContainSubstring("l"))
+			}
+		})
+
+		It("only uses the allowed characters", func() {
+			value, err := PasswordPolicy{Length: 12, AllowedCharacters: "ab"}.Generate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Trim(value, "ab")).To(BeEmpty())
+			Expect(value).To(HaveLen(12))
+		})
+
+		It("returns an error when the minimum counts exceed the length", func() {
+			_, err := PasswordPolicy{Length: 4, MinUpper: 3, MinNumber: 3}.Generate()
+			Expect(err).To(MatchError("the password policy requires 6 characters but the length is 4"))
+		})
+
+		It("returns an error when a required class is not allowed", func() {
+			_, err := PasswordPolicy{AllowedCharacters: "abc", MinNumber: 1}.Generate()
+			Expect(err).To(MatchError("the password policy requires 1 number characters but none are allowed"))
+		})
+
+		It("returns an error when no characters are allowed", func() {
+			_, err := PasswordPolicy{AllowedCharacters: "ab", ForbiddenCharacters: "ab"}.Generate()
+			Expect(err).To(MatchError("the password policy does not allow any characters"))
+		})
+
+		Context("in passphrase mode", func() {
+			It("joins words from the word list", func() {
+				policy := PasswordPolicy{Passphrase: &PassphrasePolicy{Words: 5, Separator: ".", WordList: []string{"correct", "horse", "battery", "sta.ple", "42"}}}
+
+				value, err := policy.Generate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(policy.Validate(value)).To(Succeed())
+
+				for _, word := range strings.Split(value, ".") {
+					Expect(word).To(BeElementOf("correct", "horse", "battery"))
+				}
+			})
+
+			It("reads words from the word list file", func() {
+				file, err := ioutil.TempFile("", "words")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.Remove(file.Name())
+				file.WriteString("alpha\nbeta\n")
+				file.Close()
+
+				value, err := PasswordPolicy{Passphrase: &PassphrasePolicy{Words: 2, WordListFile: file.Name()}}.Generate()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(MatchRegexp(`^(alpha|beta)-(alpha|beta)$`))
+			})
+
+			It("returns an error when the word list has no usable words", func() {
+				_, err := PasswordPolicy{Passphrase: &PassphrasePolicy{WordList: []string{"1234"}}}.Generate()
+				Expect(err).To(MatchError("the passphrase word list does not contain any words"))
+			})
+		})
+	})
+
+	Describe("Validate()", func() {
+		It("reports values of the wrong length", func() {
+			Expect(PasswordPolicy{Length: 4}.Validate("abc")).To(MatchError("the password must be 4 characters long but is 3"))
+		})
+
+		It("reports characters which are not allowed", func() {
+			Expect(PasswordPolicy{Length: 4, ExcludeNumber: true}.Validate("abc1")).To(MatchError("the password contains the character '1' which is not allowed"))
+		})
+
+		It("reports missing character classes", func() {
+			Expect(PasswordPolicy{Length: 4, MinUpper: 2}.Validate("Abcd")).To(MatchError("the password must contain at least 2 upper characters but contains 1"))
+		})
+	})
+})
@@ -20,6 +20,7 @@ type User struct {
 
 type Certificate struct {
 	KeyLength        int      `json:"key_length,omitempty"`
+	KeyAlgorithm     string   `json:"key_algorithm,omitempty"`
 	Duration         int      `json:"duration,omitempty"`
 	CommonName       string   `json:"common_name,omitempty"`
 	Organization     string   `json:"organization,omitempty"`
@@ -36,10 +37,12 @@ type Certificate struct {
 }
 
 type RSA struct {
-	KeyLength int `json:"key_length"`
+	KeyLength    int    `json:"key_length"`
+	KeyAlgorithm string `json:"key_algorithm,omitempty"`
 }
 
 type SSH struct {
-	Comment   string `json:"ssh_comment,omitempty"`
-	KeyLength int    `json:"key_length"`
+	Comment      string `json:"ssh_comment,omitempty"`
+	KeyLength    int    `json:"key_length"`
+	KeyAlgorithm string `json:"key_algorithm,omitempty"`
 }
@@ -121,15 +121,25 @@ type SSH struct {
 
 // Type needed for Bulk Regenerate functionality
 type BulkRegenerateResults struct {
-	Certificates []string `json:"regenerated_credentials" yaml:"regenerated_credentials"`
+	Certificates []string                `json:"regenerated_credentials" yaml:"regenerated_credentials"`
+	Failed       []BulkRegenerateFailure `json:"failed_credentials,omitempty" yaml:"failed_credentials,omitempty"`
+	DryRun       bool                    `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+}
+
+// A credential that could not be regenerated by a client-side bulk regenerate
+type BulkRegenerateFailure struct {
+	Name  string `json:"name" yaml:"name"`
+	Error string `json:"error" yaml:"error"`
 }
 
 // Types needed for Find functionality
 type FindResults struct {
-	Credentials []struct {
-		Name             string `json:"name" yaml:"name"`
-		VersionCreatedAt string `json:"version_created_at" yaml:"version_created_at"`
-	} `json:"credentials" yaml:"credentials"`
+	Credentials []FindResult `json:"credentials" yaml:"credentials"`
+}
+
+type FindResult struct {
+	Name             string `json:"name" yaml:"name"`
+	VersionCreatedAt string `json:"version_created_at" yaml:"version_created_at"`
 }
 
 type Paths struct {
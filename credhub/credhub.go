@@ -51,4 +51,14 @@ type CredHub struct {
 
 	// Timeout for http client
 	httpTimeout *time.Duration
+
+	// Proxy for connections to CredHub and auth servers, and the hosts bypassing it
+	proxyURL string
+	noProxy  string
+
+	// Dialer opening the SSH tunnels of ssh+socks5 proxies
+	sshTunnels *SSHTunnelDialer
+
+	// Logger of requests, nil when logging is off
+	logger *Logger
 }
@@ -0,0 +1,148 @@
+package credhub
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+)
+
+// FindFilter selects find results by attributes the CredHub find API cannot query.
+//
+// Every non-zero field must match for a credential to be selected. Filtering on Types, Metadata or
+// ExpiresBefore requires fetching the latest version of each credential returned by the server.
+type FindFilter struct {
+	// Types matches credentials of any of the given types (eg. "password", "certificate")
+	Types []string
+
+	// Metadata matches credentials whose metadata contains every key with the given value.
+	// Nested keys may be addressed with dots (eg. "team.owner").
+	Metadata map[string]string
+
+	// CreatedBefore matches credentials whose latest version was created before this time
+	CreatedBefore time.Time
+
+	// CreatedAfter matches credentials whose latest version was created after this time
+	CreatedAfter time.Time
+
+	// ExpiresBefore matches certificate credentials that expire before this time, including expired ones
+	ExpiresBefore time.Time
+}
+
+// FindMatching applies a FindFilter to the results of a FindIterator.
+// The filter is applied before FindOffset and FindLimit.
+func FindMatching(filter FindFilter) FindOption {
+	return func(o *FindOptions) error {
+		o.Filter = &filter
+		return nil
+	}
+}
+
+// MatchResult reports whether a find result satisfies the creation time constraints of the filter
+func (f FindFilter) MatchResult(result credentials.FindResult) (bool, error) {
+	if f.CreatedBefore.IsZero() && f.CreatedAfter.IsZero() {
+		return true, nil
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, result.VersionCreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("could not parse version_created_at of '%s': %v", result.Name, err)
+	}
+
+	if !f.CreatedBefore.IsZero() && !createdAt.Before(f.CreatedBefore) {
+		return false, nil
+	}
+
+	if !f.CreatedAfter.IsZero() && !createdAt.After(f.CreatedAfter) {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// MatchCredential reports whether a credential satisfies every constraint of the filter
+func (f FindFilter) MatchCredential(cred credentials.Credential) (bool, error) {
+	if ok, err := f.MatchResult(credentials.FindResult{Name: cred.Name, VersionCreatedAt: cred.VersionCreatedAt}); !ok || err != nil {
+		return ok, err
+	}
+
+	if len(f.Types) > 0 && !containsFold(f.Types, cred.Type) {
+		return false, nil
+	}
+
+	for key, expected := range f.Metadata {
+		actual, ok := lookupMetadata(cred.Metadata, key)
+		if !ok || fmt.Sprint(actual) != expected {
+			return false, nil
+		}
+	}
+
+	if !f.ExpiresBefore.IsZero() {
+		if cred.Type != "certificate" {
+			return false, nil
+		}
+
+		expiry, err := certificateExpiry(cred)
+		if err != nil {
+			return false, err
+		}
+
+		if !expiry.Before(f.ExpiresBefore) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (f FindFilter) needsCredential() bool {
+	return len(f.Types) > 0 || len(f.Metadata) > 0 || !f.ExpiresBefore.IsZero()
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupMetadata(metadata credentials.Metadata, key string) (interface{}, bool) {
+	var current interface{} = map[string]interface{}(metadata)
+
+	for _, segment := range strings.Split(key, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[segment]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func certificateExpiry(cred credentials.Credential) (time.Time, error) {
+	value, ok := cred.Value.(map[string]interface{})
+	if !ok {
+		return time.Time{}, fmt.Errorf("certificate '%s' does not have a certificate value", cred.Name)
+	}
+
+	certPEM, _ := value["certificate"].(string)
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		return time.Time{}, fmt.Errorf("certificate '%s' does not contain a PEM encoded certificate", cred.Name)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("certificate '%s' could not be parsed: %v", cred.Name, err)
+	}
+
+	return cert.NotAfter, nil
+}
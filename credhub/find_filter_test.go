@@ -0,0 +1,117 @@
+package credhub_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"time"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("FindFilter", func() {
+	now := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	certificateExpiringAt := func(notAfter time.Time) string {
+		key, err := rsa.GenerateKey(rand.Reader, 1024)
+		Expect(err).NotTo(HaveOccurred())
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			NotBefore:    notAfter.Add(-24 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).NotTo(HaveOccurred())
+
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+
+	Describe("MatchResult()", func() {
+		It("matches results created within the given window", func() {
+			filter := FindFilter{CreatedBefore: now, CreatedAfter: now.Add(-48 * time.Hour)}
+
+			Expect(filter.MatchResult(credentials.FindResult{Name: "/a", VersionCreatedAt: "2018-05-31T00:00:00Z"})).To(BeTrue())
+			Expect(filter.MatchResult(credentials.FindResult{Name: "/b", VersionCreatedAt: "2018-06-02T00:00:00Z"})).To(BeFalse())
+			Expect(filter.MatchResult(credentials.FindResult{Name: "/c", VersionCreatedAt: "2018-05-01T00:00:00Z"})).To(BeFalse())
+		})
+
+		It("returns an error when the creation time cannot be parsed", func() {
+			_, err := FindFilter{CreatedBefore: now}.MatchResult(credentials.FindResult{Name: "/a", VersionCreatedAt: "yesterday"})
+			Expect(err).To(MatchError(ContainSubstring("could not parse version_created_at of '/a'")))
+		})
+	})
+
+	Describe("MatchCredential()", func() {
+		It("matches types case-insensitively", func() {
+			filter := FindFilter{Types: []string{"Password", "user"}}
+
+			Expect(filter.MatchCredential(credentials.Credential{Base: credentials.Base{Type: "password"}})).To(BeTrue())
+			Expect(filter.MatchCredential(credentials.Credential{Base: credentials.Base{Type: "value"}})).To(BeFalse())
+		})
+
+		It("matches nested metadata", func() {
+			filter := FindFilter{Metadata: map[string]string{"team.owner": "ops", "tier": "1"}}
+			metadata := credentials.Metadata{"team": map[string]interface{}{"owner": "ops"}, "tier": float64(1)}
+
+			Expect(filter.MatchCredential(credentials.Credential{Base: credentials.Base{Metadata: metadata}})).To(BeTrue())
+			Expect(filter.MatchCredential(credentials.Credential{Base: credentials.Base{Metadata: credentials.Metadata{"team": "ops"}}})).To(BeFalse())
+			Expect(filter.MatchCredential(credentials.Credential{})).To(BeFalse())
+		})
+
+		It("matches certificates expiring before the given time", func() {
+			filter := FindFilter{ExpiresBefore: now}
+
+			expiring := credentials.Credential{Base: credentials.Base{Type: "certificate"}, Value: map[string]interface{}{"certificate": certificateExpiringAt(now.Add(-time.Hour))}}
+			valid := credentials.Credential{Base: credentials.Base{Type: "certificate"}, Value: map[string]interface{}{"certificate": certificateExpiringAt(now.Add(time.Hour))}}
+
+			Expect(filter.MatchCredential(expiring)).To(BeTrue())
+			Expect(filter.MatchCredential(valid)).To(BeFalse())
+			Expect(filter.MatchCredential(credentials.Credential{Base: credentials.Base{Type: "password"}, Value: "secret"})).To(BeFalse())
+		})
+	})
+
+	Describe("FindMatching()", func() {
+		It("fetches each credential to apply type filters", func() {
+			server := ghttp.NewServer()
+			defer server.Close()
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data", "path=/"),
+					ghttp.RespondWith(http.StatusOK, `{"credentials": [{"name": "/a", "version_created_at": "2018-01-01T00:00:00Z"}, {"name": "/b", "version_created_at": "2018-01-01T00:00:00Z"}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data", "name=/a&current=true"),
+					ghttp.RespondWith(http.StatusOK, `{"data": [{"type": "value", "name": "/a", "value": "a"}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data", "name=/b&current=true"),
+					ghttp.RespondWith(http.StatusOK, `{"data": [{"type": "password", "name": "/b", "value": "b"}]}`),
+				),
+			)
+
+			ch, err := New(server.URL())
+			Expect(err).NotTo(HaveOccurred())
+
+			it, err := ch.FindByPathIterator("/", FindMatching(FindFilter{Types: []string{"password"}}))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(it.Next()).To(BeTrue())
+			Expect(it.Result().Name).To(Equal("/b"))
+			credential, fetched := it.Credential()
+			Expect(fetched).To(BeTrue())
+			Expect(credential.Value).To(Equal("b"))
+			Expect(it.Next()).To(BeFalse())
+			Expect(it.Err()).NotTo(HaveOccurred())
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+	})
+})
@@ -0,0 +1,337 @@
+package credhub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+)
+
+type FindOption func(*FindOptions) error
+
+// FindOptions control the order and window of results returned by a FindIterator.
+//
+// The CredHub API does not sort or paginate find results, so these are applied client-side.
+// Offset and Limit are applied as results are read from the response; sorting requires
+// reading every result before the first one is returned.
+type FindOptions struct {
+	SortBy     string
+	Descending bool
+	Offset     int
+	Limit      int
+	Filter     *FindFilter
+}
+
+// FindSortBy sorts results by "name" or "version_created_at"
+func FindSortBy(field string, descending bool) FindOption {
+	return func(o *FindOptions) error {
+		if field != "name" && field != "version_created_at" {
+			return fmt.Errorf("cannot sort find results by '%s'", field)
+		}
+		o.SortBy = field
+		o.Descending = descending
+		return nil
+	}
+}
+
+// FindOffset skips the first offset results
+func FindOffset(offset int) FindOption {
+	return func(o *FindOptions) error {
+		if offset < 0 {
+			return errors.New("find offset must not be negative")
+		}
+		o.Offset = offset
+		return nil
+	}
+}
+
+// FindLimit returns at most limit results. A limit of 0 returns all results.
+func FindLimit(limit int) FindOption {
+	return func(o *FindOptions) error {
+		if limit < 0 {
+			return errors.New("find limit must not be negative")
+		}
+		o.Limit = limit
+		return nil
+	}
+}
+
+// FindIterator reads find results from the server response one credential at a time.
+//
+// Use Next() to advance the iterator and Result() to read the current result. Check Err()
+// once Next() returns false. Close() must be called if iteration is abandoned early.
+type FindIterator struct {
+	ch     *CredHub
+	filter *FindFilter
+
+	body      io.ReadCloser
+	dec       *json.Decoder
+	started   bool
+	exhausted bool
+
+	sorted []credentials.FindResult
+
+	skip      int
+	remaining int
+	limited   bool
+
+	current    credentials.FindResult
+	credential *credentials.Credential
+	err        error
+	done       bool
+}
+
+// FindByPathIterator streams the stored credential names which are within the specified path.
+func (ch *CredHub) FindByPathIterator(path string, options ...FindOption) (*FindIterator, error) {
+	return ch.findIterator("path", path, options...)
+}
+
+// FindByPartialNameIterator streams the stored credential names which contain the search.
+func (ch *CredHub) FindByPartialNameIterator(nameLike string, options ...FindOption) (*FindIterator, error) {
+	return ch.findIterator("name-like", nameLike, options...)
+}
+
+func (ch *CredHub) findIterator(key, value string, options ...FindOption) (*FindIterator, error) {
+	var opts FindOptions
+	for _, option := range options {
+		if err := option(&opts); err != nil {
+			return nil, err
+		}
+	}
+
+	query := url.Values{}
+	query.Set(key, value)
+
+	resp, err := ch.Request(http.MethodGet, "/api/v1/data", query, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &FindIterator{
+		ch:        ch,
+		filter:    opts.Filter,
+		body:      resp.Body,
+		dec:       json.NewDecoder(resp.Body),
+		skip:      opts.Offset,
+		remaining: opts.Limit,
+		limited:   opts.Limit > 0,
+	}
+
+	if opts.SortBy != "" {
+		if err := it.sortResults(opts.SortBy, opts.Descending); err != nil {
+			it.Close()
+			return nil, err
+		}
+	}
+
+	return it, nil
+}
+
+// Next advances the iterator to the next result. It returns false when there are no more
+// results or an error occurred.
+func (it *FindIterator) Next() bool {
+	for !it.done {
+		if it.limited && it.remaining == 0 {
+			it.Close()
+			return false
+		}
+
+		result, ok := it.read()
+		if !ok {
+			it.Close()
+			return false
+		}
+
+		credential, matched, err := it.match(result)
+		if err != nil {
+			it.err = err
+			it.Close()
+			return false
+		}
+		if !matched {
+			continue
+		}
+
+		if it.skip > 0 {
+			it.skip--
+			continue
+		}
+
+		it.remaining--
+		it.current = result
+		it.credential = credential
+		return true
+	}
+
+	return false
+}
+
+// Result returns the result the iterator currently points at
+func (it *FindIterator) Result() credentials.FindResult {
+	return it.current
+}
+
+// Credential returns the latest version of the current result when it was fetched to apply a
+// FindFilter. It returns false when the credential was not fetched.
+func (it *FindIterator) Credential() (credentials.Credential, bool) {
+	if it.credential == nil {
+		return credentials.Credential{}, false
+	}
+	return *it.credential, true
+}
+
+// Err returns the error that stopped iteration, if any
+func (it *FindIterator) Err() error {
+	return it.err
+}
+
+// Close releases the underlying response. It is safe to call Close more than once.
+func (it *FindIterator) Close() error {
+	if it.done {
+		return nil
+	}
+	it.done = true
+
+	io.Copy(ioutil.Discard, it.body)
+	return it.body.Close()
+}
+
+// Collect reads all remaining results into a FindResults
+func (it *FindIterator) Collect() (credentials.FindResults, error) {
+	results := credentials.FindResults{Credentials: []credentials.FindResult{}}
+	for it.Next() {
+		results.Credentials = append(results.Credentials, it.Result())
+	}
+	return results, it.Err()
+}
+
+func (it *FindIterator) match(result credentials.FindResult) (*credentials.Credential, bool, error) {
+	if it.filter == nil {
+		return nil, true, nil
+	}
+
+	matched, err := it.filter.MatchResult(result)
+	if err != nil || !matched || !it.filter.needsCredential() {
+		return nil, matched, err
+	}
+
+	credential, err := it.ch.GetLatestVersion(result.Name)
+	if err != nil {
+		if _, deleted := err.(*NotFoundError); deleted {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	matched, err = it.filter.MatchCredential(credential)
+	return &credential, matched, err
+}
+
+func (it *FindIterator) read() (credentials.FindResult, bool) {
+	var result credentials.FindResult
+
+	if it.sorted != nil {
+		if len(it.sorted) == 0 {
+			return result, false
+		}
+		result, it.sorted = it.sorted[0], it.sorted[1:]
+		return result, true
+	}
+
+	if !it.started {
+		it.started = true
+		found, err := it.seekToCredentials()
+		if err != nil {
+			it.err = err
+			return result, false
+		}
+		it.exhausted = !found
+	}
+
+	if it.exhausted || !it.dec.More() {
+		return result, false
+	}
+
+	if err := it.dec.Decode(&result); err != nil {
+		it.err = errors.New("The response body could not be decoded: " + err.Error())
+		return result, false
+	}
+
+	return result, true
+}
+
+// seekToCredentials advances the decoder to the first element of the "credentials" array.
+// It returns false if the response does not contain any credentials.
+func (it *FindIterator) seekToCredentials() (bool, error) {
+	if err := expectDelim(it.dec, '{'); err != nil {
+		return false, err
+	}
+
+	for it.dec.More() {
+		token, err := it.dec.Token()
+		if err != nil {
+			return false, errors.New("The response body could not be decoded: " + err.Error())
+		}
+
+		if key, ok := token.(string); ok && key == "credentials" {
+			return true, expectDelim(it.dec, '[')
+		}
+
+		var skipped json.RawMessage
+		if err := it.dec.Decode(&skipped); err != nil {
+			return false, errors.New("The response body could not be decoded: " + err.Error())
+		}
+	}
+
+	return false, nil
+}
+
+func (it *FindIterator) sortResults(field string, descending bool) error {
+	var all []credentials.FindResult
+	for {
+		result, ok := it.read()
+		if !ok {
+			break
+		}
+		all = append(all, result)
+	}
+	if it.err != nil {
+		return it.err
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i].Name, all[j].Name
+		if field == "version_created_at" {
+			a, b = all[i].VersionCreatedAt, all[j].VersionCreatedAt
+		}
+		if descending {
+			return a > b
+		}
+		return a < b
+	})
+
+	if all == nil {
+		all = []credentials.FindResult{}
+	}
+	it.sorted = all
+	return nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return errors.New("The response body could not be decoded: " + err.Error())
+	}
+
+	if d, ok := token.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("The response body could not be decoded: expected '%s' but found '%v'", delim, token)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package credhub_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FindIterator", func() {
+	const findResponse = `{
+  "credentials": [
+    {"version_created_at": "2017-05-09T21:09:26Z", "name": "/some/example/path/b"},
+    {"version_created_at": "2017-05-09T21:09:07Z", "name": "/some/example/path/c"},
+    {"version_created_at": "2017-05-09T21:09:45Z", "name": "/some/example/path/a"}
+  ]
+}`
+
+	newClient := func(body string) (*CredHub, *DummyAuth) {
+		dummy := &DummyAuth{Response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+		}}
+
+		ch, _ := New("https://example.com", Auth(dummy.Builder()))
+		return ch, dummy
+	}
+
+	names := func(it *FindIterator) []string {
+		var result []string
+		for it.Next() {
+			result = append(result, it.Result().Name)
+		}
+		Expect(it.Err()).NotTo(HaveOccurred())
+		return result
+	}
+
+	Describe("FindByPathIterator()", func() {
+		It("requests credentials for a specified path", func() {
+			ch, dummy := newClient(findResponse)
+
+			_, err := ch.FindByPathIterator("/some/example/path")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dummy.Request.URL.String()).To(Equal("https://example.com/api/v1/data?path=%2Fsome%2Fexample%2Fpath"))
+			Expect(dummy.Request.Method).To(Equal(http.MethodGet))
+		})
+
+		It("returns each credential in the order received", func() {
+			ch, _ := newClient(findResponse)
+
+			it, err := ch.FindByPathIterator("/some/example/path")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(it.Next()).To(BeTrue())
+			Expect(it.Result()).To(Equal(credentials.FindResult{Name: "/some/example/path/b", VersionCreatedAt: "2017-05-09T21:09:26Z"}))
+			Expect(names(it)).To(Equal([]string{"/some/example/path/c", "/some/example/path/a"}))
+		})
+
+		It("ignores other fields in the response", func() {
+			ch, _ := newClient(`{"other": {"nested": [1, 2]}, "credentials": [{"name": "/only", "version_created_at": "idc"}], "trailing": true}`)
+
+			it, err := ch.FindByPathIterator("/")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(it)).To(Equal([]string{"/only"}))
+		})
+
+		It("returns no results when the response has no credentials", func() {
+			ch, _ := newClient(`{}`)
+
+			it, err := ch.FindByPathIterator("/")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(it.Next()).To(BeFalse())
+			Expect(it.Err()).NotTo(HaveOccurred())
+		})
+
+		It("applies offset and limit", func() {
+			ch, _ := newClient(findResponse)
+
+			it, err := ch.FindByPathIterator("/some/example/path", FindOffset(1), FindLimit(1))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(it)).To(Equal([]string{"/some/example/path/c"}))
+		})
+
+		It("sorts by name", func() {
+			ch, _ := newClient(findResponse)
+
+			it, err := ch.FindByPathIterator("/some/example/path", FindSortBy("name", false))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(it)).To(Equal([]string{"/some/example/path/a", "/some/example/path/b", "/some/example/path/c"}))
+		})
+
+		It("sorts by version_created_at in descending order before applying offset and limit", func() {
+			ch, _ := newClient(findResponse)
+
+			it, err := ch.FindByPathIterator("/some/example/path", FindSortBy("version_created_at", true), FindOffset(1), FindLimit(5))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(it)).To(Equal([]string{"/some/example/path/b", "/some/example/path/c"}))
+		})
+
+		It("rejects unknown sort fields", func() {
+			ch, _ := newClient(findResponse)
+
+			_, err := ch.FindByPathIterator("/some/example/path", FindSortBy("type", false))
+			Expect(err).To(HaveOccurred())
+		})
+
+		Context("when the response body cannot be decoded", func() {
+			It("reports the error after iteration stops", func() {
+				ch, _ := newClient(`{"credentials": [{"name": "/first"}, something-invalid`)
+
+				it, err := ch.FindByPathIterator("/")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(it.Next()).To(BeTrue())
+				Expect(it.Next()).To(BeFalse())
+				Expect(it.Err()).To(MatchError(ContainSubstring("The response body could not be decoded")))
+			})
+		})
+	})
+
+	Describe("FindByPartialNameIterator()", func() {
+		It("requests credentials for a specified partial name", func() {
+			ch, dummy := newClient(findResponse)
+
+			_, err := ch.FindByPartialNameIterator("example")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dummy.Request.URL.String()).To(Equal("https://example.com/api/v1/data?name-like=example"))
+		})
+	})
+
+	Describe("Collect()", func() {
+		It("returns the remaining results as FindResults", func() {
+			ch, _ := newClient(findResponse)
+
+			it, err := ch.FindByPathIterator("/some/example/path", FindLimit(2))
+			Expect(err).NotTo(HaveOccurred())
+
+			results, err := it.Collect()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results.Credentials).To(HaveLen(2))
+			Expect(results.Credentials[1].Name).To(Equal("/some/example/path/c"))
+		})
+	})
+})
@@ -2,6 +2,7 @@ package credhub
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -69,12 +70,16 @@ type GenerateOptions struct {
 }
 
 func (ch *CredHub) generateCredential(name, credType string, gen interface{}, overwrite Mode, cred interface{}, options ...GenerateOption) error {
+	if algorithm := keyAlgorithm(gen); algorithm != "" && algorithm != generate.KeyAlgorithmRSA {
+		return fmt.Errorf("the server only generates RSA keys, generate %s keys with GenerateCredentialLocally", algorithm)
+	}
+
 	isOverwrite := overwrite == Overwrite
 
 	request := generateRequest{
 		Name:       name,
 		Type:       credType,
-		Parameters: gen,
+		Parameters: withoutKeyAlgorithm(gen),
 	}
 
 	if overwrite == Converge {
@@ -117,3 +122,48 @@ func (ch *CredHub) generateCredential(name, credType string, gen interface{}, ov
 	}
 	return dec.Decode(&cred)
 }
+
+// keyAlgorithm returns the key algorithm requested by generation parameters. The server ignores
+// the key algorithm and always generates RSA keys.
+func keyAlgorithm(gen interface{}) string {
+	switch g := gen.(type) {
+	case generate.RSA:
+		return g.KeyAlgorithm
+	case generate.SSH:
+		return g.KeyAlgorithm
+	case generate.Certificate:
+		return g.KeyAlgorithm
+	case map[string]interface{}:
+		algorithm, _ := g["key_algorithm"].(string)
+		return algorithm
+	}
+	return ""
+}
+
+// withoutKeyAlgorithm returns generation parameters without the key algorithm, which the server
+// does not accept as a parameter.
+func withoutKeyAlgorithm(gen interface{}) interface{} {
+	switch g := gen.(type) {
+	case generate.RSA:
+		g.KeyAlgorithm = ""
+		return g
+	case generate.SSH:
+		g.KeyAlgorithm = ""
+		return g
+	case generate.Certificate:
+		g.KeyAlgorithm = ""
+		return g
+	case map[string]interface{}:
+		if _, ok := g["key_algorithm"]; !ok {
+			return g
+		}
+		params := make(map[string]interface{}, len(g))
+		for key, value := range g {
+			if key != "key_algorithm" {
+				params[key] = value
+			}
+		}
+		return params
+	}
+	return gen
+}
@@ -0,0 +1,54 @@
+package credhub
+
+import (
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
+)
+
+// GenerateCredentialLocally generates a credential value on the client from the provided
+// generate parameters (generate.Password, generate.User, generate.RSA, generate.SSH or
+// generate.Certificate) and stores it with SetCredential.
+//
+// Certificates signed by a CA are signed with the latest version of the CA stored in CredHub.
+// Since the server does not know the generation parameters, NoOverwrite and Converge both
+// keep an existing credential.
+func (ch *CredHub) GenerateCredentialLocally(name, credType string, gen interface{}, overwrite Mode, options ...GenerateOption) (credentials.Credential, error) {
+	if overwrite != Overwrite {
+		existing, err := ch.GetLatestVersion(name)
+		if err == nil {
+			return existing, nil
+		}
+		if _, notFound := err.(*NotFoundError); !notFound {
+			return credentials.Credential{}, err
+		}
+	}
+
+	var generateOptions GenerateOptions
+	for _, option := range options {
+		if err := option(&generateOptions); err != nil {
+			return credentials.Credential{}, err
+		}
+	}
+
+	var ca *values.Certificate
+	if cert, ok := gen.(generate.Certificate); ok && cert.Ca != "" && !cert.SelfSign {
+		caCred, err := ch.GetLatestCertificate(cert.Ca)
+		if err != nil {
+			return credentials.Credential{}, err
+		}
+		ca = &caCred.Value
+	}
+
+	value, err := generate.Value(credType, gen, ca)
+	if err != nil {
+		return credentials.Credential{}, err
+	}
+
+	withMetadata := func(s *SetOptions) error {
+		s.Metadata = generateOptions.Metadata
+		return nil
+	}
+
+	return ch.SetCredential(name, credType, value, withMetadata)
+}
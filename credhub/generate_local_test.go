@@ -0,0 +1,89 @@
+package credhub_test
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("GenerateCredentialLocally()", func() {
+	var (
+		server *ghttp.Server
+		ch     *CredHub
+		setReq map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		setReq = nil
+		server = ghttp.NewServer()
+
+		var err error
+		ch, err = New(server.URL(), ServerVersion("2.6.0"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	recordSet := ghttp.CombineHandlers(
+		ghttp.VerifyRequest("PUT", "/api/v1/data"),
+		func(w http.ResponseWriter, r *http.Request) {
+			Expect(json.NewDecoder(r.Body).Decode(&setReq)).To(Succeed())
+			response, _ := json.Marshal(setReq)
+			w.Write(response)
+		},
+	)
+
+	It("generates the value locally and sets it", func() {
+		server.AppendHandlers(recordSet)
+
+		cred, err := ch.GenerateCredentialLocally("/example-password", "password", generate.Password{Length: 8}, Overwrite)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(setReq["type"]).To(Equal("password"))
+		Expect(setReq["value"]).To(HaveLen(8))
+		Expect(cred.Value).To(Equal(setReq["value"]))
+	})
+
+	It("signs certificates with the CA stored in CredHub", func() {
+		ca, err := generate.Certificate{CommonName: "ca", IsCA: true, KeyLength: 1024}.Generate(nil)
+		Expect(err).NotTo(HaveOccurred())
+		caJSON, _ := json.Marshal(ca)
+
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v1/data", "name=/example-ca&current=true"),
+				ghttp.RespondWith(http.StatusOK, `{"data": [{"name": "/example-ca", "type": "certificate", "value": `+string(caJSON)+`}]}`),
+			),
+			recordSet,
+		)
+
+		_, err = ch.GenerateCredentialLocally("/example-cert", "certificate", generate.Certificate{CommonName: "example.com", Ca: "/example-ca", KeyLength: 1024}, Overwrite)
+		Expect(err).NotTo(HaveOccurred())
+
+		value := setReq["value"].(map[string]interface{})
+		Expect(value["ca"]).To(Equal(ca.Certificate))
+		Expect(value["ca_name"]).To(Equal("/example-ca"))
+		Expect(value["certificate"]).To(HavePrefix("-----BEGIN CERTIFICATE-----"))
+	})
+
+	It("keeps an existing credential when not overwriting", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"data": [{"name": "/example-rsa", "type": "rsa", "value": {"public_key": "pub", "private_key": "priv"}}]}`))
+
+		cred, err := ch.GenerateCredentialLocally("/example-rsa", "rsa", generate.RSA{KeyLength: 1024}, NoOverwrite)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cred.Value).To(Equal(map[string]interface{}{"public_key": "pub", "private_key": "priv"}))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("returns an error for types which cannot be generated locally", func() {
+		_, err := ch.GenerateCredentialLocally("/example-json", "json", map[string]interface{}{}, Overwrite)
+		Expect(err).To(MatchError("credentials of type 'json' cannot be generated locally"))
+	})
+})
@@ -9,6 +9,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
 
 	. "code.cloudfoundry.org/credhub-cli/credhub"
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
@@ -473,6 +474,18 @@ var _ = Describe("Generate", func() {
 			})
 		})
 
+		Context("with a key algorithm other than RSA", func() {
+			It("returns an error without contacting the server", func() {
+				dummy := &DummyAuth{}
+				ch, _ := New("https://example.com", Auth(dummy.Builder()), ServerVersion("2.6.0"))
+
+				_, err := ch.GenerateSSH("/example-ssh", generate.SSH{KeyAlgorithm: generate.KeyAlgorithmEd25519}, Overwrite)
+
+				Expect(err).To(MatchError("the server only generates RSA keys, generate ed25519 keys with GenerateCredentialLocally"))
+				Expect(dummy.Request).To(BeNil())
+			})
+		})
+
 		Context("when request fails to complete", func() {
 			var err error
 			It("returns an error", func() {
@@ -501,4 +514,46 @@ var _ = Describe("Generate", func() {
 
 		})
 	})
+
+	Describe("with the RSA key algorithm", func() {
+		var (
+			server *ghttp.Server
+			ch     *CredHub
+			body   map[string]interface{}
+		)
+
+		BeforeEach(func() {
+			body = nil
+			server = ghttp.NewServer()
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/api/v1/data"),
+				func(w http.ResponseWriter, r *http.Request) {
+					Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+					w.Write([]byte(`{"name":"/example","type":"rsa","value":{}}`))
+				},
+			))
+
+			var err error
+			ch, err = New(server.URL(), ServerVersion("2.6.0"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("does not send the key algorithm to the server", func() {
+			_, err := ch.GenerateCredential("/example", "rsa", generate.RSA{KeyLength: 2048, KeyAlgorithm: generate.KeyAlgorithmRSA}, Overwrite)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(body["parameters"]).To(Equal(map[string]interface{}{"key_length": float64(2048)}))
+		})
+
+		It("does not send the key algorithm of parameter maps to the server", func() {
+			_, err := ch.GenerateCredential("/example", "rsa", map[string]interface{}{"key_length": 2048, "key_algorithm": "rsa"}, Overwrite)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(body["parameters"]).To(Equal(map[string]interface{}{"key_length": float64(2048)}))
+		})
+	})
 })
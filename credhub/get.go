@@ -18,6 +18,7 @@ func (ch *CredHub) GetById(id string) (credentials.Credential, error) {
 	var cred credentials.Credential
 
 	err := ch.makeCredentialGetByIdRequest(id, &cred)
+	fillCredentialSSHFingerprint(&cred)
 
 	return cred, err
 }
@@ -34,6 +35,7 @@ func (ch *CredHub) GetAllVersions(name string) ([]credentials.Credential, error)
 func (ch *CredHub) GetLatestVersion(name string) (credentials.Credential, error) {
 	var cred credentials.Credential
 	err := ch.getCurrentCredential(name, &cred)
+	fillCredentialSSHFingerprint(&cred)
 	return cred, err
 }
 
@@ -95,6 +97,7 @@ func (ch *CredHub) GetLatestRSA(name string) (credentials.RSA, error) {
 func (ch *CredHub) GetLatestSSH(name string) (credentials.SSH, error) {
 	var cred credentials.SSH
 	err := ch.getCurrentCredential(name, &cred)
+	fillSSHFingerprint(&cred)
 
 	return cred, err
 }
@@ -187,5 +190,9 @@ func (ch *CredHub) makeMultiCredentialGetRequest(query url.Values) ([]credential
 		return nil, newCredhubError("response did not contain any credentials", "")
 	}
 
+	for i := range data {
+		fillCredentialSSHFingerprint(&data[i])
+	}
+
 	return data, nil
 }
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -13,8 +15,10 @@ import (
 	"encoding/json"
 
 	. "code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
 	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
 	. "github.com/onsi/ginkgo/extensions/table"
+	"golang.org/x/crypto/ssh"
 )
 
 var _ = Describe("Get", func() {
@@ -665,6 +669,39 @@ var _ = Describe("Get", func() {
 				Expect(cred.Metadata).To(Equal(credentials.Metadata{"some": "metadata"}))
 				Expect(cred.VersionCreatedAt).To(Equal("2017-01-01T04:07:18Z"))
 			})
+
+			It("computes the fingerprint when the server does not return one", func() {
+				key, err := generate.SSH{KeyAlgorithm: generate.KeyAlgorithmEd25519}.Generate()
+				Expect(err).ToNot(HaveOccurred())
+				publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key.PublicKey))
+				Expect(err).ToNot(HaveOccurred())
+
+				responseString := fmt.Sprintf(`{
+				  "data": [
+					{
+					  "id": "some-id",
+					  "name": "/example-ssh",
+					  "type": "ssh",
+					  "value": {
+						"public_key": %q,
+						"private_key": "private-key",
+						"public_key_fingerprint": null
+					  },
+					  "version_created_at": "2017-01-01T04:07:18Z"
+					}
+				  ]
+				}`, key.PublicKey)
+
+				dummyAuth := &DummyAuth{Response: &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       ioutil.NopCloser(bytes.NewBufferString(responseString)),
+				}}
+
+				ch, _ := New("https://example.com", Auth(dummyAuth.Builder()))
+				cred, err := ch.GetLatestSSH("/example-ssh")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cred.Value.PublicKeyFingerprint).To(Equal(strings.TrimPrefix(ssh.FingerprintSHA256(publicKey), "SHA256:")))
+			})
 		})
 	})
 
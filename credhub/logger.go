@@ -0,0 +1,238 @@
+package credhub
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel is the severity of a log event
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelOff
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error", "off"}
+
+func (l LogLevel) String() string {
+	if l < LogLevelDebug || l > LogLevelOff {
+		return strconv.Itoa(int(l))
+	}
+	return logLevelNames[l]
+}
+
+// ParseLogLevel returns the level named debug, info, warn, error or off
+func ParseLogLevel(name string) (LogLevel, error) {
+	for i, levelName := range logLevelNames {
+		if strings.EqualFold(name, levelName) {
+			return LogLevel(i), nil
+		}
+	}
+	return LogLevelOff, fmt.Errorf("unknown log level '%s', expected one of %s", name, strings.Join(logLevelNames, ", "))
+}
+
+const redacted = "[REDACTED]"
+
+// redactedHeaders hold credentials in requests to CredHub and auth servers
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+}
+
+// redactedFields hold credential values and tokens in request and response bodies
+var redactedFields = map[string]bool{
+	"value":         true,
+	"password":      true,
+	"private_key":   true,
+	"passphrase":    true,
+	"client_secret": true,
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+}
+
+// Logger writes the requests of CredHub clients to Out as JSON lines, one per event with at least
+// the time, level and message of the event. Events below Level are discarded.
+//
+// Requests are logged at the info level with their status and duration, together with their
+// headers and bodies at the debug level. Credentials in headers and bodies are redacted unless
+// Unredacted is set.
+type Logger struct {
+	Out        io.Writer
+	Level      LogLevel
+	Unredacted bool
+
+	mu sync.Mutex
+}
+
+// NewLogger returns a logger writing events of at least the given level to out
+func NewLogger(out io.Writer, level LogLevel) *Logger {
+	return &Logger{Out: out, Level: level}
+}
+
+var (
+	environmentLoggers   = map[string]*Logger{}
+	environmentLoggersMu sync.Mutex
+)
+
+// LoggerFromEnvironment returns the logger configured by CREDHUB_LOG_LEVEL, CREDHUB_LOG_FILE and
+// CREDHUB_LOG_UNREDACTED, or nil when logging is off. CREDHUB_DEBUG=true and unredacted logging
+// without a level set the debug level. Events are written to stderr unless a log file is set.
+// Clients with the same configuration share one logger.
+func LoggerFromEnvironment() (*Logger, error) {
+	levelName := os.Getenv("CREDHUB_LOG_LEVEL")
+	unredacted := os.Getenv("CREDHUB_LOG_UNREDACTED") == "true"
+	if levelName == "" && (os.Getenv("CREDHUB_DEBUG") == "true" || unredacted) {
+		levelName = "debug"
+	}
+	if levelName == "" {
+		return nil, nil
+	}
+
+	level, err := ParseLogLevel(levelName)
+	if err != nil || level == LogLevelOff {
+		return nil, err
+	}
+
+	file := os.Getenv("CREDHUB_LOG_FILE")
+	key := fmt.Sprintf("%s|%s|%t", level, file, unredacted)
+
+	environmentLoggersMu.Lock()
+	defer environmentLoggersMu.Unlock()
+	if logger, ok := environmentLoggers[key]; ok {
+		return logger, nil
+	}
+
+	var out io.Writer = os.Stderr
+	if file != "" {
+		if out, err = os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600); err != nil {
+			return nil, fmt.Errorf("open log file: %s", err)
+		}
+	}
+
+	logger := NewLogger(out, level)
+	logger.Unredacted = unredacted
+	if unredacted {
+		logger.Log(LogLevelWarn, "logging unredacted requests and responses, the log contains credentials", nil)
+	}
+	environmentLoggers[key] = logger
+	return logger, nil
+}
+
+// Enabled reports whether events of the level are logged
+func (l *Logger) Enabled(level LogLevel) bool {
+	return l != nil && level >= l.Level && level < LogLevelOff
+}
+
+// Log writes an event with the fields, unless its level is disabled
+func (l *Logger) Log(level LogLevel, message string, fields map[string]interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	event := map[string]interface{}{}
+	for name, value := range fields {
+		event[name] = value
+	}
+	event["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	event["level"] = level.String()
+	event["message"] = message
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		line, _ = json.Marshal(map[string]interface{}{"time": event["time"], "level": "error", "message": "the log event could not be encoded: " + err.Error()})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Out.Write(append(line, '\n'))
+}
+
+// headers returns the headers for an event, with credentials redacted
+func (l *Logger) headers(header http.Header) map[string]string {
+	headers := map[string]string{}
+	for name, values := range header {
+		if redactedHeaders[http.CanonicalHeaderKey(name)] && !l.Unredacted {
+			headers[name] = redacted
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// body returns the body for an event. JSON bodies are logged as JSON with credential values
+// redacted, other bodies only when redaction is off.
+func (l *Logger) body(body []byte) interface{} {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	var parsed interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&parsed); err != nil {
+		if l.Unredacted {
+			return string(body)
+		}
+		return fmt.Sprintf("[REDACTED %d bytes]", len(body))
+	}
+
+	if l.Unredacted {
+		return parsed
+	}
+	return redact(parsed)
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for name, field := range v {
+			if redactedFields[strings.ToLower(name)] {
+				result[name] = redacted
+			} else {
+				result[name] = redact(field)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, element := range v {
+			result[i] = redact(element)
+		}
+		return result
+	case string:
+		if strings.Contains(v, "PRIVATE KEY-----") {
+			return redacted
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// newRequestID returns a random ID correlating the events of a request
+func newRequestID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,112 @@
+package credhub
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// requestIDHeader carries the ID correlating the log events of a request, so that they can be
+// joined with the logs of the server
+const requestIDHeader = "X-Request-Id"
+
+// loggingTransport logs the requests of an http.Client as they are sent, after auth strategies
+// have set their headers, and sends the ID of each request in the X-Request-Id header
+type loggingTransport struct {
+	logger *Logger
+	next   http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	// a RoundTripper must not modify the request it is given
+	req = req.Clone(req.Context())
+	requestID := req.Header.Get(requestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+		req.Header.Set(requestIDHeader, requestID)
+	}
+
+	if t.logger.Enabled(LogLevelDebug) {
+		var body []byte
+		if req.Body != nil && req.Body != http.NoBody {
+			var err error
+			body, err = ioutil.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+		t.logRequest(requestID, req, body)
+	}
+
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	t.logResponse(requestID, req, resp, err, time.Since(start))
+
+	return resp, err
+}
+
+// logRequest logs the headers and body of a request at the debug level
+func (t *loggingTransport) logRequest(requestID string, req *http.Request, body []byte) {
+	fields := map[string]interface{}{
+		"request_id": requestID,
+		"method":     req.Method,
+		"url":        req.URL.String(),
+		"headers":    t.logger.headers(req.Header),
+	}
+	if body := t.logger.body(body); body != nil {
+		fields["body"] = body
+	}
+	t.logger.Log(LogLevelDebug, "request", fields)
+}
+
+// logResponse logs the status and duration of a request at the info level, or the warn level for
+// error statuses, adding the headers and body of the response at the debug level. Failed requests
+// are logged at the error level.
+func (t *loggingTransport) logResponse(requestID string, req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	if !t.logger.Enabled(LogLevelError) {
+		return
+	}
+
+	fields := map[string]interface{}{
+		"request_id":  requestID,
+		"method":      req.Method,
+		"url":         req.URL.String(),
+		"duration_ms": float64(duration.Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		fields["error"] = err.Error()
+		t.logger.Log(LogLevelError, "request failed", fields)
+		return
+	}
+
+	fields["status"] = resp.StatusCode
+	if t.logger.Enabled(LogLevelDebug) {
+		fields["headers"] = t.logger.headers(resp.Header)
+		if resp.Body != nil {
+			body, readErr := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if readErr != nil {
+				fields["body_error"] = readErr.Error()
+			}
+			if body := t.logger.body(body); body != nil {
+				fields["body"] = body
+			}
+		}
+	}
+
+	level := LogLevelInfo
+	if resp.StatusCode >= 400 {
+		level = LogLevelWarn
+	}
+	t.logger.Log(level, "response", fields)
+}
@@ -31,6 +31,12 @@ func New(target string, options ...Option) (*CredHub, error) {
 		}
 	}
 
+	if credhub.logger == nil {
+		if credhub.logger, err = LoggerFromEnvironment(); err != nil {
+			return nil, err
+		}
+	}
+
 	credhub.Auth, err = credhub.authBuilder(credhub)
 	if err != nil {
 		return nil, err
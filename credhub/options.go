@@ -1,7 +1,6 @@
 package credhub
 
 import (
-	"crypto/tls"
 	"crypto/x509"
 	"errors"
 	"net/url"
@@ -78,13 +77,63 @@ func SkipTLSValidation(skipTLSvalidation bool) Option {
 
 // ClientCert will use a certificate for authentication
 func ClientCert(certificate, key string) Option {
+	return ClientCertWithPassphrase(certificate, key, "")
+}
+
+// ClientCertWithPassphrase will use a certificate for authentication whose PEM encoded private key
+// is encrypted with the given passphrase
+func ClientCertWithPassphrase(certificate, key, passphrase string) Option {
+	return func(c *CredHub) error {
+		cert, err := loadClientCertificate(certificate, key, passphrase)
+		if err != nil {
+			return err
+		}
+		c.clientCertificate = cert
+
+		return nil
+	}
+}
+
+// Proxy specifies the proxy for connections to the CredHub server and the auth server, overriding
+// CREDHUB_PROXY and the standard proxy environment variables. HTTP and HTTPS proxies are used with
+// CONNECT requests and may include credentials for proxy authentication in the URL, socks5 and
+// ssh+socks5 proxies are used as in CREDHUB_PROXY. Connections to hosts matching noProxy, a comma
+// separated list in the format of NO_PROXY, bypass the proxy.
+func Proxy(proxyURL, noProxy string) Option {
 	return func(c *CredHub) error {
-		cert, err := tls.LoadX509KeyPair(certificate, key)
+		if proxyURL == "" {
+			return nil
+		}
+
+		parsed, err := url.Parse(proxyURL)
 		if err != nil {
 			return err
 		}
-		c.clientCertificate = &cert
+		if parsed.Host == "" {
+			return errors.New("proxy URL must include a host")
+		}
+
+		c.proxyURL = proxyURL
+		c.noProxy = noProxy
+		return nil
+	}
+}
 
+// SSHTunnels sets the dialer opening the SSH tunnels of ssh+socks5 proxies, which may prompt for
+// the passphrases of encrypted private keys and share tunnels across processes with a control
+// master.
+func SSHTunnels(dialer *SSHTunnelDialer) Option {
+	return func(c *CredHub) error {
+		c.sshTunnels = dialer
+		return nil
+	}
+}
+
+// SetLogger sets the logger of requests, overriding the logger configured in the environment
+// (see LoggerFromEnvironment)
+func SetLogger(logger *Logger) Option {
+	return func(c *CredHub) error {
+		c.logger = logger
 		return nil
 	}
 }
@@ -0,0 +1,59 @@
+package credhub
+
+import (
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
+)
+
+// GeneratePasswordWithPolicy generates a password credential satisfying the provided policy.
+//
+// When the server can express the policy the password is generated by the server and the
+// generated value is validated against the policy. Otherwise, or when the value returned by the
+// server does not satisfy the policy, the password is generated locally and stored with
+// SetPassword, so the latest version always satisfies the policy.
+//
+// NoOverwrite returns an existing credential unchanged. Converge keeps an existing credential
+// only when it satisfies the policy.
+func (ch *CredHub) GeneratePasswordWithPolicy(name string, policy generate.PasswordPolicy, overwrite Mode, options ...GenerateOption) (credentials.Password, error) {
+	var cred credentials.Password
+
+	if overwrite != Overwrite {
+		existing, err := ch.GetLatestPassword(name)
+		if err == nil && (overwrite == NoOverwrite || policy.Validate(string(existing.Value)) == nil) {
+			return existing, nil
+		}
+		if _, notFound := err.(*NotFoundError); err != nil && !notFound {
+			return cred, err
+		}
+	}
+
+	if params, ok := policy.ServerParameters(); ok {
+		if err := ch.generateCredential(name, "password", params, Overwrite, &cred, options...); err != nil {
+			return cred, err
+		}
+
+		if err := policy.Validate(string(cred.Value)); err == nil {
+			return cred, nil
+		}
+	}
+
+	var generateOptions GenerateOptions
+	for _, option := range options {
+		if err := option(&generateOptions); err != nil {
+			return cred, err
+		}
+	}
+
+	value, err := policy.Generate()
+	if err != nil {
+		return cred, err
+	}
+
+	withMetadata := func(s *SetOptions) error {
+		s.Metadata = generateOptions.Metadata
+		return nil
+	}
+
+	return ch.SetPassword(name, values.Password(value), withMetadata)
+}
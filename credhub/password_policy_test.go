@@ -0,0 +1,148 @@
+package credhub_test
+
+import (
+	"encoding/json"
+	"net/http"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("GeneratePasswordWithPolicy()", func() {
+	var (
+		server *ghttp.Server
+		ch     *CredHub
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		var err error
+		ch, err = New(server.URL(), ServerVersion("2.6.0"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the server can express the policy", func() {
+		It("generates the password on the server", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/api/v1/data"),
+				ghttp.VerifyJSON(`{"name": "/example-password", "type": "password", "parameters": {"length": 4, "include_special": true}, "overwrite": true}`),
+				ghttp.RespondWith(http.StatusOK, `{"name": "/example-password", "type": "password", "value": "aB1!"}`),
+			))
+
+			cred, err := ch.GeneratePasswordWithPolicy("/example-password", generate.PasswordPolicy{Length: 4, MinSpecial: 1}, Overwrite)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(cred.Value)).To(Equal("aB1!"))
+		})
+
+		It("generates locally and sets the password when the generated value does not satisfy the policy", func() {
+			policy := generate.PasswordPolicy{Length: 4, MinUpper: 1}
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `{"name": "/example-password", "type": "password", "value": "abc1"}`),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/api/v1/data"),
+					func(w http.ResponseWriter, r *http.Request) {
+						var request map[string]interface{}
+						Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+						Expect(policy.Validate(request["value"].(string))).To(Succeed())
+
+						w.Write([]byte(`{"name": "/example-password", "type": "password", "value": "` + request["value"].(string) + `"}`))
+					},
+				),
+			)
+
+			cred, err := ch.GeneratePasswordWithPolicy("/example-password", policy, Overwrite)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy.Validate(string(cred.Value))).To(Succeed())
+		})
+
+		It("regenerates an existing password that does not satisfy the policy when converging", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/api/v1/data", "name=/example-password&current=true"),
+					ghttp.RespondWith(http.StatusOK, `{"data": [{"name": "/example-password", "type": "password", "value": "abc"}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/api/v1/data"),
+					ghttp.VerifyJSON(`{"name": "/example-password", "type": "password", "parameters": {"length": 4, "include_special": true}, "overwrite": true}`),
+					ghttp.RespondWith(http.StatusOK, `{"name": "/example-password", "type": "password", "value": "aB1!"}`),
+				),
+			)
+
+			cred, err := ch.GeneratePasswordWithPolicy("/example-password", generate.PasswordPolicy{Length: 4, MinSpecial: 1}, Converge)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(cred.Value)).To(Equal("aB1!"))
+		})
+
+		It("keeps an existing password that satisfies the policy when converging", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"data": [{"name": "/example-password", "type": "password", "value": "ab!c"}]}`))
+
+			cred, err := ch.GeneratePasswordWithPolicy("/example-password", generate.PasswordPolicy{Length: 4, MinSpecial: 1}, Converge)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(cred.Value)).To(Equal("ab!c"))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Context("when the server cannot express the policy", func() {
+		policy := generate.PasswordPolicy{Length: 12, MinNumber: 5, ForbiddenCharacters: "0"}
+
+		It("generates the password locally and sets it with its metadata", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/api/v1/data"),
+				func(w http.ResponseWriter, r *http.Request) {
+					var request map[string]interface{}
+					Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+					Expect(request["name"]).To(Equal("/example-password"))
+					Expect(request["type"]).To(Equal("password"))
+					Expect(request["metadata"]).To(Equal(map[string]interface{}{"owner": "ops"}))
+					Expect(policy.Validate(request["value"].(string))).To(Succeed())
+
+					w.Write([]byte(`{"name": "/example-password", "type": "password", "value": "` + request["value"].(string) + `"}`))
+				},
+			))
+
+			withMetadata := func(g *GenerateOptions) error {
+				g.Metadata = map[string]interface{}{"owner": "ops"}
+				return nil
+			}
+
+			cred, err := ch.GeneratePasswordWithPolicy("/example-password", policy, Overwrite, withMetadata)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy.Validate(string(cred.Value))).To(Succeed())
+		})
+
+		It("returns the existing password when not overwriting", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/api/v1/data", "name=/example-password&current=true"),
+				ghttp.RespondWith(http.StatusOK, `{"data": [{"name": "/example-password", "type": "password", "value": "existing"}]}`),
+			))
+
+			cred, err := ch.GeneratePasswordWithPolicy("/example-password", policy, NoOverwrite)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(cred.Value)).To(Equal("existing"))
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("sets a new password when not overwriting and the credential does not exist", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusNotFound, `{"error": "The request could not be completed because the credential does not exist or you do not have sufficient authorization."}`),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PUT", "/api/v1/data"),
+					ghttp.RespondWith(http.StatusOK, `{"name": "/example-password", "type": "password", "value": "new"}`),
+				),
+			)
+
+			cred, err := ch.GeneratePasswordWithPolicy("/example-password", policy, NoOverwrite)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(cred.Value)).To(Equal("new"))
+		})
+	})
+})
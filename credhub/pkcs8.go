@@ -0,0 +1,173 @@
+package credhub
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA224 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 8}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts the DER of a PKCS #8 EncryptedPrivateKeyInfo encrypted with PBES2, the
+// scheme used by OpenSSL, and returns the DER of the PKCS #8 PrivateKeyInfo. PBKDF2 and scrypt
+// key derivation with AES-CBC or 3DES-CBC encryption are supported.
+func decryptPKCS8(der []byte, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("the encrypted private key could not be parsed: %v", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("the encryption algorithm %s of the private key is not supported, only PBES2 is", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("the PBES2 parameters of the private key could not be parsed: %v", err)
+	}
+
+	newCipher, keyLength, err := pbes2Cipher(params.EncryptionScheme.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("the IV of the private key could not be parsed: %v", err)
+	}
+
+	key, err := pbes2Key(params.KeyDerivationFunc, passphrase, keyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(info.EncryptedData) == 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, ErrClientKeyPassphrase
+	}
+
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	// a wrong passphrase shows in the padding or, if the padding happens to be valid, in the key
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > block.BlockSize() {
+		return nil, ErrClientKeyPassphrase
+	}
+	for _, b := range decrypted[len(decrypted)-padding:] {
+		if int(b) != padding {
+			return nil, ErrClientKeyPassphrase
+		}
+	}
+	decrypted = decrypted[:len(decrypted)-padding]
+
+	if _, err := x509.ParsePKCS8PrivateKey(decrypted); err != nil {
+		return nil, ErrClientKeyPassphrase
+	}
+	return decrypted, nil
+}
+
+func pbes2Cipher(algorithm asn1.ObjectIdentifier) (func([]byte) (cipher.Block, error), int, error) {
+	switch {
+	case algorithm.Equal(oidAES128CBC):
+		return aes.NewCipher, 16, nil
+	case algorithm.Equal(oidAES192CBC):
+		return aes.NewCipher, 24, nil
+	case algorithm.Equal(oidAES256CBC):
+		return aes.NewCipher, 32, nil
+	case algorithm.Equal(oidDESEDE3CBC):
+		return des.NewTripleDESCipher, 24, nil
+	}
+	return nil, 0, fmt.Errorf("the cipher %s of the private key is not supported", algorithm)
+}
+
+func pbes2Key(kdf pkix.AlgorithmIdentifier, passphrase []byte, keyLength int) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("the PBKDF2 parameters of the private key could not be parsed: %v", err)
+		}
+		prf, err := pbkdf2PRF(params.PRF.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(passphrase, params.Salt, params.IterationCount, keyLength, prf), nil
+	case kdf.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("the scrypt parameters of the private key could not be parsed: %v", err)
+		}
+		return scrypt.Key(passphrase, params.Salt, params.CostParameter, params.BlockSize, params.ParallelizationParameter, keyLength)
+	}
+	return nil, fmt.Errorf("the key derivation function %s of the private key is not supported", kdf.Algorithm)
+}
+
+// pbkdf2PRF returns the hash of the HMAC used by PBKDF2, HMAC-SHA1 when none is given
+func pbkdf2PRF(algorithm asn1.ObjectIdentifier) (func() hash.Hash, error) {
+	switch {
+	case len(algorithm) == 0, algorithm.Equal(oidHMACWithSHA1):
+		return sha1.New, nil
+	case algorithm.Equal(oidHMACWithSHA224):
+		return sha256.New224, nil
+	case algorithm.Equal(oidHMACWithSHA256):
+		return sha256.New, nil
+	case algorithm.Equal(oidHMACWithSHA384):
+		return sha512.New384, nil
+	case algorithm.Equal(oidHMACWithSHA512):
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("the PBKDF2 function %s of the private key is not supported", algorithm)
+}
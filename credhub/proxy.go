@@ -0,0 +1,75 @@
+package credhub
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// HTTPProxyFunc returns a proxy function for http.Transport sending requests through the HTTP or
+// HTTPS proxy at proxyURL, except requests to hosts matching noProxy and to loopback addresses.
+// Credentials in the proxy URL are sent to the proxy for proxy authentication.
+func HTTPProxyFunc(proxyURL, noProxy string) func(*http.Request) (*url.URL, error) {
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    noProxy,
+	}).ProxyFunc()
+
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
+
+// configureProxy routes the connections of the transport through the proxy at proxyURL, or the
+// proxy in CREDHUB_PROXY when proxyURL is empty. HTTP and HTTPS proxies are used with CONNECT
+// requests, SOCKS5 proxies by dialing through them. Without either, the proxy is selected by the
+// standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables. The tunnels of ssh+socks5
+// proxies are opened by sshTunnels, or by a dialer without passphrase prompts or control master
+// when it is nil.
+func configureProxy(transport *http.Transport, proxyURL, noProxy string, sshTunnels *SSHTunnelDialer) {
+	if proxyURL == "" {
+		proxyURL = os.Getenv("CREDHUB_PROXY")
+		noProxy = noProxyFromEnvironment()
+	}
+
+	dialer := DialFunc((&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).Dial)
+
+	if isHTTPProxy(proxyURL) {
+		transport.Proxy = HTTPProxyFunc(proxyURL, noProxy)
+		transport.Dial = dialer
+		return
+	}
+
+	if sshTunnels == nil {
+		sshTunnels = &SSHTunnelDialer{}
+	}
+
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.Dial = SOCKS5DialFunc(proxyURL, noProxy, dialer, sshTunnels)
+}
+
+// usesProxy reports whether connections go through a proxy configured for CredHub rather than
+// one of the standard proxy environment variables
+func usesProxy(proxyURL string) bool {
+	return proxyURL != "" || os.Getenv("CREDHUB_PROXY") != ""
+}
+
+func isHTTPProxy(proxyURL string) bool {
+	parsed, err := url.Parse(proxyURL)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https")
+}
+
+func noProxyFromEnvironment() string {
+	if noProxy := os.Getenv("NO_PROXY"); noProxy != "" {
+		return noProxy
+	}
+	return os.Getenv("no_proxy")
+}
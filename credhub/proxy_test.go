@@ -0,0 +1,228 @@
+package credhub_test
+
+import (
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/ghttp"
+)
+
+// fakeProxy is an HTTP proxy which forwards requests and tunnels CONNECT requests for any host to
+// the port of the request on the loopback interface
+type fakeProxy struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	requests      []string
+	authorization string
+	credentials   string
+}
+
+func newFakeProxy() *fakeProxy {
+	p := &fakeProxy{}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+	return p
+}
+
+func (p *fakeProxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.requests = append(p.requests, r.Method+" "+r.Host)
+	p.authorization = r.Header.Get("Proxy-Authorization")
+	p.mu.Unlock()
+
+	if p.credentials != "" && r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte(p.credentials)) {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+		w.WriteHeader(http.StatusProxyAuthRequired)
+		return
+	}
+
+	_, port, _ := net.SplitHostPort(r.Host)
+	target := "127.0.0.1:" + port
+
+	if r.Method == "CONNECT" {
+		upstream, err := net.Dial("tcp", target)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() {
+			io.Copy(upstream, conn)
+			upstream.Close()
+		}()
+		go func() {
+			io.Copy(conn, upstream)
+			conn.Close()
+		}()
+		return
+	}
+
+	r.URL.Host = target
+	r.RequestURI = ""
+	r.Header.Del("Proxy-Authorization")
+	response, err := http.DefaultTransport.RoundTrip(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer response.Body.Close()
+	w.WriteHeader(response.StatusCode)
+	io.Copy(w, response.Body)
+}
+
+func (p *fakeProxy) Requests() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string{}, p.requests...)
+}
+
+var _ = Describe("Proxy", func() {
+	var (
+		server *Server
+		proxy  *fakeProxy
+		target string
+	)
+
+	// hosts under .invalid never resolve, so requests only succeed through the proxy
+	targetURL := func(scheme string) string {
+		u, err := url.Parse(server.URL())
+		Expect(err).NotTo(HaveOccurred())
+		return scheme + "://credhub.invalid:" + u.Port()
+	}
+
+	BeforeEach(func() {
+		os.Unsetenv("CREDHUB_PROXY")
+		proxy = newFakeProxy()
+		server = NewTLSServer()
+		server.RouteToHandler("GET", "/info", RespondWith(http.StatusOK, `{"auth-server": {"url": "https://uaa.example.com"}}`))
+		target = targetURL("https")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("CREDHUB_PROXY")
+		proxy.Close()
+		server.Close()
+	})
+
+	It("tunnels HTTPS requests through an HTTP proxy", func() {
+		ch, err := New(target, SkipTLSValidation(true), Proxy(proxy.URL, ""))
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := ch.Info()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.AuthServer.URL).To(Equal("https://uaa.example.com"))
+		Expect(proxy.Requests()).To(Equal([]string{"CONNECT " + strings.TrimPrefix(target, "https://")}))
+	})
+
+	It("forwards HTTP requests through an HTTP proxy", func() {
+		plainServer := NewServer()
+		defer plainServer.Close()
+		plainServer.RouteToHandler("GET", "/info", RespondWith(http.StatusOK, `{"auth-server": {"url": "https://uaa.example.com"}}`))
+		u, _ := url.Parse(plainServer.URL())
+
+		ch, err := New("http://credhub.invalid:"+u.Port(), Proxy(proxy.URL, ""))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ch.Info()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proxy.Requests()).To(Equal([]string{"GET credhub.invalid:" + u.Port()}))
+	})
+
+	It("authenticates with the credentials in the proxy URL", func() {
+		proxy.credentials = "proxy-user:proxy p@ss"
+		proxyURL, _ := url.Parse(proxy.URL)
+		proxyURL.User = url.UserPassword("proxy-user", "proxy p@ss")
+
+		ch, err := New(target, SkipTLSValidation(true), Proxy(proxyURL.String(), ""))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ch.Info()
+
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns an error when the proxy requires authentication", func() {
+		proxy.credentials = "proxy-user:proxy-password"
+
+		ch, err := New(target, SkipTLSValidation(true), Proxy(proxy.URL, ""))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ch.Info()
+
+		Expect(err).To(MatchError(ContainSubstring("Proxy Authentication Required")))
+	})
+
+	It("connects directly to hosts matching the no proxy list", func() {
+		ch, err := New(target, SkipTLSValidation(true), Proxy(proxy.URL, "example.com,.invalid"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ch.Info()
+
+		Expect(err).To(HaveOccurred())
+		Expect(proxy.Requests()).To(BeEmpty())
+	})
+
+	It("uses an HTTP proxy in CREDHUB_PROXY", func() {
+		os.Setenv("CREDHUB_PROXY", proxy.URL)
+
+		ch, err := New(target, SkipTLSValidation(true))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ch.Info()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proxy.Requests()).To(HaveLen(1))
+	})
+
+	It("prefers the proxy of the option to CREDHUB_PROXY", func() {
+		os.Setenv("CREDHUB_PROXY", "http://127.0.0.1:1")
+
+		ch, err := New(target, SkipTLSValidation(true), Proxy(proxy.URL, ""))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ch.Info()
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proxy.Requests()).To(HaveLen(1))
+	})
+
+	It("rejects proxy URLs without a host", func() {
+		_, err := New(target, Proxy("proxy.example.com", ""))
+
+		Expect(err).To(MatchError("proxy URL must include a host"))
+	})
+
+	Describe("HTTPProxyFunc()", func() {
+		It("returns the proxy for hosts not matching the no proxy list", func() {
+			proxyFunc := HTTPProxyFunc("http://proxy.example.com:3128", "uaa.example.com")
+
+			request, _ := http.NewRequest("GET", "https://credhub.example.com/info", nil)
+			proxyURL, err := proxyFunc(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proxyURL.String()).To(Equal("http://proxy.example.com:3128"))
+
+			request, _ = http.NewRequest("GET", "https://uaa.example.com/oauth/token", nil)
+			proxyURL, err = proxyFunc(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proxyURL).To(BeNil())
+		})
+	})
+})
@@ -18,6 +18,11 @@ type ProxyDialer interface {
 	Dialer(string, string, string) (proxy.DialFunc, error)
 }
 
+// TunnelDialer opens the SSH tunnels of ssh+socks5 proxies, see SSHTunnelDialer
+type TunnelDialer interface {
+	Dialer(*SSHTunnel) (DialFunc, error)
+}
+
 func (f DialFunc) Dial(network, address string) (net.Conn, error) { return f(network, address) }
 
 func SOCKS5DialFuncFromEnvironment(origDialer DialFunc, socks5Proxy ProxyDialer) DialFunc {
@@ -58,30 +63,68 @@ func SOCKS5DialFuncFromEnvironment(origDialer DialFunc, socks5Proxy ProxyDialer)
 			return origDialer
 		}
 
-		var (
-			dialer proxy.DialFunc
-			mut    sync.RWMutex
-		)
-		return func(network, address string) (net.Conn, error) {
-			mut.RLock()
-			haveDialer := dialer != nil
-			mut.RUnlock()
-
-			if haveDialer {
-				return dialer(network, address)
-			}
+		return lazyDialFunc(func() (DialFunc, error) {
+			dialer, err := socks5Proxy.Dialer(username, string(proxySSHKey), proxyURL.Host)
+			return DialFunc(dialer), err
+		})
+	}
 
-			mut.Lock()
-			defer mut.Unlock()
-			if dialer == nil {
-				proxyDialer, err := socks5Proxy.Dialer(username, string(proxySSHKey), proxyURL.Host)
-				if err != nil {
-					return nil, err
-				}
-				dialer = proxyDialer
-			}
+	return proxyDialFunc(allProxy, os.Getenv("no_proxy"), origDialer)
+}
+
+// SOCKS5DialFunc returns a DialFunc connecting through the SOCKS5 proxy at allProxy, or through
+// the SSH tunnel the TunnelDialer opens for proxies with the ssh+socks5 scheme (see SSHTunnel).
+// Unlike SOCKS5DialFuncFromEnvironment, ssh+socks5 proxies may authenticate with the ssh-agent,
+// verify host keys and connect through jump hosts. Connections to hosts matching noProxy use
+// origDialer, as do all connections when allProxy is empty or invalid.
+func SOCKS5DialFunc(allProxy, noProxy string, origDialer DialFunc, tunnels TunnelDialer) DialFunc {
+	if strings.HasPrefix(allProxy, "ssh+") {
+		tunnel, err := ParseSSHTunnelURL(allProxy)
+		if err != nil {
+			return origDialer
+		}
+
+		return lazyDialFunc(func() (DialFunc, error) {
+			return tunnels.Dialer(tunnel)
+		})
+	}
+
+	return proxyDialFunc(allProxy, noProxy, origDialer)
+}
+
+// lazyDialFunc returns a DialFunc which creates the dialer it connects with on the first dial
+func lazyDialFunc(newDialer func() (DialFunc, error)) DialFunc {
+	var (
+		dialer DialFunc
+		mut    sync.RWMutex
+	)
+	return func(network, address string) (net.Conn, error) {
+		mut.RLock()
+		haveDialer := dialer != nil
+		mut.RUnlock()
+
+		if haveDialer {
 			return dialer(network, address)
 		}
+
+		mut.Lock()
+		defer mut.Unlock()
+		if dialer == nil {
+			proxyDialer, err := newDialer()
+			if err != nil {
+				return nil, err
+			}
+			dialer = proxyDialer
+		}
+		return dialer(network, address)
+	}
+}
+
+// proxyDialFunc returns a DialFunc connecting through the proxy at allProxy, except to hosts
+// matching noProxy
+func proxyDialFunc(allProxy, noProxy string, origDialer DialFunc) DialFunc {
+	if len(allProxy) == 0 {
+		return origDialer
 	}
 
 	proxyURL, err := url.Parse(allProxy)
@@ -94,7 +137,6 @@ func SOCKS5DialFuncFromEnvironment(origDialer DialFunc, socks5Proxy ProxyDialer)
 		return origDialer
 	}
 
-	noProxy := os.Getenv("no_proxy")
 	if len(noProxy) == 0 {
 		return proxy.Dial
 	}
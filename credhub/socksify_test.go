@@ -172,6 +172,96 @@ var _ = Describe("Socksify", func() {
 	})
 })
 
+var _ = Describe("SOCKS5DialFunc", func() {
+	var (
+		tunnelDialer *FakeTunnelDialer
+		origDial     credhub.DialFunc
+	)
+
+	BeforeEach(func() {
+		tunnelDialer = &FakeTunnelDialer{}
+		tunnelDialer.DialerCall.Returns.DialFunc = credhub.DialFunc(func(x, y string) (net.Conn, error) {
+			return nil, errors.New("tunnel dialer")
+		})
+		origDial = credhub.DialFunc(func(x, y string) (net.Conn, error) {
+			return nil, errors.New("original dialer")
+		})
+	})
+
+	It("returns the dialer that was passed in without a proxy", func() {
+		_, err := credhub.SOCKS5DialFunc("", "", origDial, tunnelDialer)("", "")
+		Expect(err).To(MatchError("original dialer"))
+		Expect(tunnelDialer.DialerCall.CallCount).To(Equal(0))
+	})
+
+	It("opens the tunnel of an ssh+socks5 proxy once", func() {
+		dialFunc := credhub.SOCKS5DialFunc("ssh+socks5://user@localhost:12345?private-key=/path/to/key", "", origDial, tunnelDialer)
+
+		for i := 0; i < 3; i++ {
+			_, err := dialFunc("", "")
+			Expect(err).To(MatchError("tunnel dialer"))
+		}
+		Expect(tunnelDialer.DialerCall.CallCount).To(Equal(1))
+		Expect(tunnelDialer.DialerCall.Receives.Tunnel.PrivateKeyPath).To(Equal("/path/to/key"))
+		Expect(tunnelDialer.DialerCall.Receives.Tunnel.Hops).To(Equal([]credhub.SSHHop{{User: "user", Address: "localhost:12345"}}))
+	})
+
+	It("authenticates with the ssh-agent without a private key", func() {
+		_, err := credhub.SOCKS5DialFunc("ssh+socks5://localhost:12345", "", origDial, tunnelDialer)("", "")
+
+		Expect(err).To(MatchError("tunnel dialer"))
+		Expect(tunnelDialer.DialerCall.Receives.Tunnel.PrivateKeyPath).To(BeEmpty())
+		Expect(tunnelDialer.DialerCall.Receives.Tunnel.Hops).To(Equal([]credhub.SSHHop{{User: "jumpbox", Address: "localhost:12345"}}))
+	})
+
+	It("connects through the jump hosts in order", func() {
+		_, err := credhub.SOCKS5DialFunc("ssh+socks5://user@localhost:12345?jump=bastion&jump=admin@10.0.0.5:2222", "", origDial, tunnelDialer)("", "")
+
+		Expect(err).To(MatchError("tunnel dialer"))
+		Expect(tunnelDialer.DialerCall.Receives.Tunnel.Hops).To(Equal([]credhub.SSHHop{
+			{User: "jumpbox", Address: "bastion:22"},
+			{User: "admin", Address: "10.0.0.5:2222"},
+			{User: "user", Address: "localhost:12345"},
+		}))
+	})
+
+	It("returns the error of the tunnel dialer", func() {
+		tunnelDialer.DialerCall.Returns.Error = errors.New("the host key of localhost:12345 is not in known_hosts")
+
+		_, err := credhub.SOCKS5DialFunc("ssh+socks5://localhost:12345", "", origDial, tunnelDialer)("", "")
+
+		Expect(err).To(MatchError("the host key of localhost:12345 is not in known_hosts"))
+	})
+
+	It("returns the dialer that was passed in for invalid proxy URLs", func() {
+		_, err := credhub.SOCKS5DialFunc("foo://cannot-start-with-colon", "", origDial, tunnelDialer)("", "")
+		Expect(err).To(MatchError("original dialer"))
+	})
+})
+
+type FakeTunnelDialer struct {
+	DialerCall struct {
+		CallCount int
+		Receives  struct {
+			Tunnel *credhub.SSHTunnel
+		}
+		Returns struct {
+			DialFunc credhub.DialFunc
+			Error    error
+		}
+	}
+	mut sync.Mutex
+}
+
+func (p *FakeTunnelDialer) Dialer(tunnel *credhub.SSHTunnel) (credhub.DialFunc, error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.DialerCall.CallCount++
+	p.DialerCall.Receives.Tunnel = tunnel
+
+	return p.DialerCall.Returns.DialFunc, p.DialerCall.Returns.Error
+}
+
 type FakeProxyDialer struct {
 	DialerCall struct {
 		CallCount int
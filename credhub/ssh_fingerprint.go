@@ -0,0 +1,39 @@
+package credhub
+
+import (
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+)
+
+// fillSSHFingerprint computes the fingerprint of the public key when the server leaves it
+// empty, since older servers only report fingerprints for RSA SSH keys.
+func fillSSHFingerprint(cred *credentials.SSH) {
+	if cred.Value.PublicKeyFingerprint != "" {
+		return
+	}
+
+	if fingerprint, err := generate.SSHFingerprint(cred.Value.PublicKey); err == nil {
+		cred.Value.PublicKeyFingerprint = fingerprint
+	}
+}
+
+// fillCredentialSSHFingerprint fills the fingerprint of untyped SSH credentials like fillSSHFingerprint
+func fillCredentialSSHFingerprint(cred *credentials.Credential) {
+	if cred.Type != "ssh" {
+		return
+	}
+
+	value, ok := cred.Value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if fingerprint, _ := value["public_key_fingerprint"].(string); fingerprint != "" {
+		return
+	}
+
+	publicKey, _ := value["public_key"].(string)
+	if fingerprint, err := generate.SSHFingerprint(publicKey); err == nil {
+		value["public_key_fingerprint"] = fingerprint
+	}
+}
@@ -0,0 +1,593 @@
+package credhub
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultSSHUser           = "jumpbox"
+	defaultSSHControlPersist = 10 * time.Minute
+	sshDialTimeout           = 30 * time.Second
+	sshKeepAliveInterval     = 30 * time.Second
+)
+
+// SSHTunnel is a tunnel through a chain of SSH jump hosts, configured with an ssh+socks5 proxy URL
+//
+//	ssh+socks5://user@jumpbox:22?private-key=/path/to/key&jump=user@bastion:22
+//
+// which accepts the query parameters
+//
+//	private-key      private key file, optional when SSH_AUTH_SOCK points to an ssh-agent
+//	jump             jump host passed before the host of the URL, repeated in order for longer chains
+//	known-hosts      known_hosts file verifying the host keys, repeatable, ~/.ssh/known_hosts by default
+//	insecure-skip-host-key-verification
+//	                 accept any host key. Not recommended!
+//	control-path     unix socket sharing the tunnel across processes, "none" to not share it
+//	control-persist  how long a shared tunnel stays open while unused, 10m by default
+type SSHTunnel struct {
+	URL                             string
+	Hops                            []SSHHop
+	PrivateKeyPath                  string
+	KnownHostsFiles                 []string
+	InsecureSkipHostKeyVerification bool
+	ControlPath                     string
+	ControlPersist                  time.Duration
+}
+
+// SSHHop is a host of an SSH tunnel
+type SSHHop struct {
+	User    string
+	Address string
+}
+
+// PassphrasePrompt returns the passphrase of an encrypted private key
+type PassphrasePrompt func(keyPath string) (string, error)
+
+// ParseSSHTunnelURL returns the tunnel of an ssh+socks5 proxy URL
+func ParseSSHTunnelURL(proxyURL string) (*SSHTunnel, error) {
+	parsed, err := url.Parse(strings.TrimPrefix(proxyURL, "ssh+"))
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Host == "" {
+		return nil, errors.New("the ssh+socks5 proxy URL must include a host")
+	}
+
+	query, err := url.ParseQuery(parsed.RawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	tunnel := &SSHTunnel{
+		URL:            proxyURL,
+		PrivateKeyPath: query.Get("private-key"),
+		ControlPath:    defaultSSHControlPath(proxyURL),
+		ControlPersist: defaultSSHControlPersist,
+	}
+
+	for _, jump := range query["jump"] {
+		user, address := "", jump
+		if i := strings.LastIndex(jump, "@"); i >= 0 {
+			user, address = jump[:i], jump[i+1:]
+		}
+		tunnel.Hops = append(tunnel.Hops, newSSHHop(user, address))
+	}
+	user := ""
+	if parsed.User != nil {
+		user = parsed.User.Username()
+	}
+	tunnel.Hops = append(tunnel.Hops, newSSHHop(user, parsed.Host))
+
+	tunnel.KnownHostsFiles = query["known-hosts"]
+	if len(tunnel.KnownHostsFiles) == 0 {
+		if home, err := os.UserHomeDir(); err == nil {
+			tunnel.KnownHostsFiles = []string{filepath.Join(home, ".ssh", "known_hosts")}
+		}
+	}
+
+	if insecure := query.Get("insecure-skip-host-key-verification"); insecure != "" {
+		if tunnel.InsecureSkipHostKeyVerification, err = strconv.ParseBool(insecure); err != nil {
+			return nil, fmt.Errorf("invalid insecure-skip-host-key-verification: %s", err)
+		}
+	}
+
+	if controlPath, ok := query["control-path"]; ok {
+		tunnel.ControlPath = controlPath[0]
+		if tunnel.ControlPath == "none" {
+			tunnel.ControlPath = ""
+		}
+	}
+	if persist := query.Get("control-persist"); persist != "" {
+		if tunnel.ControlPersist, err = time.ParseDuration(persist); err != nil {
+			return nil, fmt.Errorf("invalid control-persist: %s", err)
+		}
+	}
+
+	return tunnel, nil
+}
+
+func newSSHHop(user, address string) SSHHop {
+	if user == "" {
+		user = defaultSSHUser
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(strings.Trim(address, "[]"), "22")
+	}
+	return SSHHop{User: user, Address: address}
+}
+
+// defaultSSHControlPath returns a control socket per proxy URL in a directory only accessible by
+// the current user, or no control socket where that directory cannot be created
+func defaultSSHControlPath(proxyURL string) string {
+	dir := sshControlDir()
+	if dir == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(proxyURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".sock")
+}
+
+// Connect opens the tunnel, connecting to each host through the previous one. Clients
+// authenticate with the private key, prompting for its passphrase when it is encrypted, and with
+// the keys of the ssh-agent in SSH_AUTH_SOCK.
+func (t *SSHTunnel) Connect(prompt PassphrasePrompt) (*ssh.Client, error) {
+	auth, closeAgent, err := t.authMethod(prompt)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAgent()
+
+	hostKeyCallback, err := t.hostKeyCallback()
+	if err != nil {
+		return nil, err
+	}
+
+	var clients []*ssh.Client
+	closeAll := func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			clients[i].Close()
+		}
+	}
+
+	for _, hop := range t.Hops {
+		config := &ssh.ClientConfig{
+			User:            hop.User,
+			Auth:            []ssh.AuthMethod{auth},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         sshDialTimeout,
+		}
+
+		var conn net.Conn
+		if len(clients) == 0 {
+			conn, err = net.DialTimeout("tcp", hop.Address, sshDialTimeout)
+		} else {
+			conn, err = clients[len(clients)-1].Dial("tcp", hop.Address)
+		}
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("ssh dial %s: %s", hop.Address, err)
+		}
+
+		c, chans, reqs, err := ssh.NewClientConn(conn, hop.Address, config)
+		if err != nil {
+			conn.Close()
+			closeAll()
+			return nil, fmt.Errorf("ssh dial %s: %s", hop.Address, err)
+		}
+		clients = append(clients, ssh.NewClient(c, chans, reqs))
+	}
+
+	client := clients[len(clients)-1]
+	go keepAlive(client)
+	go func() {
+		client.Wait()
+		closeAll()
+	}()
+
+	return client, nil
+}
+
+func (t *SSHTunnel) authMethod(prompt PassphrasePrompt) (ssh.AuthMethod, func(), error) {
+	var signers []ssh.Signer
+	if t.PrivateKeyPath != "" {
+		signer, err := t.privateKey(prompt)
+		if err != nil {
+			return nil, nil, err
+		}
+		signers = append(signers, signer)
+	}
+
+	var agentClient agent.ExtendedAgent
+	closeAgent := func() {}
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			agentClient = agent.NewClient(conn)
+			closeAgent = func() { conn.Close() }
+		}
+	}
+
+	if len(signers) == 0 && agentClient == nil {
+		return nil, nil, errors.New("the ssh+socks5 proxy requires a private-key or an ssh-agent in SSH_AUTH_SOCK")
+	}
+
+	// all keys are offered by one method, clients only try one method of each type
+	return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		if agentClient == nil {
+			return signers, nil
+		}
+		agentSigners, err := agentClient.Signers()
+		if err != nil {
+			return signers, nil
+		}
+		return append(append([]ssh.Signer{}, signers...), agentSigners...), nil
+	}), closeAgent, nil
+}
+
+func (t *SSHTunnel) privateKey(prompt PassphrasePrompt) (ssh.Signer, error) {
+	key, err := ioutil.ReadFile(t.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("read private key: %s", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		if prompt == nil {
+			return nil, fmt.Errorf("the private key %s is encrypted and no passphrase was provided", t.PrivateKeyPath)
+		}
+		passphrase, err := prompt(t.PrivateKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("decrypt private key: %s", err)
+		}
+		return signer, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %s", err)
+	}
+	return signer, nil
+}
+
+// passphrase returns the passphrase of the private key, prompting for it only when the key is
+// encrypted
+func (t *SSHTunnel) passphrase(prompt PassphrasePrompt) (string, error) {
+	if t.PrivateKeyPath == "" {
+		return "", nil
+	}
+
+	var passphrase string
+
+	_, err := t.privateKey(func(keyPath string) (string, error) {
+		if prompt == nil {
+			return "", fmt.Errorf("the private key %s is encrypted and no passphrase was provided", keyPath)
+		}
+		var err error
+		passphrase, err = prompt(keyPath)
+		return passphrase, err
+	})
+	return passphrase, err
+}
+
+func (t *SSHTunnel) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if t.InsecureSkipHostKeyVerification {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	var files []string
+	for _, file := range t.KnownHostsFiles {
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no known_hosts file to verify the host keys of the ssh+socks5 proxy, add the host keys to ~/.ssh/known_hosts or set known-hosts")
+	}
+
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return nil, fmt.Errorf("read known_hosts: %s", err)
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		if keyErr, ok := err.(*knownhosts.KeyError); ok {
+			if len(keyErr.Want) == 0 {
+				return fmt.Errorf("the host key of %s is not in known_hosts", hostname)
+			}
+			return fmt.Errorf("the host key of %s does not match known_hosts", hostname)
+		}
+		return err
+	}, nil
+}
+
+func keepAlive(client *ssh.Client) {
+	done := make(chan struct{})
+	go func() {
+		client.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(sshKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				client.Close()
+				return
+			}
+		}
+	}
+}
+
+var errSSHControlSocketInUse = errors.New("the control socket is in use")
+
+// ListenControlSocket listens on the control socket of the tunnel, replacing stale sockets. It
+// returns an error when another process already serves the tunnel on it.
+func (t *SSHTunnel) ListenControlSocket() (net.Listener, error) {
+	if t.ControlPath == "" {
+		return nil, errors.New("the tunnel has no control socket")
+	}
+
+	if _, err := os.Stat(t.ControlPath); err == nil {
+		if conn, err := net.Dial("unix", t.ControlPath); err == nil {
+			conn.Close()
+			return nil, errSSHControlSocketInUse
+		}
+		os.Remove(t.ControlPath)
+	}
+
+	return net.Listen("unix", t.ControlPath)
+}
+
+// ServeControlSocket shares the client with other processes on the listener until the client
+// disconnects or no connection used it for ControlPersist
+func (t *SSHTunnel) ServeControlSocket(listener net.Listener, client *ssh.Client) error {
+	var (
+		mu     sync.Mutex
+		active int
+	)
+	idle := time.AfterFunc(t.ControlPersist, func() { listener.Close() })
+	go func() {
+		client.Wait()
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return nil
+		}
+
+		mu.Lock()
+		active++
+		idle.Stop()
+		mu.Unlock()
+
+		go func() {
+			serveControlConnection(conn, client)
+
+			mu.Lock()
+			defer mu.Unlock()
+			active--
+			if active == 0 {
+				idle.Reset(t.ControlPersist)
+			}
+		}()
+	}
+}
+
+// serveControlConnection reads the network and address to dial as "network address\n", replies
+// "ok\n" or "error: reason\n" and then relays the connection
+func serveControlConnection(conn net.Conn, client *ssh.Client) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	request, err := reader.ReadString('\n')
+	if err != nil {
+		return
+	}
+	fields := strings.Fields(request)
+	if len(fields) != 2 {
+		fmt.Fprintf(conn, "error: invalid request\n")
+		return
+	}
+
+	remote, err := client.Dial(fields[0], fields[1])
+	if err != nil {
+		fmt.Fprintf(conn, "error: %s\n", err)
+		return
+	}
+	defer remote.Close()
+	if _, err := io.WriteString(conn, "ok\n"); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, reader)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// dialControlSocket connects to address through the tunnel served on the control socket
+func dialControlSocket(controlPath, network, address string) (net.Conn, error) {
+	conn, err := net.Dial("unix", controlPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := fmt.Fprintf(conn, "%s %s\n", network, address); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// the reply is read byte by byte so that no data of the connection is consumed
+	var reply []byte
+	buf := make([]byte, 1)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if buf[0] == '\n' {
+			break
+		}
+		reply = append(reply, buf[0])
+	}
+
+	if string(reply) != "ok" {
+		conn.Close()
+		return nil, errors.New(strings.TrimPrefix(string(reply), "error: "))
+	}
+	return conn, nil
+}
+
+// SSHTunnelDialer opens the tunnels of ssh+socks5 proxies
+type SSHTunnelDialer struct {
+	// PassphrasePrompt returns the passphrases of encrypted private keys. Encrypted keys cannot
+	// be used when it is nil.
+	PassphrasePrompt PassphrasePrompt
+
+	// ControlMaster is the command line of a program serving the tunnel of the proxy URL passed
+	// as its last argument in the background with ServeSSHTunnel, so that later processes reuse
+	// the tunnel. Every process opens its own tunnel when it is empty.
+	ControlMaster []string
+}
+
+var _ TunnelDialer = new(SSHTunnelDialer)
+
+// Dialer returns a DialFunc connecting through the tunnel. It reuses the tunnel served on the
+// control socket, starting the control master when no process serves it yet.
+func (d *SSHTunnelDialer) Dialer(tunnel *SSHTunnel) (DialFunc, error) {
+	controlDial := func(network, address string) (net.Conn, error) {
+		return dialControlSocket(tunnel.ControlPath, network, address)
+	}
+
+	if tunnel.ControlPath != "" {
+		if conn, err := net.Dial("unix", tunnel.ControlPath); err == nil {
+			conn.Close()
+			return controlDial, nil
+		}
+
+		if len(d.ControlMaster) != 0 {
+			if err := d.startControlMaster(tunnel); err != nil {
+				return nil, err
+			}
+			return controlDial, nil
+		}
+	}
+
+	client, err := tunnel.Connect(d.PassphrasePrompt)
+	if err != nil {
+		return nil, err
+	}
+	return client.Dial, nil
+}
+
+// startControlMaster starts the control master in the background and waits until it serves the
+// tunnel. The passphrase of the private key is prompted for here and passed on stdin.
+func (d *SSHTunnelDialer) startControlMaster(tunnel *SSHTunnel) error {
+	passphrase, err := tunnel.passphrase(d.PassphrasePrompt)
+	if err != nil {
+		return err
+	}
+
+	args := append(append([]string{}, d.ControlMaster[1:]...), tunnel.URL)
+	cmd := exec.Command(d.ControlMaster[0], args...)
+	cmd.Stdin = strings.NewReader(passphrase + "\n")
+	detach(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start the ssh tunnel: %s", err)
+	}
+
+	status, _ := bufio.NewReader(stdout).ReadString('\n')
+	status = strings.TrimSpace(status)
+	if status != "ok" {
+		cmd.Wait()
+		if status == "" {
+			return errors.New("the ssh tunnel exited unexpectedly")
+		}
+		return errors.New(strings.TrimPrefix(status, "error: "))
+	}
+
+	return cmd.Process.Release()
+}
+
+// ServeSSHTunnel opens the tunnel of the ssh+socks5 proxy URL and serves it on its control socket
+// until it is unused for its control persist duration. The passphrase of an encrypted private key
+// is read as the first line of passphrase. Once the tunnel is served, or fails to open, status
+// receives "ok\n" or "error: reason\n".
+func ServeSSHTunnel(proxyURL string, passphrase io.Reader, status io.Writer) error {
+	err := serveSSHTunnel(proxyURL, passphrase, func() { io.WriteString(status, "ok\n") })
+	if err != nil {
+		fmt.Fprintf(status, "error: %s\n", err)
+	}
+	return err
+}
+
+func serveSSHTunnel(proxyURL string, passphrase io.Reader, ready func()) error {
+	tunnel, err := ParseSSHTunnelURL(proxyURL)
+	if err != nil {
+		return err
+	}
+
+	line, err := bufio.NewReader(passphrase).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	line = strings.TrimSuffix(line, "\n")
+
+	listener, err := tunnel.ListenControlSocket()
+	if err == errSSHControlSocketInUse {
+		ready()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := tunnel.Connect(func(string) (string, error) { return line, nil })
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	defer client.Close()
+
+	ready()
+	return tunnel.ServeControlSocket(listener, client)
+}
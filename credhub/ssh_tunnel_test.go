@@ -0,0 +1,314 @@
+// +build !windows
+
+package credhub_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub"
+	"code.cloudfoundry.org/credhub-cli/test"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SSHTunnel", func() {
+	var (
+		dir        string
+		key        *rsa.PrivateKey
+		keyPath    string
+		knownHosts string
+		jumpbox    *test.SSHServer
+		target     *httptest.Server
+		authSock   string
+	)
+
+	writeKey := func(block *pem.Block) string {
+		path := filepath.Join(dir, "id_rsa")
+		Expect(ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600)).To(Succeed())
+		return path
+	}
+
+	get := func(dial DialFunc) string {
+		client := &http.Client{Transport: &http.Transport{Dial: dial}}
+		defer client.CloseIdleConnections()
+		response, err := client.Get(target.URL)
+		Expect(err).NotTo(HaveOccurred())
+		defer response.Body.Close()
+		body, _ := ioutil.ReadAll(response.Body)
+		return string(body)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "ssh-tunnel")
+		Expect(err).NotTo(HaveOccurred())
+
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		keyPath = writeKey(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+		publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		jumpbox = test.StartSSHServer("jumpbox", publicKey)
+		knownHosts = filepath.Join(dir, "known_hosts")
+		Expect(ioutil.WriteFile(knownHosts, []byte(jumpbox.KnownHostsLine()+"\n"), 0600)).To(Succeed())
+
+		target = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("through the tunnel"))
+		}))
+
+		authSock = os.Getenv("SSH_AUTH_SOCK")
+		os.Unsetenv("SSH_AUTH_SOCK")
+	})
+
+	AfterEach(func() {
+		os.Setenv("SSH_AUTH_SOCK", authSock)
+		target.Close()
+		jumpbox.Close()
+		os.RemoveAll(dir)
+	})
+
+	tunnelTo := func(query string) *SSHTunnel {
+		if !strings.Contains(query, "control-path=") {
+			query += "&control-path=none"
+		}
+		tunnel, err := ParseSSHTunnelURL("ssh+socks5://jumpbox@" + jumpbox.Addr() + "?known-hosts=" + knownHosts + query)
+		Expect(err).NotTo(HaveOccurred())
+		return tunnel
+	}
+
+	It("connects with the private key to hosts in known_hosts", func() {
+		client, err := tunnelTo("&private-key=" + keyPath).Connect(nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer client.Close()
+
+		Expect(get(client.Dial)).To(Equal("through the tunnel"))
+	})
+
+	It("connects through the chain of jump hosts", func() {
+		publicKey, _ := ssh.NewPublicKey(&key.PublicKey)
+		bastion := test.StartSSHServer("admin", publicKey)
+		defer bastion.Close()
+		Expect(ioutil.WriteFile(knownHosts, []byte(bastion.KnownHostsLine()+"\n"+jumpbox.KnownHostsLine()+"\n"), 0600)).To(Succeed())
+
+		client, err := tunnelTo("&private-key=" + keyPath + "&jump=admin@" + bastion.Addr()).Connect(nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer client.Close()
+
+		Expect(get(client.Dial)).To(Equal("through the tunnel"))
+		Expect(bastion.Connections()).To(Equal(1))
+		Expect(jumpbox.Connections()).To(Equal(1))
+	})
+
+	It("authenticates with the keys of the ssh-agent", func() {
+		keyring := agent.NewKeyring()
+		Expect(keyring.Add(agent.AddedKey{PrivateKey: key})).To(Succeed())
+		socket := filepath.Join(dir, "agent.sock")
+		listener, err := net.Listen("unix", socket)
+		Expect(err).NotTo(HaveOccurred())
+		defer listener.Close()
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go agent.ServeAgent(keyring, conn)
+			}
+		}()
+		os.Setenv("SSH_AUTH_SOCK", socket)
+
+		client, err := tunnelTo("").Connect(nil)
+		Expect(err).NotTo(HaveOccurred())
+		defer client.Close()
+
+		Expect(get(client.Dial)).To(Equal("through the tunnel"))
+	})
+
+	It("requires a private key or an ssh-agent", func() {
+		_, err := tunnelTo("").Connect(nil)
+
+		Expect(err).To(MatchError("the ssh+socks5 proxy requires a private-key or an ssh-agent in SSH_AUTH_SOCK"))
+	})
+
+	It("returns an error when the private key cannot be read", func() {
+		_, err := tunnelTo("&private-key=/no/file/here").Connect(nil)
+
+		Expect(err).To(MatchError(HavePrefix("read private key:")))
+	})
+
+	Context("with an encrypted private key", func() {
+		BeforeEach(func() {
+			block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte("secret"), x509.PEMCipherAES256)
+			Expect(err).NotTo(HaveOccurred())
+			keyPath = writeKey(block)
+		})
+
+		It("prompts for the passphrase", func() {
+			var prompted string
+			client, err := tunnelTo("&private-key=" + keyPath).Connect(func(path string) (string, error) {
+				prompted = path
+				return "secret", nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			defer client.Close()
+
+			Expect(prompted).To(Equal(keyPath))
+			Expect(get(client.Dial)).To(Equal("through the tunnel"))
+		})
+
+		It("returns an error for a wrong passphrase", func() {
+			_, err := tunnelTo("&private-key=" + keyPath).Connect(func(string) (string, error) {
+				return "wrong", nil
+			})
+
+			Expect(err).To(MatchError(HavePrefix("decrypt private key:")))
+		})
+
+		It("returns an error without a prompt", func() {
+			_, err := tunnelTo("&private-key=" + keyPath).Connect(nil)
+
+			Expect(err).To(MatchError("the private key " + keyPath + " is encrypted and no passphrase was provided"))
+		})
+	})
+
+	Describe("host key verification", func() {
+		It("rejects hosts missing from known_hosts", func() {
+			Expect(ioutil.WriteFile(knownHosts, nil, 0600)).To(Succeed())
+
+			_, err := tunnelTo("&private-key=" + keyPath).Connect(nil)
+
+			Expect(err).To(MatchError(ContainSubstring("the host key of " + jumpbox.Addr() + " is not in known_hosts")))
+		})
+
+		It("rejects host keys not matching known_hosts", func() {
+			line := knownhosts.Line([]string{jumpbox.Addr()}, test.NewSSHKey().PublicKey())
+			Expect(ioutil.WriteFile(knownHosts, []byte(line+"\n"), 0600)).To(Succeed())
+
+			_, err := tunnelTo("&private-key=" + keyPath).Connect(nil)
+
+			Expect(err).To(MatchError(ContainSubstring("the host key of " + jumpbox.Addr() + " does not match known_hosts")))
+		})
+
+		It("requires a known_hosts file", func() {
+			os.Remove(knownHosts)
+
+			_, err := tunnelTo("&private-key=" + keyPath).Connect(nil)
+
+			Expect(err).To(MatchError(HavePrefix("no known_hosts file")))
+		})
+
+		It("accepts any host key when verification is skipped", func() {
+			os.Remove(knownHosts)
+
+			client, err := tunnelTo("&private-key=" + keyPath + "&insecure-skip-host-key-verification=true").Connect(nil)
+			Expect(err).NotTo(HaveOccurred())
+			client.Close()
+		})
+	})
+
+	Describe("control socket", func() {
+		var controlPath string
+
+		BeforeEach(func() {
+			controlPath = filepath.Join(dir, "control.sock")
+		})
+
+		serve := func(tunnel *SSHTunnel) chan error {
+			listener, err := tunnel.ListenControlSocket()
+			Expect(err).NotTo(HaveOccurred())
+			client, err := tunnel.Connect(nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			done := make(chan error, 1)
+			go func() {
+				done <- tunnel.ServeControlSocket(listener, client)
+				client.Close()
+			}()
+			return done
+		}
+
+		It("shares the tunnel with other dialers", func() {
+			tunnel := tunnelTo("&private-key=" + keyPath + "&control-path=" + controlPath + "&control-persist=200ms")
+			done := serve(tunnel)
+
+			for i := 0; i < 2; i++ {
+				dial, err := (&SSHTunnelDialer{}).Dialer(tunnel)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(get(dial)).To(Equal("through the tunnel"))
+			}
+			Expect(jumpbox.Connections()).To(Equal(1))
+
+			Eventually(done, 2*time.Second).Should(Receive(BeNil()))
+			Expect(controlPath).NotTo(BeAnExistingFile())
+		})
+
+		It("reports the errors of the tunnel", func() {
+			tunnel := tunnelTo("&private-key=" + keyPath + "&control-path=" + controlPath + "&control-persist=200ms")
+			serve(tunnel)
+
+			dial, err := (&SSHTunnelDialer{}).Dialer(tunnel)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = dial("tcp", "127.0.0.1:1")
+
+			Expect(err).To(MatchError(ContainSubstring("connect failed")))
+		})
+
+		It("refuses to serve a tunnel another process already serves", func() {
+			tunnel := tunnelTo("&private-key=" + keyPath + "&control-path=" + controlPath + "&control-persist=200ms")
+			serve(tunnel)
+
+			_, err := tunnel.ListenControlSocket()
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("replaces stale control sockets", func() {
+			Expect(ioutil.WriteFile(controlPath, nil, 0600)).To(Succeed())
+			tunnel := tunnelTo("&private-key=" + keyPath + "&control-path=" + controlPath + "&control-persist=200ms")
+
+			listener, err := tunnel.ListenControlSocket()
+
+			Expect(err).NotTo(HaveOccurred())
+			listener.Close()
+		})
+	})
+
+	Describe("ParseSSHTunnelURL()", func() {
+		It("uses a control socket per proxy URL by default", func() {
+			tunnel, err := ParseSSHTunnelURL("ssh+socks5://jumpbox@10.0.0.5:22?private-key=/key")
+			Expect(err).NotTo(HaveOccurred())
+			other, err := ParseSSHTunnelURL("ssh+socks5://jumpbox@10.0.0.6:22?private-key=/key")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tunnel.ControlPath).To(HaveSuffix(".sock"))
+			Expect(tunnel.ControlPath).NotTo(Equal(other.ControlPath))
+			Expect(tunnel.ControlPersist).To(Equal(10 * time.Minute))
+			Expect(tunnel.KnownHostsFiles).To(Equal([]string{filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts")}))
+		})
+
+		It("rejects invalid options", func() {
+			_, err := ParseSSHTunnelURL("ssh+socks5://10.0.0.5?control-persist=forever")
+			Expect(err).To(MatchError(HavePrefix("invalid control-persist")))
+
+			_, err = ParseSSHTunnelURL("ssh+socks5://10.0.0.5?insecure-skip-host-key-verification=maybe")
+			Expect(err).To(MatchError(HavePrefix("invalid insecure-skip-host-key-verification")))
+		})
+	})
+})
+
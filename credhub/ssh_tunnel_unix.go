@@ -0,0 +1,35 @@
+// +build !windows
+
+package credhub
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+)
+
+// sshControlDir returns the directory of the control sockets, which only the current user may
+// access
+func sshControlDir() string {
+	dir := filepath.Join(os.TempDir(), fmt.Sprintf("credhub-ssh-%d", os.Getuid()))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return ""
+	}
+
+	info, err := os.Lstat(dir)
+	if err != nil || !info.IsDir() || info.Mode().Perm() != 0700 {
+		return ""
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || int(stat.Uid) != os.Getuid() {
+		return ""
+	}
+	return dir
+}
+
+// detach starts the command in its own session, so that it outlives the terminal signals of the
+// process starting it
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
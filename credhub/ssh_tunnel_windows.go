@@ -0,0 +1,14 @@
+// +build windows
+
+package credhub
+
+import (
+	"os/exec"
+)
+
+// sshControlDir returns no directory, tunnels are not shared on windows
+func sshControlDir() string {
+	return ""
+}
+
+func detach(cmd *exec.Cmd) {}
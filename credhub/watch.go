@@ -0,0 +1,187 @@
+package credhub
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+)
+
+// WatchEventType describes how a credential changed between two polls
+type WatchEventType string
+
+const (
+	CredentialCreated WatchEventType = "created"
+	CredentialUpdated WatchEventType = "updated"
+	CredentialDeleted WatchEventType = "deleted"
+)
+
+// WatchEvent is emitted by a Watcher when a credential under the watched path changes.
+//
+// For deleted credentials, VersionCreatedAt is the last version seen before the deletion.
+type WatchEvent struct {
+	Type             WatchEventType `json:"type" yaml:"type"`
+	Name             string         `json:"name" yaml:"name"`
+	VersionCreatedAt string         `json:"version_created_at" yaml:"version_created_at"`
+}
+
+// WatchHook is invoked by a Watcher for every event, before the event is sent on the Events channel.
+// Errors returned by a hook are sent on the Errors channel and do not stop the Watcher.
+type WatchHook func(WatchEvent) error
+
+type WatchOption func(*WatchOptions) error
+
+type WatchOptions struct {
+	Hooks []WatchHook
+}
+
+// WithWatchHook registers a hook to run for every change seen by the Watcher
+func WithWatchHook(hook WatchHook) WatchOption {
+	return func(o *WatchOptions) error {
+		o.Hooks = append(o.Hooks, hook)
+		return nil
+	}
+}
+
+// Watcher polls a path for credential changes. Use Watch() to construct a Watcher.
+//
+// Events and Errors must both be drained by the caller, otherwise polling will block.
+// Both channels are closed once Stop() returns.
+type Watcher struct {
+	Events <-chan WatchEvent
+	Errors <-chan error
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Watch polls FindByPath every interval and reports credentials under the path that have been
+// created, updated (a new version_created_at) or deleted since the previous poll.
+//
+// The credentials present when Watch is called form the baseline and do not produce events.
+func (ch *CredHub) Watch(path string, interval time.Duration, options ...WatchOption) (*Watcher, error) {
+	if interval <= 0 {
+		return nil, errors.New("watch interval must be greater than zero")
+	}
+
+	var opts WatchOptions
+	for _, option := range options {
+		if err := option(&opts); err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := ch.FindByPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan WatchEvent)
+	errs := make(chan error)
+	w := &Watcher{
+		Events: events,
+		Errors: errs,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(w.done)
+		defer close(errs)
+		defer close(events)
+
+		known := versionsByName(results)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+
+			results, err := ch.FindByPath(path)
+			if err != nil {
+				if !w.sendError(errs, err) {
+					return
+				}
+				continue
+			}
+
+			current := versionsByName(results)
+			for _, event := range diffVersions(known, current) {
+				for _, hook := range opts.Hooks {
+					if err := hook(event); err != nil {
+						if !w.sendError(errs, err) {
+							return
+						}
+					}
+				}
+
+				select {
+				case events <- event:
+				case <-w.stop:
+					return
+				}
+			}
+			known = current
+		}
+	}()
+
+	return w, nil
+}
+
+// Stop ends polling and closes the Events and Errors channels
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+func (w *Watcher) sendError(errs chan<- error, err error) bool {
+	select {
+	case errs <- err:
+		return true
+	case <-w.stop:
+		return false
+	}
+}
+
+func versionsByName(results credentials.FindResults) map[string]string {
+	versions := make(map[string]string, len(results.Credentials))
+	for _, cred := range results.Credentials {
+		versions[cred.Name] = cred.VersionCreatedAt
+	}
+	return versions
+}
+
+func diffVersions(previous, current map[string]string) []WatchEvent {
+	var events []WatchEvent
+
+	for name, createdAt := range current {
+		previousCreatedAt, existed := previous[name]
+		switch {
+		case !existed:
+			events = append(events, WatchEvent{Type: CredentialCreated, Name: name, VersionCreatedAt: createdAt})
+		case previousCreatedAt != createdAt:
+			events = append(events, WatchEvent{Type: CredentialUpdated, Name: name, VersionCreatedAt: createdAt})
+		}
+	}
+
+	for name, createdAt := range previous {
+		if _, exists := current[name]; !exists {
+			events = append(events, WatchEvent{Type: CredentialDeleted, Name: name, VersionCreatedAt: createdAt})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Name < events[j].Name
+	})
+
+	return events
+}
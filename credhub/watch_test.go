@@ -0,0 +1,123 @@
+package credhub_test
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	. "code.cloudfoundry.org/credhub-cli/credhub"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Watch", func() {
+	var (
+		server   *ghttp.Server
+		mu       sync.Mutex
+		response string
+		ch       *CredHub
+	)
+
+	setResponse := func(body string) {
+		mu.Lock()
+		defer mu.Unlock()
+		response = body
+	}
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		setResponse(`{"credentials":[
+			{"name":"/some/path/unchanged","version_created_at":"2019-01-01T00:00:00Z"},
+			{"name":"/some/path/rotated","version_created_at":"2019-01-01T00:00:00Z"},
+			{"name":"/some/path/removed","version_created_at":"2019-01-01T00:00:00Z"}
+		]}`)
+
+		server.RouteToHandler("GET", "/api/v1/data", ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/api/v1/data", "path=/some/path"),
+			func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				w.Write([]byte(response))
+			},
+		))
+
+		var err error
+		ch, err = New(server.URL())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("emits created, updated and deleted events relative to the initial credentials", func() {
+		watcher, err := ch.Watch("/some/path", 10*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		defer watcher.Stop()
+
+		setResponse(`{"credentials":[
+			{"name":"/some/path/unchanged","version_created_at":"2019-01-01T00:00:00Z"},
+			{"name":"/some/path/rotated","version_created_at":"2019-02-01T00:00:00Z"},
+			{"name":"/some/path/added","version_created_at":"2019-02-01T00:00:00Z"}
+		]}`)
+
+		Eventually(watcher.Events).Should(Receive(Equal(WatchEvent{Type: CredentialCreated, Name: "/some/path/added", VersionCreatedAt: "2019-02-01T00:00:00Z"})))
+		Eventually(watcher.Events).Should(Receive(Equal(WatchEvent{Type: CredentialDeleted, Name: "/some/path/removed", VersionCreatedAt: "2019-01-01T00:00:00Z"})))
+		Eventually(watcher.Events).Should(Receive(Equal(WatchEvent{Type: CredentialUpdated, Name: "/some/path/rotated", VersionCreatedAt: "2019-02-01T00:00:00Z"})))
+		Consistently(watcher.Events, 50*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("runs hooks for each event and reports hook failures on the error channel", func() {
+		var hooked []string
+		var hookMu sync.Mutex
+		hook := func(event WatchEvent) error {
+			hookMu.Lock()
+			defer hookMu.Unlock()
+			hooked = append(hooked, event.Name)
+			return errors.New("hook failed")
+		}
+
+		watcher, err := ch.Watch("/some/path", 10*time.Millisecond, WithWatchHook(hook))
+		Expect(err).NotTo(HaveOccurred())
+		defer watcher.Stop()
+
+		setResponse(`{"credentials":[
+			{"name":"/some/path/unchanged","version_created_at":"2019-01-01T00:00:00Z"},
+			{"name":"/some/path/rotated","version_created_at":"2019-01-01T00:00:00Z"},
+			{"name":"/some/path/removed","version_created_at":"2019-01-01T00:00:00Z"},
+			{"name":"/some/path/added","version_created_at":"2019-02-01T00:00:00Z"}
+		]}`)
+
+		Eventually(watcher.Errors).Should(Receive(MatchError("hook failed")))
+		Eventually(watcher.Events).Should(Receive(Equal(WatchEvent{Type: CredentialCreated, Name: "/some/path/added", VersionCreatedAt: "2019-02-01T00:00:00Z"})))
+
+		hookMu.Lock()
+		defer hookMu.Unlock()
+		Expect(hooked).To(Equal([]string{"/some/path/added"}))
+	})
+
+	It("closes the channels when stopped", func() {
+		watcher, err := ch.Watch("/some/path", 10*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+
+		watcher.Stop()
+
+		Eventually(watcher.Events).Should(BeClosed())
+		Eventually(watcher.Errors).Should(BeClosed())
+	})
+
+	It("returns an error when the initial request fails", func() {
+		server.RouteToHandler("GET", "/api/v1/data", ghttp.RespondWith(http.StatusForbidden, `{"error":"forbidden"}`))
+
+		_, err := ch.Watch("/some/path", 10*time.Millisecond)
+		Expect(err).To(MatchError("forbidden"))
+	})
+
+	It("requires a positive interval", func() {
+		_, err := ch.Watch("/some/path", 0)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 func NewNetworkError(e error) error {
@@ -112,3 +113,163 @@ func NewInvalidJSONMetadataError() error {
 func NewServerDoesNotSupportMetadataError() error {
 	return errors.New("The --metadata flag is not supported for this version of the credhub server (requires >= 2.6.x). Please remove the flag and retry your request.")
 }
+
+func NewOutputJSONAndOutputFormatError() error {
+	return errors.New("The --output-json flag and --output flag are incompatible")
+}
+
+func NewReverseWithoutSortError() error {
+	return errors.New("The --reverse flag requires the --sort-by flag. Please update and retry your request.")
+}
+
+func NewInvalidMetadataFilterError(filter string) error {
+	return fmt.Errorf("The metadata filter '%s' is not valid. Metadata filters must be in the form KEY=VALUE. Please update and retry your request.", filter)
+}
+
+func NewInvalidDurationFlagError(flag string, err error) error {
+	return fmt.Errorf("The argument for --%s is not a valid duration: %v. Please update and retry your request.", flag, err)
+}
+
+func NewFilterRequiresPathError() error {
+	return errors.New("Filter flags can only be used when deleting by path. Please update and retry your request.")
+}
+
+func NewBulkRegenerateParametersError() error {
+	return errors.New("Exactly one of --signed-by or --path must be provided. Please update and retry your request.")
+}
+
+func NewBulkRegenerateQueryRequiresPathError() error {
+	return errors.New("Filter flags and --dry-run can only be used when regenerating by path. Please update and retry your request.")
+}
+
+func NewBulkRegenerateFailureError() error {
+	return errors.New("Some or all of the credentials under the provided path could not be regenerated. Please refer to the output.")
+}
+
+func NewMissingPolicyError() error {
+	return errors.New("The --policy flag must be provided with the --policy-file flag. Please update and retry your request.")
+}
+
+func NewPolicyOnlyValidForPasswordTypeError() error {
+	return errors.New("Password policies are only valid for password credentials. Please update and retry your request.")
+}
+
+func NewPolicyAndPasswordParametersError() error {
+	return errors.New("The --policy flag cannot be combined with the length, include or exclude flags. Please update and retry your request.")
+}
+
+func NewPolicyFileError(file string, err error) error {
+	return fmt.Errorf("The password policy file '%s' could not be read: %v", file, err)
+}
+
+func NewUnknownPolicyError(name string) error {
+	return fmt.Errorf("The password policy '%s' could not be found. Please update and retry your request.", name)
+}
+
+func NewOutputFileRequiresLocalError() error {
+	return errors.New("The --output-file flag can only be used with the --local flag. Please update and retry your request.")
+}
+
+func NewPolicyAndLocalError() error {
+	return errors.New("The --policy flag cannot be combined with the --local flag. Please update and retry your request.")
+}
+
+func NewLocalCaNotFoundError(ca, file string) error {
+	return fmt.Errorf("The CA '%s' could not be found in '%s'. Please generate the CA into the same file and retry your request.", ca, file)
+}
+
+func NewKeyAlgorithmOnlyValidForKeyTypesError() error {
+	return errors.New("The --key-algorithm flag can only be used with certificate, ssh and rsa credentials. Please update and retry your request.")
+}
+
+func NewInvalidKeyError(err error) error {
+	return fmt.Errorf("The provided keys are invalid: %v. Please update and retry your request.", err)
+}
+
+func NewInvalidImportFileError() error {
+	return errors.New("One or more credentials in the import file are invalid. Please update and retry your request.")
+}
+
+func NewImportRolledBackError() error {
+	return errors.New("One or more credentials failed to import. All imported credentials were rolled back.")
+}
+
+func NewImportRollbackFailedError() error {
+	return errors.New("One or more credentials failed to import and one or more credentials could not be rolled back. Please review the rollback report.")
+}
+
+func NewInvalidRewriteError(flag, rewrite string) error {
+	return fmt.Errorf("The --%s value '%s' is not valid. Rewrites must be in the form FROM=TO. Please update and retry your request.", flag, rewrite)
+}
+
+func NewInvalidRewritePatternError(pattern string, err error) error {
+	return fmt.Errorf("The --rewrite-regex pattern '%s' is not valid: %v. Please update and retry your request.", pattern, err)
+}
+
+func NewUnknownBulkFormatError(format string, formats []string) error {
+	return fmt.Errorf("The format '%s' is not supported. Valid formats are: %s. Please update and retry your request.", format, strings.Join(formats, ", "))
+}
+
+func NewFormatAndJSONFlagError(flag string) error {
+	return fmt.Errorf("The --%s flag can only be combined with the json format. Please update and retry your request.", flag)
+}
+
+func NewFlagRequiresCredHubFormatError(flag string) error {
+	return fmt.Errorf("The --%s flag can only be used with the yaml and json formats. Please update and retry your request.", flag)
+}
+
+func NewInvalidImportFormatFileError(format string, err error) error {
+	return fmt.Errorf("The import file is not a valid %s file: %v. Please update and retry your request.", format, err)
+}
+
+func NewExportFileExistsError(file string) error {
+	return fmt.Errorf("The file '%s' already exists. Use --force to overwrite it.", file)
+}
+
+func NewStdoutOnlyAndFileError() error {
+	return errors.New("The --stdout-only flag cannot be combined with the --file flag. Please update and retry your request.")
+}
+
+func NewExportToTerminalNotConfirmedError() error {
+	return errors.New("The credentials were not printed to the terminal. Use --file to write them to a file instead.")
+}
+
+func NewClientCertificateParametersError() error {
+	return errors.New("Both a client certificate and a client key must be provided. Please update and retry your request.")
+}
+
+func NewClientKeyPassphraseRequiredError() error {
+	return errors.New("The client private key is encrypted. Please set CREDHUB_CLIENT_KEY_PASSPHRASE or run the command in a terminal to enter the passphrase.")
+}
+
+func NewProxyKeyPassphraseRequiredError() error {
+	return errors.New("The private key of the SSH proxy is encrypted. Please set CREDHUB_PROXY_KEY_PASSPHRASE or run the command in a terminal to enter the passphrase.")
+}
+
+func NewClientCertificateRejectedError(err error) error {
+	return fmt.Errorf("The server rejected the client certificate during the TLS handshake: %s. Please validate that the certificate is signed by a CA trusted by the server, has not expired and is valid for client authentication.", err)
+}
+
+func NewClientCertificateUnauthorizedError(status int) error {
+	return fmt.Errorf("The server did not accept the client certificate for authentication (HTTP %d). Please validate that mutual TLS authentication is enabled for this certificate's CA and retry your request.", status)
+}
+
+func NewInvalidAccessTokenError(err error) error {
+	return fmt.Errorf("The access token could not be read: %s. Please log in again and retry your request.", err)
+}
+
+func NewInvalidClientCertificateError(path string) error {
+	return fmt.Errorf("The client certificate '%s' is not a valid PEM encoded certificate. Please log in again and retry your request.", path)
+}
+
+func NewWhoamiVerifyClientCertificateError() error {
+	return errors.New("The --verify flag can only be used when authenticated with a token. Please update and retry your request.")
+}
+
+func NewCredentialHelperError(err error) error {
+	return fmt.Errorf("The credential helper did not return an access token: %s. Please validate the helper command and retry your request.", err)
+}
+
+func NewSaveRefreshedTokensError(err error) error {
+	return fmt.Errorf("The refreshed tokens could not be saved: %s. The next command may fail to authenticate and require logging in again.", err)
+}
@@ -22,6 +22,9 @@ type NeedsClient interface {
 type NeedsConfig interface {
 	SetConfig(config.Config)
 }
+type MayRunOffline interface {
+	RunsOffline() bool
+}
 
 func main() {
 	debug.SetTraceback("all")
@@ -37,39 +40,26 @@ func main() {
 			_ = os.Setenv("CREDHUB_HTTP_TIMEOUT", timeout.String())
 		}
 
+		if level := parser.FindOptionByLongName("log-level").Value().(string); level != "" {
+			_ = os.Setenv("CREDHUB_LOG_LEVEL", level)
+		}
+		if file := parser.FindOptionByLongName("log-file").Value().(string); file != "" {
+			_ = os.Setenv("CREDHUB_LOG_FILE", file)
+		}
+		if parser.FindOptionByLongName("log-unredacted").Value().(bool) {
+			_ = os.Setenv("CREDHUB_LOG_UNREDACTED", "true")
+		}
+
 		if cmd, ok := command.(NeedsConfig); ok {
 			cmd.SetConfig(config.ReadConfig())
 		}
 
-		if cmd, ok := command.(NeedsClient); ok {
+		if cmd, ok := command.(NeedsClient); ok && !runsOffline(command) {
 			cfg := config.ReadConfig()
 			if err := config.ValidateConfig(cfg); err != nil {
 				return err
 			}
-			clientId := cfg.ClientID
-			clientSecret := cfg.ClientSecret
-			useClientCredentials := true
-			if clientId == "" {
-				clientId = config.AuthClient
-				clientSecret = config.AuthPassword
-				useClientCredentials = false
-			}
-			client, err := credhub.New(cfg.ApiURL,
-				credhub.AuthURL(cfg.AuthURL),
-				credhub.CaCerts(cfg.CaCerts...),
-				credhub.SkipTLSValidation(cfg.InsecureSkipVerify),
-				credhub.Auth(auth.Uaa(
-					clientId,
-					clientSecret,
-					"",
-					"",
-					cfg.AccessToken,
-					cfg.RefreshToken,
-					useClientCredentials,
-				)),
-				credhub.ServerVersion(cfg.ServerVersion),
-				credhub.SetHttpTimeout(cfg.HttpTimeout),
-			)
+			client, err := newClient(cfg)
 			if err != nil {
 				return err
 			}
@@ -97,3 +87,60 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+func runsOffline(command flags.Commander) bool {
+	cmd, ok := command.(MayRunOffline)
+	return ok && cmd.RunsOffline()
+}
+
+func newClient(cfg config.Config) (*credhub.CredHub, error) {
+	options := []credhub.Option{
+		credhub.CaCerts(cfg.CaCerts...),
+		credhub.SkipTLSValidation(cfg.InsecureSkipVerify),
+		credhub.ServerVersion(cfg.ServerVersion),
+		credhub.SetHttpTimeout(cfg.HttpTimeout),
+		commands.ProxyOption(cfg),
+	}
+
+	if cfg.UsesCredentialHelper() && cfg.ClientID == "" {
+		client, err := credhub.New(cfg.ApiURL, append(options, commands.CredentialHelperOption(cfg))...)
+		if err == nil {
+			commands.PersistRefreshedTokens(client)
+		}
+		return client, err
+	}
+
+	if cfg.UsesClientCertificate() && cfg.ClientID == "" {
+		certOptions, err := commands.ClientCertificateOptions(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return credhub.New(cfg.ApiURL, append(options, certOptions...)...)
+	}
+
+	clientId := cfg.ClientID
+	clientSecret := cfg.ClientSecret
+	useClientCredentials := true
+	if clientId == "" {
+		clientId = config.AuthClient
+		clientSecret = config.AuthPassword
+		useClientCredentials = false
+	}
+
+	client, err := credhub.New(cfg.ApiURL, append(options,
+		credhub.AuthURL(cfg.AuthURL),
+		credhub.Auth(auth.Uaa(
+			clientId,
+			clientSecret,
+			"",
+			"",
+			cfg.AccessToken,
+			cfg.RefreshToken,
+			useClientCredentials,
+		)),
+	)...)
+	if err == nil && !useClientCredentials {
+		commands.PersistRefreshedTokens(client)
+	}
+	return client, err
+}
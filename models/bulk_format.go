@@ -0,0 +1,131 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+)
+
+// BulkFormat writes exported credentials in a file layout and reads import files in that layout
+type BulkFormat interface {
+	Export(creds []credentials.Credential) ([]byte, error)
+	Import(data []byte) (CredentialBulkImport, error)
+}
+
+// Names of the bulk formats registered by this package
+const (
+	FormatYAML             = "yaml"
+	FormatJSON             = "json"
+	FormatDotenv           = "dotenv"
+	FormatK8sSecret        = "k8s-secret"
+	FormatK8sSecretsByPath = "k8s-secret-by-path"
+	FormatFlatJSON         = "flat-json"
+)
+
+var bulkFormats = map[string]BulkFormat{}
+
+func init() {
+	RegisterBulkFormat(FormatYAML, credhubFormat{outputJSON: false})
+	RegisterBulkFormat(FormatJSON, credhubFormat{outputJSON: true})
+	RegisterBulkFormat(FormatDotenv, dotenvFormat{})
+	RegisterBulkFormat(FormatK8sSecret, k8sSecretFormat{})
+	RegisterBulkFormat(FormatK8sSecretsByPath, k8sSecretFormat{byPath: true})
+	RegisterBulkFormat(FormatFlatJSON, flatJSONFormat{})
+}
+
+// RegisterBulkFormat makes a format available to export and import by name, replacing any
+// format registered with the same name
+func RegisterBulkFormat(name string, format BulkFormat) {
+	bulkFormats[name] = format
+}
+
+// BulkFormatNamed returns the format registered with the given name
+func BulkFormatNamed(name string) (BulkFormat, bool) {
+	format, ok := bulkFormats[name]
+	return format, ok
+}
+
+// BulkFormatNames returns the names of the registered formats in alphabetical order
+func BulkFormatNames() []string {
+	names := make([]string, 0, len(bulkFormats))
+	for name := range bulkFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// credhubFormat is the YAML or JSON layout written by export and read by import by default
+type credhubFormat struct {
+	outputJSON bool
+}
+
+func (f credhubFormat) Export(creds []credentials.Credential) ([]byte, error) {
+	exportCreds, err := ExportCredentials(creds, f.outputJSON)
+	if err != nil {
+		return nil, err
+	}
+	return exportCreds.Bytes, nil
+}
+
+func (f credhubFormat) Import(data []byte) (CredentialBulkImport, error) {
+	var bulkImport CredentialBulkImport
+	err := bulkImport.ReadBytes(data, f.outputJSON)
+	return bulkImport, err
+}
+
+// structuredTypes are the credential types whose value is a map
+var structuredTypes = map[string]bool{"json": true, "user": true, "certificate": true, "rsa": true, "ssh": true}
+
+// scalarValue returns the value of value and password credentials as a string
+func scalarValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// encodeValue returns scalar values as they are and structured values as JSON
+func encodeValue(credType string, value interface{}) (string, error) {
+	if !structuredTypes[credType] {
+		return scalarValue(value), nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("the value of a %s credential could not be encoded: %v", credType, err)
+	}
+	return string(data), nil
+}
+
+// decodeValue reverses encodeValue
+func decodeValue(credType string, encoded string) (interface{}, error) {
+	if !structuredTypes[credType] {
+		return encoded, nil
+	}
+
+	var value map[string]interface{}
+	if err := json.Unmarshal([]byte(encoded), &value); err != nil {
+		return nil, fmt.Errorf("the value of a %s credential must be a JSON object: %v", credType, err)
+	}
+	return value, nil
+}
+
+// importedCredential builds a credential as read from an import file
+func importedCredential(name, credType string, value interface{}, metadata map[string]interface{}) map[string]interface{} {
+	credential := map[string]interface{}{"name": name, "type": credType, "value": value}
+	if metadata != nil {
+		credential["metadata"] = metadata
+	}
+	return unpackCredential(credential)
+}
+
+// splitName returns the path containing a credential and the last segment of its name
+func splitName(name string) (string, string) {
+	name = normalizeName(name)
+	i := strings.LastIndex(name, "/")
+	return name[:i+1], name[i+1:]
+}
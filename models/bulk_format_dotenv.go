@@ -0,0 +1,153 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+)
+
+// dotenvFormat writes one variable per credential. Structured values are JSON encoded. Each
+// variable is preceded by a comment recording the credential name and type, e.g.
+//
+//	# /path/to/cred (password)
+//	PATH_TO_CRED="value"
+//
+// Variables without such a comment are imported as value credentials named after the variable.
+// Metadata is not exported.
+type dotenvFormat struct{}
+
+var (
+	dotenvInvalidChars = regexp.MustCompile(`[^A-Z0-9_]`)
+	dotenvComment      = regexp.MustCompile(`^#\s*(\S+)\s+\(([a-z]+)\)\s*$`)
+	dotenvVariable     = regexp.MustCompile(`^(?:export\s+)?([A-Za-z_][A-Za-z0-9_.]*)\s*=\s*(.*)$`)
+)
+
+// DotenvVariable returns the variable name a credential is exported as
+func DotenvVariable(name string) string {
+	variable := dotenvInvalidChars.ReplaceAllString(strings.ToUpper(strings.TrimPrefix(name, "/")), "_")
+	if variable == "" || (variable[0] >= '0' && variable[0] <= '9') {
+		variable = "_" + variable
+	}
+	return variable
+}
+
+func (dotenvFormat) Export(creds []credentials.Credential) ([]byte, error) {
+	var buf bytes.Buffer
+	names := make(map[string]string, len(creds))
+
+	for _, cred := range creds {
+		variable := DotenvVariable(cred.Name)
+		if other, ok := names[variable]; ok {
+			return nil, fmt.Errorf("the credentials '%s' and '%s' would both be exported as %s", other, cred.Name, variable)
+		}
+		names[variable] = cred.Name
+
+		value, err := encodeValue(cred.Type, cred.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(&buf, "# %s (%s)\n%s=\"%s\"\n", cred.Name, cred.Type, variable, escapeDotenv(value))
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (dotenvFormat) Import(data []byte) (CredentialBulkImport, error) {
+	bulkImport := CredentialBulkImport{Credentials: []map[string]interface{}{}}
+	var name, credType string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "#") {
+			if match := dotenvComment.FindStringSubmatch(text); match != nil {
+				name, credType = match[1], match[2]
+			}
+			continue
+		}
+
+		match := dotenvVariable.FindStringSubmatch(text)
+		if match == nil {
+			return bulkImport, fmt.Errorf("line %d is not a variable assignment", line)
+		}
+
+		encoded, err := unquoteDotenv(match[2])
+		if err != nil {
+			return bulkImport, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		if name == "" {
+			name, credType = match[1], "value"
+		}
+
+		value, err := decodeValue(credType, encoded)
+		if err != nil {
+			return bulkImport, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		bulkImport.Credentials = append(bulkImport.Credentials, importedCredential(name, credType, value, nil))
+		name, credType = "", ""
+	}
+
+	return bulkImport, scanner.Err()
+}
+
+var dotenvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, `$`, `\$`)
+
+func escapeDotenv(value string) string {
+	return dotenvEscaper.Replace(value)
+}
+
+// unquoteDotenv returns the value of a variable. Double quoted values may contain escapes,
+// single quoted values are literal and unquoted values end at a comment.
+func unquoteDotenv(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		var value strings.Builder
+		for i := 1; i < len(raw); i++ {
+			switch raw[i] {
+			case '"':
+				return value.String(), nil
+			case '\\':
+				i++
+				if i == len(raw) {
+					break
+				}
+				switch raw[i] {
+				case 'n':
+					value.WriteByte('\n')
+				case 'r':
+					value.WriteByte('\r')
+				case 't':
+					value.WriteByte('\t')
+				default:
+					value.WriteByte(raw[i])
+				}
+			default:
+				value.WriteByte(raw[i])
+			}
+		}
+		return "", fmt.Errorf("the value is missing a closing double quote")
+	case strings.HasPrefix(raw, `'`):
+		end := strings.Index(raw[1:], `'`)
+		if end < 0 {
+			return "", fmt.Errorf("the value is missing a closing single quote")
+		}
+		return raw[1 : end+1], nil
+	}
+
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+)
+
+// flatJSONFormat writes a JSON object mapping every credential name to a key/value object, as
+// stored by Vault's key/value secrets engine. Value and password credentials are stored under the
+// key `value`, other credentials as their value object. Types and metadata are not exported.
+//
+// Imported types are inferred from the keys of each object: user, certificate, ssh and rsa
+// objects are recognized by their fields, objects only containing a string `value` become value
+// credentials and all other objects json credentials.
+type flatJSONFormat struct{}
+
+func (flatJSONFormat) Export(creds []credentials.Credential) ([]byte, error) {
+	flat := make(map[string]interface{}, len(creds))
+
+	for _, cred := range creds {
+		if structuredTypes[cred.Type] {
+			flat[cred.Name] = cred.Value
+		} else {
+			flat[cred.Name] = map[string]string{"value": scalarValue(cred.Value)}
+		}
+	}
+
+	return json.MarshalIndent(flat, "", "  ")
+}
+
+func (flatJSONFormat) Import(data []byte) (CredentialBulkImport, error) {
+	bulkImport := CredentialBulkImport{Credentials: []map[string]interface{}{}}
+
+	var flat map[string]interface{}
+	if err := json.Unmarshal(data, &flat); err != nil {
+		return bulkImport, fmt.Errorf("the file must contain a JSON object: %v", err)
+	}
+
+	names := make([]string, 0, len(flat))
+	for name := range flat {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, ok := flat[name].(map[string]interface{})
+		if !ok {
+			return bulkImport, fmt.Errorf("the value of '%s' must be a JSON object", name)
+		}
+
+		credType := inferCredentialType(value)
+		var credValue interface{} = value
+		if credType == "value" {
+			credValue = value["value"]
+		}
+
+		bulkImport.Credentials = append(bulkImport.Credentials, importedCredential(name, credType, credValue, nil))
+	}
+
+	return bulkImport, nil
+}
+
+// inferCredentialType returns the type whose value fields contain every key of the object
+func inferCredentialType(value map[string]interface{}) string {
+	if _, ok := value["value"].(string); ok && len(value) == 1 {
+		return "value"
+	}
+
+	onlyStrings := func(fields ...string) bool {
+		allowed := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			allowed[field] = true
+		}
+		for key, field := range value {
+			if _, ok := field.(string); !ok || !allowed[key] {
+				return false
+			}
+		}
+		return len(value) > 0
+	}
+
+	switch {
+	case onlyStrings("username", "password", "password_hash") && value["password"] != nil:
+		return "user"
+	case onlyStrings("ca", "ca_name", "certificate", "private_key"):
+		return "certificate"
+	case onlyStrings("public_key", "private_key", "public_key_fingerprint"):
+		if publicKey, _ := value["public_key"].(string); strings.HasPrefix(publicKey, "ssh-") || strings.HasPrefix(publicKey, "ecdsa-sha2-") || value["public_key_fingerprint"] != nil {
+			return "ssh"
+		}
+		return "rsa"
+	}
+
+	return "json"
+}
@@ -0,0 +1,380 @@
+package models
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+	"gopkg.in/yaml.v2"
+)
+
+// Annotations recording the CredHub credentials stored in a Kubernetes Secret
+const (
+	K8sNameAnnotation     = "credhub.cloudfoundry.org/name"
+	K8sTypeAnnotation     = "credhub.cloudfoundry.org/type"
+	K8sTypesAnnotation    = "credhub.cloudfoundry.org/types"
+	K8sMetadataAnnotation = "credhub.cloudfoundry.org/metadata"
+)
+
+// k8sSecretFormat writes Kubernetes Secret manifests separated by YAML document markers.
+//
+// By default every credential is written to its own Secret. Value, password and json
+// credentials are stored under the key `value`, the fields of other credentials under their
+// own keys. The credential name, type and metadata are recorded as annotations.
+//
+// When byPath is set, the credentials of a path are grouped into one Secret. Each credential is
+// stored under its base name, or under `<base name>.<field>` for its fields, and the types are
+// recorded in a JSON annotation. Metadata is not exported.
+type k8sSecretFormat struct {
+	byPath bool
+}
+
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sObjectMeta     `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	StringData map[string]string `yaml:"stringData,omitempty"`
+}
+
+type k8sObjectMeta struct {
+	Name        string            `yaml:"name"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+var k8sInvalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// K8sSecretName returns the Secret name a credential name or path is exported as
+func K8sSecretName(name string) string {
+	secretName := k8sInvalidNameChars.ReplaceAllString(strings.ToLower(strings.Trim(name, "/")), "-")
+	secretName = strings.Trim(secretName, "-.")
+	if secretName == "" {
+		return "credhub"
+	}
+	return secretName
+}
+
+func (f k8sSecretFormat) Export(creds []credentials.Credential) ([]byte, error) {
+	var secrets []k8sSecret
+	var err error
+
+	if f.byPath {
+		secrets, err = k8sSecretsByPath(creds)
+	} else {
+		secrets, err = k8sSecretPerCredential(creds)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, secret := range secrets {
+		data, err := yaml.Marshal(secret)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func k8sSecretPerCredential(creds []credentials.Credential) ([]k8sSecret, error) {
+	secrets := make([]k8sSecret, 0, len(creds))
+	names := make(map[string]string, len(creds))
+
+	for _, cred := range creds {
+		secretName := K8sSecretName(cred.Name)
+		if other, ok := names[secretName]; ok {
+			return nil, fmt.Errorf("the credentials '%s' and '%s' would both be exported as the Secret %s", other, cred.Name, secretName)
+		}
+		names[secretName] = cred.Name
+
+		fields, err := k8sFields(cred.Type, cred.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		annotations := map[string]string{K8sNameAnnotation: cred.Name, K8sTypeAnnotation: cred.Type}
+		if len(cred.Metadata) > 0 {
+			metadata, err := json.Marshal(cred.Metadata)
+			if err != nil {
+				return nil, err
+			}
+			annotations[K8sMetadataAnnotation] = string(metadata)
+		}
+
+		secrets = append(secrets, newK8sSecret(secretName, annotations, fields))
+	}
+
+	return secrets, nil
+}
+
+func k8sSecretsByPath(creds []credentials.Credential) ([]k8sSecret, error) {
+	var paths []string
+	fieldsByPath := make(map[string]map[string]string)
+	typesByPath := make(map[string]map[string]string)
+
+	for _, cred := range creds {
+		path, base := splitName(cred.Name)
+		if _, ok := fieldsByPath[path]; !ok {
+			paths = append(paths, path)
+			fieldsByPath[path] = make(map[string]string)
+			typesByPath[path] = make(map[string]string)
+		}
+		typesByPath[path][base] = cred.Type
+
+		fields, err := k8sFields(cred.Type, cred.Value)
+		if err != nil {
+			return nil, err
+		}
+		for key, field := range fields {
+			if cred.Type == "json" || !structuredTypes[cred.Type] {
+				key = base
+			} else {
+				key = base + "." + key
+			}
+			if _, ok := fieldsByPath[path][key]; ok {
+				return nil, fmt.Errorf("the key %s is used by more than one credential in %s", key, path)
+			}
+			fieldsByPath[path][key] = field
+		}
+	}
+
+	secrets := make([]k8sSecret, 0, len(paths))
+	names := make(map[string]string, len(paths))
+	for _, path := range paths {
+		secretName := K8sSecretName(path)
+		if other, ok := names[secretName]; ok {
+			return nil, fmt.Errorf("the paths '%s' and '%s' would both be exported as the Secret %s", other, path, secretName)
+		}
+		names[secretName] = path
+
+		types, err := json.Marshal(typesByPath[path])
+		if err != nil {
+			return nil, err
+		}
+
+		annotations := map[string]string{K8sNameAnnotation: path, K8sTypesAnnotation: string(types)}
+		secrets = append(secrets, newK8sSecret(secretName, annotations, fieldsByPath[path]))
+	}
+
+	return secrets, nil
+}
+
+func newK8sSecret(name string, annotations map[string]string, fields map[string]string) k8sSecret {
+	data := make(map[string]string, len(fields))
+	for key, field := range fields {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(field))
+	}
+
+	return k8sSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   k8sObjectMeta{Name: name, Annotations: annotations},
+		Type:       "Opaque",
+		Data:       data,
+	}
+}
+
+// k8sFields returns the Secret keys of a credential value
+func k8sFields(credType string, value interface{}) (map[string]string, error) {
+	if credType == "json" || !structuredTypes[credType] {
+		encoded, err := encodeValue(credType, value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"value": encoded}, nil
+	}
+
+	valueMap, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the value of a %s credential must be a map", credType)
+	}
+
+	fields := make(map[string]string, len(valueMap))
+	for key, field := range valueMap {
+		if field != nil {
+			fields[key] = scalarValue(field)
+		}
+	}
+	return fields, nil
+}
+
+// k8sValue reverses k8sFields
+func k8sValue(credType string, fields map[string]string) (interface{}, error) {
+	if credType == "json" || !structuredTypes[credType] {
+		encoded, ok := fields["value"]
+		if !ok {
+			return nil, fmt.Errorf("the key value is required for %s credentials", credType)
+		}
+		return decodeValue(credType, encoded)
+	}
+
+	value := make(map[string]interface{}, len(fields))
+	for key, field := range fields {
+		value[key] = field
+	}
+	return value, nil
+}
+
+func (f k8sSecretFormat) Import(data []byte) (CredentialBulkImport, error) {
+	bulkImport := CredentialBulkImport{Credentials: []map[string]interface{}{}}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var secret k8sSecret
+		err := decoder.Decode(&secret)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return bulkImport, fmt.Errorf("the Secret manifests could not be parsed: %v", err)
+		}
+		if secret.Kind == "" && secret.Metadata.Name == "" {
+			continue
+		}
+		if secret.Kind != "Secret" {
+			return bulkImport, fmt.Errorf("the manifest '%s' of kind '%s' is not a Secret", secret.Metadata.Name, secret.Kind)
+		}
+
+		fields, err := k8sSecretFields(secret)
+		if err != nil {
+			return bulkImport, err
+		}
+
+		var creds []map[string]interface{}
+		if secret.Metadata.Annotations[K8sTypesAnnotation] != "" {
+			creds, err = k8sGroupedCredentials(secret, fields)
+		} else {
+			creds, err = k8sCredential(secret, fields)
+		}
+		if err != nil {
+			return bulkImport, err
+		}
+
+		bulkImport.Credentials = append(bulkImport.Credentials, creds...)
+	}
+
+	return bulkImport, nil
+}
+
+// k8sSecretFields returns the decoded data of a Secret merged with its string data
+func k8sSecretFields(secret k8sSecret) (map[string]string, error) {
+	fields := make(map[string]string, len(secret.Data)+len(secret.StringData))
+	for key, encoded := range secret.Data {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("the key %s of the Secret '%s' is not base64 encoded: %v", key, secret.Metadata.Name, err)
+		}
+		fields[key] = string(decoded)
+	}
+	for key, field := range secret.StringData {
+		fields[key] = field
+	}
+	return fields, nil
+}
+
+// k8sCredential returns the credential of a Secret written per credential. Secrets without
+// annotations become json credentials named after the Secret, or value credentials when they
+// only contain the key `value`.
+func k8sCredential(secret k8sSecret, fields map[string]string) ([]map[string]interface{}, error) {
+	name := secret.Metadata.Annotations[K8sNameAnnotation]
+	if name == "" {
+		name = "/" + secret.Metadata.Name
+	}
+
+	credType := secret.Metadata.Annotations[K8sTypeAnnotation]
+	var value interface{}
+	switch {
+	case credType != "":
+		var err error
+		if value, err = k8sValue(credType, fields); err != nil {
+			return nil, fmt.Errorf("the Secret '%s': %v", secret.Metadata.Name, err)
+		}
+	case len(fields) == 1 && fields["value"] != "":
+		credType, value = "value", fields["value"]
+	default:
+		valueMap := make(map[string]interface{}, len(fields))
+		for key, field := range fields {
+			valueMap[key] = field
+		}
+		credType, value = "json", valueMap
+	}
+
+	var metadata map[string]interface{}
+	if encoded := secret.Metadata.Annotations[K8sMetadataAnnotation]; encoded != "" {
+		if err := json.Unmarshal([]byte(encoded), &metadata); err != nil {
+			return nil, fmt.Errorf("the metadata annotation of the Secret '%s' is not a JSON object: %v", secret.Metadata.Name, err)
+		}
+	}
+
+	return []map[string]interface{}{importedCredential(name, credType, value, metadata)}, nil
+}
+
+// k8sGroupedCredentials returns the credentials of a Secret grouping a path. Keys are assigned
+// to the credential with the longest base name they belong to.
+func k8sGroupedCredentials(secret k8sSecret, fields map[string]string) ([]map[string]interface{}, error) {
+	var types map[string]string
+	if err := json.Unmarshal([]byte(secret.Metadata.Annotations[K8sTypesAnnotation]), &types); err != nil {
+		return nil, fmt.Errorf("the types annotation of the Secret '%s' is not a JSON object: %v", secret.Metadata.Name, err)
+	}
+
+	path := secret.Metadata.Annotations[K8sNameAnnotation]
+	if path == "" {
+		path = "/" + secret.Metadata.Name
+	}
+	path = strings.TrimSuffix(path, "/") + "/"
+
+	bases := make([]string, 0, len(types))
+	for base := range types {
+		bases = append(bases, base)
+	}
+	sort.Slice(bases, func(i, j int) bool { return len(bases[i]) > len(bases[j]) || len(bases[i]) == len(bases[j]) && bases[i] < bases[j] })
+
+	grouped := make(map[string]map[string]string, len(bases))
+	for key, field := range fields {
+		assigned := false
+		for _, base := range bases {
+			if key == base {
+				if grouped[base] == nil {
+					grouped[base] = make(map[string]string)
+				}
+				grouped[base]["value"] = field
+				assigned = true
+				break
+			}
+			if strings.HasPrefix(key, base+".") && structuredTypes[types[base]] && types[base] != "json" {
+				if grouped[base] == nil {
+					grouped[base] = make(map[string]string)
+				}
+				grouped[base][strings.TrimPrefix(key, base+".")] = field
+				assigned = true
+				break
+			}
+		}
+		if !assigned {
+			return nil, fmt.Errorf("the key %s of the Secret '%s' does not belong to a credential", key, secret.Metadata.Name)
+		}
+	}
+
+	sort.Strings(bases)
+	creds := make([]map[string]interface{}, 0, len(bases))
+	for _, base := range bases {
+		value, err := k8sValue(types[base], grouped[base])
+		if err != nil {
+			return nil, fmt.Errorf("the credential %s of the Secret '%s': %v", base, secret.Metadata.Name, err)
+		}
+		creds = append(creds, importedCredential(path+base, types[base], value, nil))
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,292 @@
+package models_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+	"code.cloudfoundry.org/credhub-cli/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BulkFormat", func() {
+	fixtures := map[string]string{
+		"../test/test_import_file.yml":                                 models.FormatYAML,
+		"../test/test_import_file.json":                                models.FormatJSON,
+		"../test/test_import_with_int_for_value.yml":                   models.FormatYAML,
+		"../test/test_import_ssh_type_with_public_key_fingerprint.yml": models.FormatYAML,
+		"../test/test_import_user_type_with_password_hash.json":        models.FormatJSON,
+	}
+
+	// readFixture returns the credentials of a fixture as returned by the server
+	readFixture := func(file, format string) []credentials.Credential {
+		bulkImport := models.CredentialBulkImport{}
+		Expect(bulkImport.ReadFile(file, format == models.FormatJSON)).To(Succeed())
+
+		creds := make([]credentials.Credential, len(bulkImport.Credentials))
+		for i, credential := range bulkImport.Credentials {
+			creds[i].Name = credential["name"].(string)
+			creds[i].Type = credential["type"].(string)
+			creds[i].Value = credential["value"]
+			if metadata, ok := credential["metadata"].(map[string]interface{}); ok {
+				creds[i].Metadata = metadata
+			}
+		}
+		return creds
+	}
+
+	// comparable returns the credentials keyed by name as JSON, with scalar values as strings
+	// and without empty metadata
+	comparable := func(creds []map[string]interface{}, keepMetadata bool, types map[string]string) map[string]string {
+		byName := make(map[string]string, len(creds))
+		for _, credential := range creds {
+			credType := credential["type"].(string)
+			if mapped, ok := types[credType]; ok {
+				credType = mapped
+			}
+			value := credential["value"]
+			if credType == "value" || credType == "password" {
+				value = fmt.Sprint(value)
+			}
+			entry := map[string]interface{}{"type": credType, "value": value}
+			if metadata, ok := credential["metadata"].(map[string]interface{}); keepMetadata && ok && len(metadata) > 0 {
+				entry["metadata"] = metadata
+			}
+			data, err := json.Marshal(entry)
+			Expect(err).NotTo(HaveOccurred())
+			byName[credential["name"].(string)] = string(data)
+		}
+		return byName
+	}
+
+	asImported := func(creds []credentials.Credential) []map[string]interface{} {
+		imported := make([]map[string]interface{}, len(creds))
+		for i, cred := range creds {
+			imported[i] = map[string]interface{}{"name": cred.Name, "type": cred.Type, "value": cred.Value}
+			if cred.Metadata != nil {
+				imported[i]["metadata"] = map[string]interface{}(cred.Metadata)
+			}
+		}
+		return imported
+	}
+
+	roundTrips := []struct {
+		format       string
+		keepMetadata bool
+		types        map[string]string
+	}{
+		{format: models.FormatYAML, keepMetadata: true},
+		{format: models.FormatJSON, keepMetadata: true},
+		{format: models.FormatDotenv},
+		{format: models.FormatK8sSecret, keepMetadata: true},
+		{format: models.FormatK8sSecretsByPath},
+		{format: models.FormatFlatJSON, types: map[string]string{"password": "value"}},
+	}
+
+	for _, roundTrip := range roundTrips {
+		roundTrip := roundTrip
+
+		for file, fixtureFormat := range fixtures {
+			file, fixtureFormat := file, fixtureFormat
+
+			It(fmt.Sprintf("round trips %s through the %s format", strings.TrimPrefix(file, "../test/"), roundTrip.format), func() {
+				creds := readFixture(file, fixtureFormat)
+
+				format, ok := models.BulkFormatNamed(roundTrip.format)
+				Expect(ok).To(BeTrue())
+
+				data, err := format.Export(creds)
+				Expect(err).NotTo(HaveOccurred())
+
+				bulkImport, err := format.Import(data)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(comparable(bulkImport.Credentials, roundTrip.keepMetadata, nil)).To(Equal(comparable(asImported(creds), roundTrip.keepMetadata, roundTrip.types)))
+			})
+		}
+	}
+
+	It("lists the registered formats", func() {
+		Expect(models.BulkFormatNames()).To(Equal([]string{"dotenv", "flat-json", "json", "k8s-secret", "k8s-secret-by-path", "yaml"}))
+	})
+
+	Describe("dotenv", func() {
+		format, _ := models.BulkFormatNamed(models.FormatDotenv)
+
+		It("writes a commented variable per credential", func() {
+			data, err := format.Export([]credentials.Credential{
+				{Base: credentials.Base{Name: "/dev/app-1/password", Type: "password"}, Value: "p\"a$s\ns"},
+				{Base: credentials.Base{Name: "/dev/user", Type: "user"}, Value: map[string]interface{}{"username": "u", "password": "p"}},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`# /dev/app-1/password (password)
+DEV_APP_1_PASSWORD="p\"a\$s\ns"
+# /dev/user (user)
+DEV_USER="{\"password\":\"p\",\"username\":\"u\"}"
+`))
+		})
+
+		It("imports variables without comments as value credentials", func() {
+			bulkImport, err := format.Import([]byte("# a comment\nexport PLAIN=some value # trailing\nQUOTED='single $quoted'\n\nEMPTY=\n"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bulkImport.Credentials).To(HaveLen(3))
+			Expect(bulkImport.Credentials[0]).To(HaveKeyWithValue("name", "PLAIN"))
+			Expect(bulkImport.Credentials[0]).To(HaveKeyWithValue("type", "value"))
+			Expect(bulkImport.Credentials[0]).To(HaveKeyWithValue("value", "some value"))
+			Expect(bulkImport.Credentials[1]).To(HaveKeyWithValue("value", "single $quoted"))
+			Expect(bulkImport.Credentials[2]).To(HaveKeyWithValue("value", ""))
+		})
+
+		It("returns an error when two credentials map to the same variable", func() {
+			_, err := format.Export([]credentials.Credential{
+				{Base: credentials.Base{Name: "/a/b", Type: "value"}, Value: "1"},
+				{Base: credentials.Base{Name: "/a-b", Type: "value"}, Value: "2"},
+			})
+
+			Expect(err).To(MatchError("the credentials '/a/b' and '/a-b' would both be exported as A_B"))
+		})
+
+		It("returns an error for lines which are not assignments", func() {
+			_, err := format.Import([]byte("VALID=1\nnot an assignment\n"))
+
+			Expect(err).To(MatchError("line 2 is not a variable assignment"))
+		})
+	})
+
+	Describe("k8s-secret", func() {
+		format, _ := models.BulkFormatNamed(models.FormatK8sSecret)
+
+		It("writes a Secret per credential", func() {
+			data, err := format.Export([]credentials.Credential{
+				{Base: credentials.Base{Name: "/dev/App_1/password", Type: "password"}, Value: "secret"},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: dev-app-1-password
+  annotations:
+    credhub.cloudfoundry.org/name: /dev/App_1/password
+    credhub.cloudfoundry.org/type: password
+type: Opaque
+data:
+  value: c2VjcmV0
+`))
+		})
+
+		It("imports Secrets without annotations", func() {
+			bulkImport, err := format.Import([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: tls
+data:
+  tls.crt: Y2VydA==
+stringData:
+  tls.key: key
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: token
+stringData:
+  value: some-token
+`))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bulkImport.Credentials).To(HaveLen(2))
+			Expect(bulkImport.Credentials[0]).To(HaveKeyWithValue("name", "/tls"))
+			Expect(bulkImport.Credentials[0]).To(HaveKeyWithValue("type", "json"))
+			Expect(bulkImport.Credentials[0]).To(HaveKeyWithValue("value", map[string]interface{}{"tls.crt": "cert", "tls.key": "key"}))
+			Expect(bulkImport.Credentials[1]).To(HaveKeyWithValue("name", "/token"))
+			Expect(bulkImport.Credentials[1]).To(HaveKeyWithValue("type", "value"))
+			Expect(bulkImport.Credentials[1]).To(HaveKeyWithValue("value", "some-token"))
+		})
+
+		It("returns an error for manifests which are not Secrets", func() {
+			_, err := format.Import([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"))
+
+			Expect(err).To(MatchError("the manifest 'config' of kind 'ConfigMap' is not a Secret"))
+		})
+	})
+
+	Describe("k8s-secret-by-path", func() {
+		format, _ := models.BulkFormatNamed(models.FormatK8sSecretsByPath)
+
+		It("groups the credentials of a path into a Secret", func() {
+			data, err := format.Export([]credentials.Credential{
+				{Base: credentials.Base{Name: "/dev/app/password", Type: "password"}, Value: "secret"},
+				{Base: credentials.Base{Name: "/dev/app/user", Type: "user"}, Value: map[string]interface{}{"username": "u", "password": "p"}},
+				{Base: credentials.Base{Name: "/dev/db", Type: "value"}, Value: "db"},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: dev-app
+  annotations:
+    credhub.cloudfoundry.org/name: /dev/app/
+    credhub.cloudfoundry.org/types: '{"password":"password","user":"user"}'
+type: Opaque
+data:
+  password: c2VjcmV0
+  user.password: cA==
+  user.username: dQ==
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: dev
+  annotations:
+    credhub.cloudfoundry.org/name: /dev/
+    credhub.cloudfoundry.org/types: '{"db":"value"}'
+type: Opaque
+data:
+  db: ZGI=
+`))
+		})
+	})
+
+	Describe("flat-json", func() {
+		format, _ := models.BulkFormatNamed(models.FormatFlatJSON)
+
+		It("infers the types of imported credentials", func() {
+			bulkImport, err := format.Import([]byte(`{
+				"/value": {"value": "v"},
+				"/user": {"username": "u", "password": "p"},
+				"/certificate": {"certificate": "c", "private_key": "k"},
+				"/rsa": {"public_key": "-----BEGIN PUBLIC KEY-----", "private_key": "k"},
+				"/ssh": {"public_key": "ssh-ed25519 AAAA", "private_key": "k"},
+				"/json": {"value": 1, "other": "o"}
+			}`))
+
+			Expect(err).NotTo(HaveOccurred())
+			types := map[string]string{}
+			for _, credential := range bulkImport.Credentials {
+				types[credential["name"].(string)] = credential["type"].(string)
+			}
+			Expect(types).To(Equal(map[string]string{
+				"/value":       "value",
+				"/user":        "user",
+				"/certificate": "certificate",
+				"/rsa":         "rsa",
+				"/ssh":         "ssh",
+				"/json":        "json",
+			}))
+		})
+
+		It("returns an error when a credential is not an object", func() {
+			_, err := format.Import([]byte(`{"/value": "v"}`))
+
+			Expect(err).To(MatchError("the value of '/value' must be a JSON object"))
+		})
+	})
+})
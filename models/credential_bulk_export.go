@@ -12,10 +12,20 @@ type exportCredential struct {
 	Type     string
 	Value    interface{}
 	Metadata interface{}
+	Versions []CredentialVersion `json:",omitempty" yaml:",omitempty"`
+}
+
+// CredentialVersion is a version of a credential in export and import files
+type CredentialVersion struct {
+	Id               string      `json:"id" yaml:"id"`
+	VersionCreatedAt string      `json:"version_created_at" yaml:"version_created_at"`
+	Value            interface{} `json:"value" yaml:"value"`
+	Transitional     bool        `json:"transitional,omitempty" yaml:"transitional,omitempty"`
 }
 
 type exportCredentials struct {
 	Credentials []exportCredential
+	Permissions []PermissionEntry `json:",omitempty" yaml:",omitempty"`
 }
 
 type CredentialBulkExport struct {
@@ -23,14 +33,29 @@ type CredentialBulkExport struct {
 }
 
 func ExportCredentials(credentials []credentials.Credential, outputJSON bool) (*CredentialBulkExport, error) {
-	exportCreds := exportCredentials{make([]exportCredential, len(credentials))}
+	return ExportCredentialsWithPermissions(credentials, nil, outputJSON)
+}
+
+// ExportCredentialsWithPermissions exports the credentials followed by a permissions section
+// which is omitted when there are no permissions
+func ExportCredentialsWithPermissions(credentials []credentials.Credential, permissions []PermissionEntry, outputJSON bool) (*CredentialBulkExport, error) {
+	return ExportCredentialsWithVersions(credentials, nil, permissions, outputJSON)
+}
+
+// ExportCredentialsWithVersions exports the credentials with the versions at the same index of
+// versions, ordered from oldest to newest. Credentials without versions only contain their value.
+func ExportCredentialsWithVersions(credentials []credentials.Credential, versions [][]CredentialVersion, permissions []PermissionEntry, outputJSON bool) (*CredentialBulkExport, error) {
+	exportCreds := exportCredentials{Credentials: make([]exportCredential, len(credentials)), Permissions: permissions}
 
 	for i, credential := range credentials {
 		exportCreds.Credentials[i] = exportCredential{
-			credential.Name,
-			credential.Type,
-			credential.Value,
-			credential.Metadata,
+			Name:     credential.Name,
+			Type:     credential.Type,
+			Value:    credential.Value,
+			Metadata: credential.Metadata,
+		}
+		if i < len(versions) {
+			exportCreds.Credentials[i].Versions = versions[i]
 		}
 	}
 
@@ -138,6 +138,63 @@ var _ = Describe("ExportCredentials", func() {
 			Expect(err).To(BeNil())
 		})
 	})
+
+	Describe("with permissions", func() {
+		permissions := []models.PermissionEntry{
+			{Actor: "uaa-client:app", Path: "/valueName", Operations: []string{"read", "write"}},
+		}
+
+		for _, outputJSON := range []bool{false, true} {
+			outputJSON := outputJSON
+
+			It("produces a file whose permissions can be reimported", func() {
+				exportCreds, err := models.ExportCredentialsWithPermissions(credentials, permissions, outputJSON)
+				Expect(err).NotTo(HaveOccurred())
+
+				credImporter := &models.CredentialBulkImport{}
+				Expect(credImporter.ReadBytes(exportCreds.Bytes, outputJSON)).To(Succeed())
+				Expect(credImporter.Credentials).To(HaveLen(2))
+				Expect(credImporter.Permissions).To(Equal(permissions))
+			})
+		}
+
+		It("omits the permissions section when there are none", func() {
+			exportCreds, err := models.ExportCredentials(credentials, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exportCreds.String()).NotTo(ContainSubstring("permissions"))
+		})
+	})
+
+	Describe("with versions", func() {
+		versions := [][]models.CredentialVersion{
+			{
+				{Id: "oldID", VersionCreatedAt: "2020-01-01T00:00:00Z", Value: "old"},
+				{Id: "valueID", VersionCreatedAt: "2020-02-01T00:00:00Z", Value: "test"},
+			},
+		}
+
+		for _, outputJSON := range []bool{false, true} {
+			outputJSON := outputJSON
+
+			It("produces a file whose versions can be reimported in chronological order", func() {
+				exportCreds, err := models.ExportCredentialsWithVersions(credentials, versions, nil, outputJSON)
+				Expect(err).NotTo(HaveOccurred())
+
+				credImporter := &models.CredentialBulkImport{}
+				Expect(credImporter.ReadBytes(exportCreds.Bytes, outputJSON)).To(Succeed())
+				Expect(credImporter.Credentials).To(HaveLen(2))
+
+				imported, ok, err := models.ImportVersions(credImporter.Credentials[0])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ok).To(BeTrue())
+				Expect(imported).To(Equal(versions[0]))
+
+				_, ok, err = models.ImportVersions(credImporter.Credentials[1])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ok).To(BeFalse())
+			})
+		}
+	})
 })
 
 var _ = Describe("CredentialBulkExport", func() {
@@ -1,19 +1,33 @@
 package models
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
 	"strconv"
 
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
 	"code.cloudfoundry.org/credhub-cli/errors"
 )
 
 type CredentialBulkImport struct {
 	Credentials []map[string]interface{} `json:"credentials" yaml:"credentials"`
+	Permissions []PermissionEntry        `json:"permissions,omitempty" yaml:"permissions,omitempty"`
+}
+
+// PermissionEntry grants an actor operations on a credential path in import and export files
+type PermissionEntry struct {
+	Actor      string   `json:"actor" yaml:"actor"`
+	Path       string   `json:"path" yaml:"path"`
+	Operations []string `json:"operations" yaml:"operations"`
 }
 
 func (credentialBulkImport *CredentialBulkImport) ReadFile(filepath string, importJSON bool) error {
@@ -36,7 +50,7 @@ func (credentialBulkImport *CredentialBulkImport) ReadBytes(data []byte, importJ
 		}
 	}
 
-	if credentialBulkImport.Credentials == nil {
+	if credentialBulkImport.Credentials == nil && credentialBulkImport.Permissions == nil {
 		return errors.NewNoCredentialsTagError()
 	}
 
@@ -101,3 +115,207 @@ func unpackArray(array []interface{}) []interface{} {
 	}
 	return array
 }
+
+// Credential returns the credential with the given name, if present
+func (credentialBulkImport *CredentialBulkImport) Credential(name string) (map[string]interface{}, bool) {
+	for _, credential := range credentialBulkImport.Credentials {
+		if credential["name"] == name {
+			return credential, true
+		}
+	}
+	return nil, false
+}
+
+// AddCredential appends a credential, replacing a credential with the same name when overwrite is true.
+// It returns false when a credential with the same name exists and overwrite is false.
+func (credentialBulkImport *CredentialBulkImport) AddCredential(name, credType string, value interface{}, metadata map[string]interface{}, overwrite bool) bool {
+	credential := map[string]interface{}{
+		"name":  name,
+		"type":  credType,
+		"value": value,
+	}
+	if metadata != nil {
+		credential["metadata"] = metadata
+	}
+
+	for i, existing := range credentialBulkImport.Credentials {
+		if existing["name"] == name {
+			if !overwrite {
+				return false
+			}
+			credentialBulkImport.Credentials[i] = credential
+			return true
+		}
+	}
+
+	credentialBulkImport.Credentials = append(credentialBulkImport.Credentials, credential)
+	return true
+}
+
+// WriteFile writes the credentials to filepath in the import file format
+func (credentialBulkImport *CredentialBulkImport) WriteFile(filepath string, outputJSON bool) error {
+	credentials := make([]map[string]interface{}, len(credentialBulkImport.Credentials))
+	for i, credential := range credentialBulkImport.Credentials {
+		credentials[i] = make(map[string]interface{}, len(credential))
+		for key, value := range credential {
+			// added when reading, the import command always overwrites
+			if key != "overwrite" {
+				credentials[i][key] = value
+			}
+		}
+	}
+
+	output := CredentialBulkImport{Credentials: credentials, Permissions: credentialBulkImport.Permissions}
+
+	data, err := yaml.Marshal(output)
+	if outputJSON {
+		data, err = json.Marshal(output)
+	}
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath, data, 0600)
+}
+
+// ImportMode returns the mode declared by a credential of an import file. Credentials are
+// overwritten unless they declare another mode. Converge is only supported when generating.
+func ImportMode(credential map[string]interface{}) (string, error) {
+	mode, present := credential["mode"]
+	if !present || mode == nil {
+		return "overwrite", nil
+	}
+
+	switch mode {
+	case "overwrite", "no-overwrite":
+		return mode.(string), nil
+	case "converge":
+		if _, generated := credential["generate"]; generated {
+			return "converge", nil
+		}
+		return "", fmt.Errorf("the mode 'converge' can only be used with generate")
+	}
+
+	return "", fmt.Errorf("the mode '%v' is not supported. Valid modes are 'overwrite', 'no-overwrite' and 'converge'", mode)
+}
+
+// ImportGenerationParameters returns the parameters of a credential declaring `generate` instead of
+// a value, decoded into the parameter type of the generate package matching its type. It
+// returns false for credentials with a value.
+func ImportGenerationParameters(credential map[string]interface{}) (interface{}, bool, error) {
+	params, present := credential["generate"]
+	if !present {
+		return nil, false, nil
+	}
+
+	fields, ok := params.(map[string]interface{})
+	if !ok {
+		if params != nil {
+			return nil, true, fmt.Errorf("generate must be a map")
+		}
+		fields = map[string]interface{}{}
+	}
+
+	credType, _ := credential["type"].(string)
+	var decoded interface{}
+	switch credType {
+	case "password":
+		decoded = &generate.Password{}
+	case "user":
+		decoded = &generate.User{}
+	case "certificate":
+		decoded = &generate.Certificate{}
+	case "rsa":
+		decoded = &generate.RSA{}
+	case "ssh":
+		decoded = &generate.SSH{}
+	default:
+		return nil, true, fmt.Errorf("credentials of type '%s' cannot be generated", credType)
+	}
+
+	username, _ := fields["username"].(string)
+	if credType == "user" {
+		withoutUsername := make(map[string]interface{}, len(fields))
+		for key, field := range fields {
+			if key != "username" {
+				withoutUsername[key] = field
+			}
+		}
+		fields = withoutUsername
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, true, fmt.Errorf("the generate parameters are invalid: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(decoded); err != nil {
+		return nil, true, fmt.Errorf("the generate parameters are invalid: %v", err)
+	}
+
+	if user, ok := decoded.(*generate.User); ok {
+		user.Username = username
+	}
+
+	return reflect.ValueOf(decoded).Elem().Interface(), true, nil
+}
+
+// ImportVersions returns the versions of a credential exported with all of its versions, ordered
+// from oldest to newest. Versions without a creation time are ordered first, in their order in
+// the file. It returns false for credentials without versions.
+func ImportVersions(credential map[string]interface{}) ([]CredentialVersion, bool, error) {
+	entries, present := credential["versions"]
+	if !present || entries == nil {
+		return nil, false, nil
+	}
+
+	list, ok := entries.([]interface{})
+	if !ok {
+		return nil, true, fmt.Errorf("versions must be a list")
+	}
+
+	versions := make([]CredentialVersion, len(list))
+	createdAt := make([]time.Time, len(list))
+	for i, entry := range list {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, true, fmt.Errorf("the version at index %d must be a map", i)
+		}
+
+		value, ok := fields["value"]
+		if !ok || value == nil {
+			return nil, true, fmt.Errorf("the version at index %d has no value", i)
+		}
+
+		versions[i].Value = value
+		versions[i].Id, _ = fields["id"].(string)
+		versions[i].VersionCreatedAt, _ = fields["version_created_at"].(string)
+		versions[i].Transitional, _ = fields["transitional"].(bool)
+
+		if versions[i].VersionCreatedAt != "" {
+			t, err := time.Parse(time.RFC3339Nano, versions[i].VersionCreatedAt)
+			if err != nil {
+				return nil, true, fmt.Errorf("the version at index %d has an invalid version_created_at: %v", i, err)
+			}
+			createdAt[i] = t
+		}
+	}
+
+	order := make([]int, len(versions))
+	for i := range order {
+		order[i] = i
+	}
+	// the zero time of versions without a creation time is before every other time
+	sort.SliceStable(order, func(a, b int) bool {
+		return createdAt[order[a]].Before(createdAt[order[b]])
+	})
+
+	sorted := make([]CredentialVersion, len(versions))
+	for i, index := range order {
+		sorted[i] = versions[index]
+	}
+
+	return sorted, true, nil
+}
@@ -1,6 +1,9 @@
 package models_test
 
 import (
+	"io/ioutil"
+	"os"
+
 	"code.cloudfoundry.org/credhub-cli/errors"
 	"code.cloudfoundry.org/credhub-cli/models"
 	. "github.com/onsi/ginkgo"
@@ -303,4 +306,111 @@ var _ = Describe("CredentialBulkImport", func() {
 			})
 		})
 	})
+
+	Describe("AddCredential()", func() {
+		It("appends new credentials and only replaces existing ones when overwriting", func() {
+			var credentialBulkImport models.CredentialBulkImport
+
+			Expect(credentialBulkImport.AddCredential("/a", "value", "first", nil, false)).To(BeTrue())
+			Expect(credentialBulkImport.AddCredential("/a", "value", "second", nil, false)).To(BeFalse())
+			Expect(credentialBulkImport.AddCredential("/b", "value", "b", map[string]interface{}{"k": "v"}, false)).To(BeTrue())
+
+			credential, ok := credentialBulkImport.Credential("/a")
+			Expect(ok).To(BeTrue())
+			Expect(credential["value"]).To(Equal("first"))
+
+			Expect(credentialBulkImport.AddCredential("/a", "value", "third", nil, true)).To(BeTrue())
+			credential, _ = credentialBulkImport.Credential("/a")
+			Expect(credential["value"]).To(Equal("third"))
+			Expect(credentialBulkImport.Credentials).To(HaveLen(2))
+		})
+	})
+
+	Describe("WriteFile()", func() {
+		for _, format := range []bool{false, true} {
+			outputJSON := format
+
+			It("writes a file which can be read back", func() {
+				file, err := ioutil.TempFile("", "import")
+				Expect(err).NotTo(HaveOccurred())
+				file.Close()
+				defer os.Remove(file.Name())
+
+				var written models.CredentialBulkImport
+				written.AddCredential("/a", "user", map[string]interface{}{"username": "u", "password": "p"}, map[string]interface{}{"k": "v"}, true)
+				Expect(written.WriteFile(file.Name(), outputJSON)).To(Succeed())
+
+				info, err := os.Stat(file.Name())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+				var read models.CredentialBulkImport
+				Expect(read.ReadFile(file.Name(), outputJSON)).To(Succeed())
+				Expect(read.Credentials).To(Equal([]map[string]interface{}{{
+					"name":      "/a",
+					"type":      "user",
+					"value":     map[string]interface{}{"username": "u", "password": "p"},
+					"metadata":  map[string]interface{}{"k": "v"},
+					"overwrite": true,
+				}}))
+
+				Expect(read.WriteFile(file.Name(), outputJSON)).To(Succeed())
+				contents, _ := ioutil.ReadFile(file.Name())
+				Expect(string(contents)).NotTo(ContainSubstring("overwrite"))
+			})
+		}
+	})
+
+	Describe("ImportVersions()", func() {
+		It("orders the versions from oldest to newest", func() {
+			credential := map[string]interface{}{
+				"versions": []interface{}{
+					map[string]interface{}{"id": "new", "version_created_at": "2020-02-01T00:00:00Z", "value": "b"},
+					map[string]interface{}{"id": "transitional", "version_created_at": "2020-01-01T00:00:00.5Z", "value": "a", "transitional": true},
+					map[string]interface{}{"id": "old", "version_created_at": "2020-01-01T00:00:00Z", "value": "a"},
+				},
+			}
+
+			versions, ok, err := models.ImportVersions(credential)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(versions).To(Equal([]models.CredentialVersion{
+				{Id: "old", VersionCreatedAt: "2020-01-01T00:00:00Z", Value: "a"},
+				{Id: "transitional", VersionCreatedAt: "2020-01-01T00:00:00.5Z", Value: "a", Transitional: true},
+				{Id: "new", VersionCreatedAt: "2020-02-01T00:00:00Z", Value: "b"},
+			}))
+		})
+
+		It("orders versions without a creation time first", func() {
+			credential := map[string]interface{}{
+				"versions": []interface{}{
+					map[string]interface{}{"id": "new", "version_created_at": "2020-02-01T00:00:00Z", "value": "c"},
+					map[string]interface{}{"id": "unknown-1", "value": "x"},
+					map[string]interface{}{"id": "old", "version_created_at": "2020-01-01T00:00:00Z", "value": "a"},
+					map[string]interface{}{"id": "unknown-2", "value": "y"},
+				},
+			}
+
+			versions, _, err := models.ImportVersions(credential)
+
+			Expect(err).NotTo(HaveOccurred())
+			var ids []string
+			for _, version := range versions {
+				ids = append(ids, version.Id)
+			}
+			Expect(ids).To(Equal([]string{"unknown-1", "unknown-2", "old", "new"}))
+		})
+
+		It("returns an error for invalid versions", func() {
+			_, _, err := models.ImportVersions(map[string]interface{}{"versions": "nope"})
+			Expect(err).To(MatchError("versions must be a list"))
+
+			_, _, err = models.ImportVersions(map[string]interface{}{"versions": []interface{}{map[string]interface{}{"id": "id"}}})
+			Expect(err).To(MatchError("the version at index 0 has no value"))
+
+			_, _, err = models.ImportVersions(map[string]interface{}{"versions": []interface{}{map[string]interface{}{"value": "a", "version_created_at": "yesterday"}}})
+			Expect(err).To(MatchError(ContainSubstring("the version at index 0 has an invalid version_created_at")))
+		})
+	})
 })
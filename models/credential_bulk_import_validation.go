@@ -0,0 +1,273 @@
+package models
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
+)
+
+// ImportProblem describes why the credential, or the permission when Permission is set,
+// at Index of an import file cannot be imported
+type ImportProblem struct {
+	Index      int
+	Name       string
+	Problem    string
+	Permission bool
+}
+
+var permissionOperations = map[string]bool{"read": true, "write": true, "delete": true, "read_acl": true, "write_acl": true}
+
+func (p ImportProblem) String() string {
+	if p.Permission {
+		return fmt.Sprintf("Permission at index %d: %s", p.Index, p.Problem)
+	}
+	if p.Name == "" {
+		return fmt.Sprintf("Credential at index %d: %s", p.Index, p.Problem)
+	}
+	return fmt.Sprintf("Credential '%s' at index %d: %s", p.Name, p.Index, p.Problem)
+}
+
+// Validate statically checks every credential and returns all problems found, in file order.
+//
+// CA names referenced by certificates that are not defined in the file are passed to caExists.
+// A nil caExists skips those references. Errors returned by caExists abort the validation.
+func (credentialBulkImport *CredentialBulkImport) Validate(caExists func(name string) (bool, error)) ([]ImportProblem, error) {
+	var problems []ImportProblem
+	firstIndex := make(map[string]int)
+	types := make(map[string]string)
+
+	for i, credential := range credentialBulkImport.Credentials {
+		name, _ := credential["name"].(string)
+		if name != "" {
+			if _, ok := firstIndex[normalizeName(name)]; !ok {
+				firstIndex[normalizeName(name)] = i
+				types[normalizeName(name)], _ = credential["type"].(string)
+			}
+		}
+	}
+
+	for i, credential := range credentialBulkImport.Credentials {
+		name, _ := credential["name"].(string)
+		report := func(format string, args ...interface{}) {
+			problems = append(problems, ImportProblem{Index: i, Name: name, Problem: fmt.Sprintf(format, args...)})
+		}
+
+		if name == "" {
+			report("name is required")
+		} else if first := firstIndex[normalizeName(name)]; first != i {
+			report("the name is already used by the credential at index %d", first)
+		}
+
+		if metadata, ok := credential["metadata"]; ok && metadata != nil {
+			if _, ok := metadata.(map[string]interface{}); !ok {
+				report("metadata must be a map")
+			}
+		}
+
+		if _, err := ImportMode(credential); err != nil {
+			report("%v", err)
+		}
+
+		if _, _, err := ImportVersions(credential); err != nil {
+			report("%v", err)
+		}
+
+		credType, _ := credential["type"].(string)
+		value, hasValue := credential["value"]
+		if credType == "" {
+			report("type is required")
+			continue
+		}
+
+		// CA names are resolved within the file first, then on the server
+		checkCA := func(caName string) error {
+			if caType, ok := types[normalizeName(caName)]; ok {
+				if caType != "certificate" {
+					report("the CA '%s' is not a certificate", caName)
+				}
+				return nil
+			}
+			if caExists == nil {
+				return nil
+			}
+			exists, err := caExists(caName)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				report("the CA '%s' could not be found in the file or on the server", caName)
+			}
+			return nil
+		}
+
+		if params, generated, err := ImportGenerationParameters(credential); generated {
+			if hasValue && value != nil {
+				report("value and generate cannot both be provided")
+			}
+			if err != nil {
+				report("%v", err)
+				continue
+			}
+			if cert, ok := params.(generate.Certificate); ok && cert.Ca != "" {
+				if err := checkCA(cert.Ca); err != nil {
+					return problems, err
+				}
+			}
+			continue
+		}
+
+		if !hasValue || value == nil {
+			report("value or generate is required")
+			continue
+		}
+
+		switch credType {
+		case "value":
+			switch value.(type) {
+			case string, int, float32, float64, bool:
+			default:
+				report("value must be a string")
+			}
+		case "password":
+			if _, ok := value.(string); !ok {
+				report("value must be a string")
+			}
+		case "json":
+			if _, ok := value.(map[string]interface{}); !ok {
+				report("value must be a map")
+			}
+		case "user":
+			fields, ok := stringFields(value, report, "username", "password", "password_hash")
+			if ok && fields["password"] == "" {
+				report("value.password is required")
+			}
+		case "rsa", "ssh":
+			fields, ok := stringFields(value, report, "public_key", "private_key", "public_key_fingerprint")
+			if !ok {
+				continue
+			}
+			if fields["public_key"] == "" && fields["private_key"] == "" {
+				report("value must contain a public_key or private_key")
+				continue
+			}
+
+			var err error
+			if credType == "rsa" {
+				err = generate.ValidateRSA(values.RSA{PublicKey: fields["public_key"], PrivateKey: fields["private_key"]}, "")
+			} else {
+				err = generate.ValidateSSH(values.SSH{PublicKey: fields["public_key"], PrivateKey: fields["private_key"]}, "")
+			}
+			if err != nil {
+				report("%v", err)
+			}
+		case "certificate":
+			fields, ok := stringFields(value, report, "ca", "ca_name", "certificate", "private_key")
+			if !ok {
+				continue
+			}
+			if fields["ca"] == "" && fields["certificate"] == "" && fields["private_key"] == "" {
+				report("value must contain a ca, certificate or private_key")
+				continue
+			}
+			if fields["ca"] != "" && fields["ca_name"] != "" {
+				report("value cannot contain both ca and ca_name")
+			}
+			if fields["ca"] != "" {
+				if err := validateCertificatesPEM(fields["ca"]); err != nil {
+					report("the ca could not be parsed: %v", err)
+				}
+			}
+			if err := generate.ValidateCertificate(values.Certificate{Certificate: fields["certificate"], PrivateKey: fields["private_key"]}, ""); err != nil {
+				report("%v", err)
+			}
+
+			if caName := fields["ca_name"]; caName != "" {
+				if err := checkCA(caName); err != nil {
+					return problems, err
+				}
+			}
+		default:
+			report("type '%s' is not supported", credType)
+		}
+	}
+
+	for i, permission := range credentialBulkImport.Permissions {
+		report := func(format string, args ...interface{}) {
+			problems = append(problems, ImportProblem{Index: i, Problem: fmt.Sprintf(format, args...), Permission: true})
+		}
+
+		if permission.Actor == "" {
+			report("actor is required")
+		}
+		if permission.Path == "" {
+			report("path is required")
+		}
+		if len(permission.Operations) == 0 {
+			report("operations are required")
+		}
+		for _, operation := range permission.Operations {
+			if !permissionOperations[operation] {
+				report("the operation '%s' is not supported", operation)
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+// stringFields returns the given string fields of a map value. Missing fields are empty.
+func stringFields(value interface{}, report func(string, ...interface{}), names ...string) (map[string]string, bool) {
+	fieldsMap, ok := value.(map[string]interface{})
+	if !ok {
+		report("value must be a map")
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(names))
+	for _, name := range names {
+		field, present := fieldsMap[name]
+		if !present || field == nil {
+			continue
+		}
+		s, ok := field.(string)
+		if !ok {
+			report("value.%s must be a string", name)
+			return nil, false
+		}
+		fields[name] = s
+	}
+
+	return fields, true
+}
+
+// validateCertificatesPEM checks that data contains one or more PEM encoded certificates
+func validateCertificatesPEM(data string) error {
+	rest := []byte(data)
+	found := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return err
+		}
+		found = true
+	}
+
+	if !found {
+		return errors.New("no PEM encoded certificate found")
+	}
+
+	return nil
+}
+
+func normalizeName(name string) string {
+	return "/" + strings.TrimPrefix(name, "/")
+}
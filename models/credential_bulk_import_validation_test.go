@@ -0,0 +1,145 @@
+package models_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/generate"
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials/values"
+	"code.cloudfoundry.org/credhub-cli/models"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CredentialBulkImport validation", func() {
+	var (
+		ca, leaf values.Certificate
+		ssh      values.SSH
+	)
+
+	BeforeEach(func() {
+		var err error
+		ca, err = generate.Certificate{CommonName: "ca", IsCA: true, KeyAlgorithm: generate.KeyAlgorithmECDSAP256}.Generate(nil)
+		Expect(err).NotTo(HaveOccurred())
+		leaf, err = generate.Certificate{CommonName: "leaf", Ca: "/ca", KeyAlgorithm: generate.KeyAlgorithmECDSAP256}.Generate(&ca)
+		Expect(err).NotTo(HaveOccurred())
+		ssh, err = generate.SSH{KeyAlgorithm: generate.KeyAlgorithmEd25519}.Generate()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("accepts valid credentials", func() {
+		bulkImport := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+			{"name": "/password", "type": "password", "value": "secret", "metadata": map[string]interface{}{"owner": "ops"}},
+			{"name": "/value", "type": "value", "value": 42},
+			{"name": "/json", "type": "json", "value": map[string]interface{}{"key": "value"}},
+			{"name": "/user", "type": "user", "value": map[string]interface{}{"username": "admin", "password": "secret"}},
+			{"name": "/leaf", "type": "certificate", "value": map[string]interface{}{"ca_name": "ca", "certificate": leaf.Certificate, "private_key": leaf.PrivateKey}},
+			{"name": "/ca", "type": "certificate", "value": map[string]interface{}{"ca": ca.Certificate, "certificate": ca.Certificate, "private_key": ca.PrivateKey}},
+			{"name": "/ssh", "type": "ssh", "value": map[string]interface{}{"public_key": ssh.PublicKey, "private_key": ssh.PrivateKey, "public_key_fingerprint": "ignored"}},
+		}}
+
+		problems, err := bulkImport.Validate(func(name string) (bool, error) {
+			Fail("CAs defined in the file are not looked up")
+			return false, nil
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("reports every problem with its index", func() {
+		bulkImport := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+			{"type": "password", "value": "secret"},
+			{"name": "/password", "type": "password", "value": map[string]interface{}{"not": "a string"}, "metadata": "owner"},
+			{"name": "password", "type": "password", "value": "secret"},
+			{"name": "/user", "type": "user", "value": map[string]interface{}{"username": "admin"}},
+			{"name": "/cert", "type": "certificate", "value": map[string]interface{}{"certificate": "certificate", "ca": ca.Certificate, "ca_name": "/ca"}},
+			{"name": "/mismatch", "type": "certificate", "value": map[string]interface{}{"certificate": ca.Certificate, "private_key": leaf.PrivateKey}},
+			{"name": "/signed", "type": "certificate", "value": map[string]interface{}{"ca_name": "/server-ca", "certificate": leaf.Certificate}},
+			{"name": "/other", "type": "certificate", "value": map[string]interface{}{"ca_name": "/password", "certificate": leaf.Certificate}},
+			{"name": "/unknown", "type": "secret", "value": "secret"},
+			{"name": "/empty", "type": "rsa", "value": map[string]interface{}{}},
+		}}
+
+		var lookedUp []string
+		problems, err := bulkImport.Validate(func(name string) (bool, error) {
+			lookedUp = append(lookedUp, name)
+			return name == "/ca", nil
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lookedUp).To(Equal([]string{"/ca", "/server-ca"}))
+
+		var messages []string
+		for _, problem := range problems {
+			messages = append(messages, problem.String())
+		}
+		Expect(messages).To(Equal([]string{
+			"Credential at index 0: name is required",
+			"Credential '/password' at index 1: metadata must be a map",
+			"Credential '/password' at index 1: value must be a string",
+			"Credential 'password' at index 2: the name is already used by the credential at index 1",
+			"Credential '/user' at index 3: value.password is required",
+			"Credential '/cert' at index 4: value cannot contain both ca and ca_name",
+			"Credential '/cert' at index 4: the certificate is not PEM encoded",
+			"Credential '/mismatch' at index 5: the private key does not match the public key",
+			"Credential '/signed' at index 6: the CA '/server-ca' could not be found in the file or on the server",
+			"Credential '/other' at index 7: the CA '/password' is not a certificate",
+			"Credential '/unknown' at index 8: type 'secret' is not supported",
+			"Credential '/empty' at index 9: value must contain a public_key or private_key",
+		}))
+	})
+
+	It("checks generation directives and modes", func() {
+		bulkImport := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+			{"name": "/ca", "type": "certificate", "mode": "no-overwrite", "generate": map[string]interface{}{"is_ca": true, "common_name": "ca"}},
+			{"name": "/leaf", "type": "certificate", "mode": "converge", "generate": map[string]interface{}{"ca": "/ca", "common_name": "leaf"}},
+			{"name": "/signed", "type": "certificate", "generate": map[string]interface{}{"ca": "/server-ca"}},
+			{"name": "/both", "type": "password", "value": "secret", "generate": map[string]interface{}{"length": 20}},
+			{"name": "/converge", "type": "password", "value": "secret", "mode": "converge"},
+			{"name": "/typo", "type": "rsa", "generate": map[string]interface{}{"key_lenght": 2048}},
+		}}
+
+		problems, err := bulkImport.Validate(func(name string) (bool, error) {
+			return false, nil
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		var messages []string
+		for _, problem := range problems {
+			messages = append(messages, problem.String())
+		}
+		Expect(messages).To(Equal([]string{
+			"Credential '/signed' at index 2: the CA '/server-ca' could not be found in the file or on the server",
+			"Credential '/both' at index 3: value and generate cannot both be provided",
+			"Credential '/converge' at index 4: the mode 'converge' can only be used with generate",
+			`Credential '/typo' at index 5: the generate parameters are invalid: json: unknown field "key_lenght"`,
+		}))
+	})
+
+	It("decodes generation parameters into the generate types", func() {
+		params, generated, err := models.ImportGenerationParameters(map[string]interface{}{
+			"type":     "user",
+			"generate": map[string]interface{}{"username": "admin", "length": 12, "exclude_upper": true},
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(generated).To(BeTrue())
+		Expect(params).To(Equal(generate.User{Username: "admin", Length: 12, ExcludeUpper: true}))
+
+		_, generated, err = models.ImportGenerationParameters(map[string]interface{}{"type": "password", "value": "secret"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(generated).To(BeFalse())
+	})
+
+	It("returns errors from looking up CAs", func() {
+		bulkImport := models.CredentialBulkImport{Credentials: []map[string]interface{}{
+			{"name": "/signed", "type": "certificate", "value": map[string]interface{}{"ca_name": "/server-ca", "certificate": leaf.Certificate}},
+		}}
+
+		_, err := bulkImport.Validate(func(name string) (bool, error) {
+			return false, errors.New("unauthorized")
+		})
+
+		Expect(err).To(MatchError("unauthorized"))
+	})
+})
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+)
+
+// NameRewrite renames credentials whose name matches its pattern
+type NameRewrite struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// NameRewrites are applied in order. Only the first matching rewrite renames a credential.
+type NameRewrites []NameRewrite
+
+// NewPrefixRewrite replaces the prefix from of matching names with to
+func NewPrefixRewrite(from, to string) NameRewrite {
+	return NameRewrite{
+		pattern:     regexp.MustCompile("^" + regexp.QuoteMeta(from)),
+		replacement: strings.Replace(to, "$", "$$", -1),
+	}
+}
+
+// NewRegexRewrite replaces the matches of pattern with replacement, which may refer to
+// submatches as in regexp.Regexp.ReplaceAllString
+func NewRegexRewrite(pattern, replacement string) (NameRewrite, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return NameRewrite{}, err
+	}
+	return NameRewrite{pattern: re, replacement: replacement}, nil
+}
+
+// Rewrite returns the name renamed by the first matching rewrite, or the name when none match
+func (r NameRewrites) Rewrite(name string) string {
+	for _, rewrite := range r {
+		if rewrite.pattern.MatchString(name) {
+			return rewrite.pattern.ReplaceAllString(name, rewrite.replacement)
+		}
+	}
+	return name
+}
+
+// RewriteCredentials renames the credentials and the CA names referenced by certificates
+func (r NameRewrites) RewriteCredentials(creds []credentials.Credential) {
+	for i := range creds {
+		creds[i].Name = r.Rewrite(creds[i].Name)
+		if creds[i].Type == "certificate" {
+			r.rewriteCANames(creds[i].Value)
+		}
+	}
+}
+
+// RewriteVersions rewrites the CA names referenced by the versions of certificates, which are
+// ordered like the credentials they belong to
+func (r NameRewrites) RewriteVersions(creds []credentials.Credential, versions [][]CredentialVersion) {
+	for i := range versions {
+		if i >= len(creds) || creds[i].Type != "certificate" {
+			continue
+		}
+		for _, version := range versions[i] {
+			r.rewriteCANames(version.Value)
+		}
+	}
+}
+
+// RewritePermissions rewrites the paths of the permissions
+func (r NameRewrites) RewritePermissions(permissions []PermissionEntry) {
+	for i := range permissions {
+		permissions[i].Path = r.Rewrite(permissions[i].Path)
+	}
+}
+
+// rewriteCANames rewrites ca_name and ca when it holds the name of the signing CA, as written by export
+func (r NameRewrites) rewriteCANames(value interface{}) {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if caName, ok := fields["ca_name"].(string); ok && caName != "" {
+		fields["ca_name"] = r.Rewrite(caName)
+	}
+	if ca, ok := fields["ca"].(string); ok && ca != "" && !strings.Contains(ca, "-----BEGIN") {
+		fields["ca"] = r.Rewrite(ca)
+	}
+}
+
+// RewriteNames renames the credentials, the CA names referenced by certificates, their versions
+// and generate directives, and the paths of the permissions of the import file
+func (credentialBulkImport *CredentialBulkImport) RewriteNames(rewrites NameRewrites) {
+	if len(rewrites) == 0 {
+		return
+	}
+
+	for _, credential := range credentialBulkImport.Credentials {
+		if name, ok := credential["name"].(string); ok {
+			credential["name"] = rewrites.Rewrite(name)
+		}
+
+		if credential["type"] != "certificate" {
+			continue
+		}
+		rewrites.rewriteCANames(credential["value"])
+		if versions, ok := credential["versions"].([]interface{}); ok {
+			for _, version := range versions {
+				if fields, ok := version.(map[string]interface{}); ok {
+					rewrites.rewriteCANames(fields["value"])
+				}
+			}
+		}
+		if params, ok := credential["generate"].(map[string]interface{}); ok {
+			if ca, ok := params["ca"].(string); ok && ca != "" {
+				params["ca"] = rewrites.Rewrite(ca)
+			}
+		}
+	}
+
+	rewrites.RewritePermissions(credentialBulkImport.Permissions)
+}
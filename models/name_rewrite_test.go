@@ -0,0 +1,110 @@
+package models_test
+
+import (
+	"code.cloudfoundry.org/credhub-cli/credhub/credentials"
+	"code.cloudfoundry.org/credhub-cli/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NameRewrites", func() {
+	var rewrites models.NameRewrites
+
+	BeforeEach(func() {
+		regexRewrite, err := models.NewRegexRewrite(`^/dev/([^/]+)/db/`, "/qa/$1/database/")
+		Expect(err).NotTo(HaveOccurred())
+
+		rewrites = models.NameRewrites{
+			models.NewPrefixRewrite("/dev/app/", "/qa/app$/"),
+			regexRewrite,
+		}
+	})
+
+	Describe("Rewrite", func() {
+		It("applies the first matching rewrite", func() {
+			Expect(rewrites.Rewrite("/dev/app/password")).To(Equal("/qa/app$/password"))
+			Expect(rewrites.Rewrite("/dev/billing/db/password")).To(Equal("/qa/billing/database/password"))
+		})
+
+		It("only replaces prefixes", func() {
+			Expect(rewrites.Rewrite("/other/dev/app/password")).To(Equal("/other/dev/app/password"))
+		})
+
+		It("returns names which match no rewrite unchanged", func() {
+			Expect(rewrites.Rewrite("/prod/app/password")).To(Equal("/prod/app/password"))
+		})
+	})
+
+	It("returns an error for invalid regular expressions", func() {
+		_, err := models.NewRegexRewrite("/dev/(app", "/qa/")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("RewriteCredentials", func() {
+		It("rewrites names and the ca names of certificates", func() {
+			creds := []credentials.Credential{
+				{
+					Base:  credentials.Base{Name: "/dev/app/leaf", Type: "certificate"},
+					Value: map[string]interface{}{"ca": "/dev/app/ca", "certificate": "some-cert"},
+				},
+				{
+					Base:  credentials.Base{Name: "/dev/app/ca", Type: "certificate"},
+					Value: map[string]interface{}{"ca": "-----BEGIN CERTIFICATE-----\n/dev/app/", "certificate": "some-cert"},
+				},
+			}
+
+			rewrites.RewriteCredentials(creds)
+
+			Expect(creds[0].Name).To(Equal("/qa/app$/leaf"))
+			Expect(creds[0].Value).To(HaveKeyWithValue("ca", "/qa/app$/ca"))
+			Expect(creds[1].Name).To(Equal("/qa/app$/ca"))
+			Expect(creds[1].Value).To(HaveKeyWithValue("ca", "-----BEGIN CERTIFICATE-----\n/dev/app/"))
+		})
+	})
+
+	Describe("RewriteVersions", func() {
+		It("rewrites the ca names of certificate versions", func() {
+			creds := []credentials.Credential{
+				{Base: credentials.Base{Name: "/dev/app/leaf", Type: "certificate"}},
+				{Base: credentials.Base{Name: "/dev/app/password", Type: "password"}},
+			}
+			versions := [][]models.CredentialVersion{
+				{{Value: map[string]interface{}{"ca_name": "/dev/app/ca"}}, {Value: map[string]interface{}{"ca_name": "/dev/app/old-ca"}}},
+				{{Value: "/dev/app/ca"}},
+			}
+
+			rewrites.RewriteVersions(creds, versions)
+
+			Expect(versions[0][0].Value).To(HaveKeyWithValue("ca_name", "/qa/app$/ca"))
+			Expect(versions[0][1].Value).To(HaveKeyWithValue("ca_name", "/qa/app$/old-ca"))
+			Expect(versions[1][0].Value).To(Equal("/dev/app/ca"))
+		})
+	})
+
+	Describe("RewriteNames", func() {
+		It("rewrites names, ca names of values and versions, generate CAs and permission paths", func() {
+			bulkImport := models.CredentialBulkImport{
+				Credentials: []map[string]interface{}{
+					{"name": "/dev/app/leaf", "type": "certificate", "value": map[string]interface{}{"ca_name": "/dev/app/ca"}, "versions": []interface{}{
+						map[string]interface{}{"value": map[string]interface{}{"ca_name": "/dev/app/old-ca"}},
+					}},
+					{"name": "/dev/app/generated", "type": "certificate", "generate": map[string]interface{}{"ca": "/dev/app/ca"}},
+					{"name": "/dev/billing/db/password", "type": "password", "value": "secret"},
+				},
+				Permissions: []models.PermissionEntry{
+					{Actor: "uaa-client:app", Path: "/dev/app/*", Operations: []string{"read"}},
+				},
+			}
+
+			bulkImport.RewriteNames(rewrites)
+
+			Expect(bulkImport.Credentials[0]).To(HaveKeyWithValue("name", "/qa/app$/leaf"))
+			Expect(bulkImport.Credentials[0]["value"]).To(HaveKeyWithValue("ca_name", "/qa/app$/ca"))
+			Expect(bulkImport.Credentials[0]["versions"].([]interface{})[0]).To(HaveKeyWithValue("value", HaveKeyWithValue("ca_name", "/qa/app$/old-ca")))
+			Expect(bulkImport.Credentials[1]["generate"]).To(HaveKeyWithValue("ca", "/qa/app$/ca"))
+			Expect(bulkImport.Credentials[2]).To(HaveKeyWithValue("name", "/qa/billing/database/password"))
+			Expect(bulkImport.Permissions[0].Path).To(Equal("/qa/app$/*"))
+		})
+	})
+})
@@ -0,0 +1,139 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHServer is an SSH server forwarding direct-tcpip channels, as used by jump hosts
+type SSHServer struct {
+	listener net.Listener
+	HostKey  ssh.Signer
+
+	mu          sync.Mutex
+	connections int
+}
+
+// StartSSHServer starts an SSH server on the loopback interface accepting clients of user
+// authenticating with authorizedKey
+func StartSSHServer(user string, authorizedKey ssh.PublicKey) *SSHServer {
+	hostKey := NewSSHKey()
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if c.User() == user && string(key.Marshal()) == string(authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	s := &SSHServer{listener: listener, HostKey: hostKey}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *SSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	s.mu.Lock()
+	s.connections++
+	s.mu.Unlock()
+
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		var target struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &target); err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		remote, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			remote.Close()
+			continue
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			io.Copy(channel, remote)
+			channel.Close()
+		}()
+		go func() {
+			io.Copy(remote, channel)
+			remote.Close()
+		}()
+	}
+}
+
+// Addr returns the address of the server
+func (s *SSHServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// KnownHostsLine returns the known_hosts entry of the server
+func (s *SSHServer) KnownHostsLine() string {
+	return knownhosts.Line([]string{knownhosts.Normalize(s.Addr())}, s.HostKey.PublicKey())
+}
+
+// Connections returns the number of clients which connected to the server
+func (s *SSHServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+// Close stops accepting clients
+func (s *SSHServer) Close() error {
+	return s.listener.Close()
+}
+
+// NewSSHKey returns a new RSA key for SSH
+func NewSSHKey() ssh.Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		panic(err)
+	}
+	return signer
+}
@@ -5,7 +5,7 @@ import (
 	"os"
 )
 
-var CREDHUB_ENV_VARS []string = []string{"CREDHUB_SERVER", "CREDHUB_CLIENT", "CREDHUB_SECRET", "CREDHUB_CA_CERT"}
+var CREDHUB_ENV_VARS []string = []string{"CREDHUB_SERVER", "CREDHUB_CLIENT", "CREDHUB_SECRET", "CREDHUB_CA_CERT", "CREDHUB_CLIENT_CERT", "CREDHUB_CLIENT_KEY", "CREDHUB_CLIENT_KEY_PASSPHRASE", "CREDHUB_CREDENTIAL_STORE", "CREDHUB_CREDENTIAL_STORE_PASSPHRASE", "CREDHUB_CREDENTIAL_HELPER", "CREDHUB_PROXY_KEY_PASSPHRASE", "CREDHUB_LOG_LEVEL", "CREDHUB_LOG_FILE", "CREDHUB_LOG_UNREDACTED"}
 
 func UnsetAndCacheCredHubEnvVars() map[string]string {
 	credhubEnv := make(map[string]string)
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// link publishes files without replacing existing ones; it is replaced in tests
+var link = os.Link
+
+// WriteFileAtomically writes data to a temporary file next to path, syncs it and renames it to
+// path, so that path either keeps its previous content or contains all of data. The file is
+// created with perm and the directory is synced after the rename. Unless overwrite is set, the
+// temporary file is linked to path instead, which fails with an error satisfying os.IsExist when
+// path exists, even if another process creates it concurrently. On filesystems without hard
+// links, path is created exclusively and written directly, which is not atomic.
+func WriteFileAtomically(path string, data []byte, perm os.FileMode, overwrite bool) (err error) {
+	// fail early without writing data; linking the file below is what guarantees path is not replaced
+	if !overwrite {
+		if _, err := os.Lstat(path); err == nil {
+			return &os.PathError{Op: "write", Path: path, Err: os.ErrExist}
+		}
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		// report the file that was requested rather than the temporary one
+		if pathErr, ok := err.(*os.PathError); ok {
+			return &os.PathError{Op: "open", Path: path, Err: pathErr.Err}
+		}
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	if overwrite {
+		if err = os.Rename(tmp.Name(), path); err != nil {
+			return err
+		}
+	} else {
+		if err = link(tmp.Name(), path); err != nil {
+			if linkErr, ok := err.(*os.LinkError); ok {
+				err = &os.PathError{Op: "write", Path: path, Err: linkErr.Err}
+			}
+			if os.IsExist(err) {
+				return err
+			}
+			// the filesystem does not support hard links
+			if err = writeFileExclusively(path, data, perm); err != nil {
+				return err
+			}
+		}
+		os.Remove(tmp.Name())
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// writeFileExclusively creates path, failing when it exists, and writes data to it. A partially
+// written file is removed.
+func writeFileExclusively(path string, data []byte, perm os.FileMode) (err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(path)
+		}
+	}()
+
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir persists a rename in dir. Directories cannot be synced on every platform, so
+// failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,116 @@
+package util_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+
+	"code.cloudfoundry.org/credhub-cli/test"
+	"code.cloudfoundry.org/credhub-cli/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("#WriteFileAtomically", func() {
+	var tempDir, path string
+
+	BeforeEach(func() {
+		tempDir = test.CreateTempDir("atomicFile")
+		path = filepath.Join(tempDir, "export.yml")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tempDir)).To(Succeed())
+	})
+
+	It("writes the file with the given permissions and leaves no temporary file", func() {
+		Expect(util.WriteFileAtomically(path, []byte("data"), 0600, false)).To(Succeed())
+
+		Expect(ioutil.ReadFile(path)).To(Equal([]byte("data")))
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		}
+
+		files, err := ioutil.ReadDir(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	It("does not replace an existing file unless overwriting", func() {
+		Expect(ioutil.WriteFile(path, []byte("existing"), 0600)).To(Succeed())
+
+		err := util.WriteFileAtomically(path, []byte("data"), 0600, false)
+		Expect(os.IsExist(err)).To(BeTrue())
+		Expect(ioutil.ReadFile(path)).To(Equal([]byte("existing")))
+
+		Expect(util.WriteFileAtomically(path, []byte("data"), 0600, true)).To(Succeed())
+		Expect(ioutil.ReadFile(path)).To(Equal([]byte("data")))
+	})
+
+	It("does not replace a file created concurrently unless overwriting", func() {
+		const writers = 8
+		results := make(chan error, writers)
+		for i := 0; i < writers; i++ {
+			go func(i int) {
+				defer GinkgoRecover()
+				results <- util.WriteFileAtomically(path, []byte{byte('a' + i)}, 0600, false)
+			}(i)
+		}
+
+		var written []byte
+		for i := 0; i < writers; i++ {
+			if err := <-results; err == nil {
+				Expect(written).To(BeEmpty())
+				written, _ = ioutil.ReadFile(path)
+			} else {
+				Expect(os.IsExist(err)).To(BeTrue())
+			}
+		}
+		Expect(ioutil.ReadFile(path)).To(Equal(written))
+
+		files, err := ioutil.ReadDir(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+	})
+
+	Context("when the filesystem does not support hard links", func() {
+		var restore func()
+
+		BeforeEach(func() {
+			restore = util.StubLink(func(oldname, newname string) error {
+				return &os.LinkError{Op: "link", Old: oldname, New: newname, Err: syscall.EPERM}
+			})
+		})
+
+		AfterEach(func() {
+			restore()
+		})
+
+		It("writes the file directly without replacing an existing file", func() {
+			Expect(util.WriteFileAtomically(path, []byte("data"), 0600, false)).To(Succeed())
+			Expect(ioutil.ReadFile(path)).To(Equal([]byte("data")))
+
+			err := util.WriteFileAtomically(path, []byte("other"), 0600, false)
+			Expect(os.IsExist(err)).To(BeTrue())
+			Expect(ioutil.ReadFile(path)).To(Equal([]byte("data")))
+
+			files, err := ioutil.ReadDir(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+		})
+	})
+
+	It("returns an error and leaves no file when the directory does not exist", func() {
+		err := util.WriteFileAtomically(filepath.Join(tempDir, "missing", "export.yml"), []byte("data"), 0600, false)
+		Expect(err).To(HaveOccurred())
+
+		files, err := ioutil.ReadDir(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(files).To(BeEmpty())
+	})
+})
@@ -1,7 +1,11 @@
 package util
 
 import (
+	"fmt"
 	"io/ioutil"
+	"regexp"
+	"strconv"
+	"time"
 
 	"strings"
 
@@ -31,3 +35,44 @@ func AddDefaultSchemeIfNecessary(serverUrl string) string {
 		return "https://" + serverUrl
 	}
 }
+
+var dayUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+var leadingDayUnit = regexp.MustCompile(`^(\d+)([dw])`)
+
+// ParseDuration extends time.ParseDuration with day ("d") and week ("w") units,
+// eg. "90d", "2w" or "1d12h". Day and week units must precede the standard units.
+// Only positive durations are accepted.
+func ParseDuration(s string) (time.Duration, error) {
+	var duration time.Duration
+
+	rest := s
+	for {
+		match := leadingDayUnit.FindStringSubmatch(rest)
+		if match == nil {
+			break
+		}
+		count, err := strconv.Atoi(match[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		duration += time.Duration(count) * dayUnits[match[2]]
+		rest = rest[len(match[0]):]
+	}
+
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		duration += d
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("invalid duration %q, the duration must be positive", s)
+	}
+	return duration, nil
+}
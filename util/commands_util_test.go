@@ -9,6 +9,7 @@ import (
 	. "github.com/onsi/gomega"
 
 	"runtime"
+	"time"
 
 	credhub_errors "code.cloudfoundry.org/credhub-cli/errors"
 	"code.cloudfoundry.org/credhub-cli/test"
@@ -69,4 +70,38 @@ var _ = Describe("Util", func() {
 			Expect(transformedUrl).To(Equal("ftp://foo.com:8080"))
 		})
 	})
+	Describe("#ParseDuration", func() {
+		It("parses day and week units", func() {
+			Expect(util.ParseDuration("90d")).To(Equal(90 * 24 * time.Hour))
+			Expect(util.ParseDuration("2w")).To(Equal(14 * 24 * time.Hour))
+		})
+
+		It("parses standard durations", func() {
+			Expect(util.ParseDuration("1h30m")).To(Equal(90 * time.Minute))
+		})
+
+		It("parses day and week units combined with standard units", func() {
+			Expect(util.ParseDuration("1d12h")).To(Equal(36 * time.Hour))
+			Expect(util.ParseDuration("1w2d")).To(Equal(9 * 24 * time.Hour))
+		})
+
+		It("rejects durations that are not positive", func() {
+			_, err := util.ParseDuration("-3d")
+			Expect(err).To(MatchError(`invalid duration "-3d"`))
+
+			_, err = util.ParseDuration("-1h")
+			Expect(err).To(MatchError(`invalid duration "-1h", the duration must be positive`))
+
+			_, err = util.ParseDuration("0d")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error for invalid durations", func() {
+			_, err := util.ParseDuration("1.5d")
+			Expect(err).To(MatchError(`invalid duration "1.5d"`))
+
+			_, err = util.ParseDuration("soon")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,8 @@
+package util
+
+// StubLink replaces the function linking files and returns a function restoring it
+func StubLink(stub func(oldname, newname string) error) func() {
+	original := link
+	link = stub
+	return func() { link = original }
+}